@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	p "lautenbacher.net/goleds/producer"
+)
+
+// AlertRequest is the JSON form of an alert in /api/alert. Period is a
+// duration like "500ms", empty fields take the defaults of the AlertLED
+// config.
+type AlertRequest struct {
+	Pattern  string    `json:"Pattern"`
+	LedRGB   []float64 `json:"LedRGB"`
+	Repeat   int       `json:"Repeat"`
+	Period   string    `json:"Period"`
+	Priority int       `json:"Priority"`
+}
+
+// alertHandler lists the pending alerts (GET), enqueues an alert
+// (POST) or removes all alerts (DELETE).
+func (a *App) alertHandler(w http.ResponseWriter, r *http.Request) {
+	alerts := a.alerts.Load()
+	if alerts == nil {
+		http.Error(w, "AlertLED is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		pending := alerts.Pending()
+		resp := make([]AlertRequest, len(pending))
+		for i, alert := range pending {
+			resp[i] = AlertRequest{Pattern: alert.Pattern, LedRGB: alert.LedRGB, Repeat: alert.Repeat,
+				Period: alert.Period.String(), Priority: alert.Priority}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			slog.Error("Failed to encode alerts to JSON", "error", err)
+		}
+	case http.MethodPost:
+		var req AlertRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		alert := p.Alert{Pattern: req.Pattern, LedRGB: req.LedRGB, Repeat: req.Repeat, Priority: req.Priority}
+		if req.Period != "" {
+			period, err := time.ParseDuration(req.Period)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Invalid Period: %v", err), http.StatusBadRequest)
+				return
+			}
+			alert.Period = period
+		}
+		if err := alerts.Enqueue(alert); errors.Is(err, p.ErrAlertQueueFull) {
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
+		} else if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid alert: %v", err), http.StatusBadRequest)
+			return
+		}
+		slog.Info("Alert enqueued", "pattern", alert.Pattern, "priority", alert.Priority)
+		w.WriteHeader(http.StatusOK)
+	case http.MethodDelete:
+		slog.Info("Clearing all alerts")
+		alerts.Clear()
+		w.WriteHeader(http.StatusOK)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	c "lautenbacher.net/goleds/config"
+	p "lautenbacher.net/goleds/producer"
+	u "lautenbacher.net/goleds/util"
+)
+
+func TestAlertHandler(t *testing.T) {
+	app := NewApp(make(chan os.Signal, 1))
+
+	rec := httptest.NewRecorder()
+	app.alertHandler(rec, httptest.NewRequest(http.MethodPost, "/api/alert", bytes.NewBufferString(`{}`)))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d without AlertLED, got %d", http.StatusServiceUnavailable, rec.Code)
+	}
+
+	cfg := c.AlertLEDConfig{Enabled: true, Delay: 50 * time.Millisecond, Period: time.Second, Repeat: 3,
+		LedRGB: []float64{255, 0, 0}, MaxQueue: 2}
+	// The producer isn't started, so the alerts stay in the queue.
+	app.alerts.Store(p.NewAlertProducer(ALERT_LED_UID, u.NewAtomicMapEvent[p.LedProducer](), 10, cfg))
+
+	tests := []struct {
+		body       string
+		wantStatus int
+	}{
+		{`{"Pattern": "Pulse", "LedRGB": [0, 0, 255], "Period": "500ms", "Priority": 2}`, http.StatusOK},
+		{`{"Pattern": "Strobe"}`, http.StatusBadRequest},
+		{`{"Period": "soon"}`, http.StatusBadRequest},
+		{`not json`, http.StatusBadRequest},
+		{`{}`, http.StatusOK},
+		{`{}`, http.StatusTooManyRequests},
+	}
+	for _, tc := range tests {
+		rec := httptest.NewRecorder()
+		app.alertHandler(rec, httptest.NewRequest(http.MethodPost, "/api/alert", bytes.NewBufferString(tc.body)))
+		if rec.Code != tc.wantStatus {
+			t.Errorf("%s: expected status %d, got %d", tc.body, tc.wantStatus, rec.Code)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	app.alertHandler(rec, httptest.NewRequest(http.MethodGet, "/api/alert", nil))
+	var pending []AlertRequest
+	if err := json.NewDecoder(rec.Body).Decode(&pending); err != nil {
+		t.Fatalf("Failed to decode pending alerts: %v", err)
+	}
+	if len(pending) != 2 || pending[0].Pattern != "Pulse" || pending[0].Period != "500ms" || pending[1].Repeat != 3 {
+		t.Errorf("Unexpected pending alerts %+v", pending)
+	}
+
+	rec = httptest.NewRecorder()
+	app.alertHandler(rec, httptest.NewRequest(http.MethodDelete, "/api/alert", nil))
+	if rec.Code != http.StatusOK || len(app.alerts.Load().Pending()) != 0 {
+		t.Errorf("Expected no alerts after DELETE, got status %d and %+v", rec.Code, app.alerts.Load().Pending())
+	}
+
+	rec = httptest.NewRecorder()
+	app.alertHandler(rec, httptest.NewRequest(http.MethodPut, "/api/alert", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d for PUT, got %d", http.StatusMethodNotAllowed, rec.Code)
+	}
+}
+
+// TestCombineLeds_AlertOverlay checks that lit alert LEDs replace the
+// combined LEDs of the other producers instead of being merged.
+func TestCombineLeds_AlertOverlay(t *testing.T) {
+	app := NewApp(make(chan os.Signal, 1))
+	ranges := map[string][]p.Led{
+		NIGHT_LED_UID: {{Red: 100}, {Red: 100}},
+		ALERT_LED_UID: {{Blue: 10}, {}},
+	}
+	target := make([]p.Led, 2)
+	app.combineLeds(ranges, target)
+	if target[0] != (p.Led{Blue: 10}) || target[1] != (p.Led{Red: 100}) {
+		t.Errorf("Expected the alert above the night light, got %+v", target)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+)
+
+// BrightnessRequest is the JSON body of /api/brightness.
+type BrightnessRequest struct {
+	Brightness float64 `json:"Brightness"`
+}
+
+// brightnessHandler returns (GET) or sets (POST) the master
+// brightness, a factor between 0 and 1 applied to all LEDs. It is not
+// saved to the config file.
+func (a *App) brightnessHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(BrightnessRequest{Brightness: a.brightness.Value()}); err != nil {
+			slog.Error("Failed to encode brightness to JSON", "error", err)
+		}
+	case http.MethodPost:
+		var req BrightnessRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if req.Brightness < 0 || req.Brightness > 1 {
+			http.Error(w, fmt.Sprintf("Brightness must be between 0 and 1, got %v", req.Brightness), http.StatusBadRequest)
+			return
+		}
+		slog.Info("Setting master brightness", "brightness", req.Brightness)
+		a.brightness.Send(req.Brightness)
+		w.WriteHeader(http.StatusOK)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
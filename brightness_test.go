@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	p "lautenbacher.net/goleds/producer"
+	u "lautenbacher.net/goleds/util"
+)
+
+func TestBrightnessHandler(t *testing.T) {
+	app := NewApp(make(chan os.Signal, 1))
+
+	rec := httptest.NewRecorder()
+	app.brightnessHandler(rec, httptest.NewRequest(http.MethodGet, "/api/brightness", nil))
+	var resp BrightnessRequest
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || resp.Brightness != 1 {
+		t.Errorf("Expected initial brightness 1, got %+v (%v)", resp, err)
+	}
+
+	tests := []struct {
+		body       string
+		wantStatus int
+	}{
+		{`{"Brightness": 0.25}`, http.StatusOK},
+		{`{"Brightness": 1.5}`, http.StatusBadRequest},
+		{`{"Brightness": -0.1}`, http.StatusBadRequest},
+		{`not json`, http.StatusBadRequest},
+	}
+	for _, tc := range tests {
+		rec := httptest.NewRecorder()
+		app.brightnessHandler(rec, httptest.NewRequest(http.MethodPost, "/api/brightness", bytes.NewBufferString(tc.body)))
+		if rec.Code != tc.wantStatus {
+			t.Errorf("%s: expected status %d, got %d", tc.body, tc.wantStatus, rec.Code)
+		}
+	}
+	if brightness := app.brightness.Value(); brightness != 0.25 {
+		t.Errorf("Expected brightness 0.25 after the invalid requests, got %v", brightness)
+	}
+
+	rec = httptest.NewRecorder()
+	app.brightnessHandler(rec, httptest.NewRequest(http.MethodPut, "/api/brightness", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d for PUT, got %d", http.StatusMethodNotAllowed, rec.Code)
+	}
+}
+
+// TestMasterBrightness checks that a brightness change is applied to
+// the current frame right away.
+func TestMasterBrightness(t *testing.T) {
+	app := NewApp(make(chan os.Signal, 1))
+	app.stopsignal = make(chan struct{})
+	mockPlatform := NewMockPlatform()
+	app.platform = mockPlatform
+
+	prod := NewMockLedProducer("prod", nil)
+	prod.leds = []p.Led{{Red: 100, Green: 40}}
+	ledReader := u.NewAtomicMapEvent[p.LedProducer]()
+	ledBufferPool := &sync.Pool{New: func() any { return make([]p.Led, 10) }}
+
+	app.shutdownWg.Add(1)
+	go app.combineAndUpdateDisplay(ledReader, ledBufferPool)
+	t.Cleanup(func() {
+		close(app.stopsignal)
+		app.shutdownWg.Wait()
+	})
+
+	lastFirstLed := func(expected p.Led) {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for time.Now().Before(deadline) {
+			if frames := mockPlatform.GetLastLeds(); len(frames) > 0 && frames[len(frames)-1][0] == expected {
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		t.Fatalf("Expected first LED %+v, got frames %+v", expected, mockPlatform.GetLastLeds())
+	}
+
+	ledReader.Send(prod.GetUID(), prod)
+	lastFirstLed(p.Led{Red: 100, Green: 40})
+
+	app.brightness.Send(0.5)
+	lastFirstLed(p.Led{Red: 50, Green: 20})
+}
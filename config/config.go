@@ -4,11 +4,16 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"maps"
+	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
+	u "lautenbacher.net/goleds/util"
 )
 
 const CONFILE = "config.yml"
@@ -26,6 +31,26 @@ func validateRGB(rgb []float64) error {
 	return nil
 }
 
+// validateColorPoints checks a gradient given as [value, red, green,
+// blue] points with increasing values.
+func validateColorPoints(points [][]float64) error {
+	if len(points) == 0 {
+		return fmt.Errorf("needs at least one point")
+	}
+	for i, point := range points {
+		if len(point) != 4 {
+			return fmt.Errorf("point %d must be a [value, red, green, blue] point", i)
+		}
+		if err := validateRGB(point[1:]); err != nil {
+			return fmt.Errorf("point %d invalid: %w", i, err)
+		}
+		if i > 0 && point[0] <= points[i-1][0] {
+			return fmt.Errorf("the values must be increasing")
+		}
+	}
+	return nil
+}
+
 // isValidIndex checks if an index is within the valid range [0, ledsTotal).
 func isValidIndex(index, ledsTotal int) bool {
 	return index >= 0 && index < ledsTotal
@@ -37,12 +62,18 @@ type SensorLEDConfig struct {
 	RunUpDelay        time.Duration `yaml:"RunUpDelay"`
 	RunDownDelay      time.Duration `yaml:"RunDownDelay"`
 	HoldTime          time.Duration `yaml:"HoldTime"`
-	LedRGB            []float64     `yaml:"LedRGB,flow"`
+	LedRGB            []float64     `yaml:"LedRGB,flow" palette:"color"`
 	LatchEnabled      bool          `yaml:"LatchEnabled"`
 	LatchTriggerValue int           `yaml:"LatchTriggerValue"`
 	LatchTriggerDelay time.Duration `yaml:"LatchTriggerDelay"`
 	LatchTime         time.Duration `yaml:"LatchTime"`
-	LatchLedRGB       []float64     `yaml:"LatchLedRGB,flow"`
+	LatchLedRGB       []float64     `yaml:"LatchLedRGB,flow" palette:"color"`
+	// RunUpSpeed and RunDownSpeed are the metres per second the light
+	// spreads and contracts with Display.Positions, RunUpDelay and
+	// RunDownDelay are then the time between two frames. 0 keeps one
+	// LED per delay.
+	RunUpSpeed   float64 `yaml:"RunUpSpeed"`
+	RunDownSpeed float64 `yaml:"RunDownSpeed"`
 }
 
 func (c *SensorLEDConfig) Validate() error {
@@ -71,6 +102,12 @@ func (c *SensorLEDConfig) Validate() error {
 	if err := validateRGB(c.LatchLedRGB); err != nil {
 		return fmt.Errorf("LatchLedRGB invalid: %w", err)
 	}
+	if c.RunUpSpeed < 0 || c.RunDownSpeed < 0 {
+		return fmt.Errorf("RunUpSpeed and RunDownSpeed must be non-negative")
+	}
+	if (c.RunUpSpeed > 0 && c.RunUpDelay <= 0) || (c.RunDownSpeed > 0 && c.RunDownDelay <= 0) {
+		return fmt.Errorf("RunUpDelay and RunDownDelay must be positive with a speed")
+	}
 
 	return nil
 }
@@ -80,7 +117,18 @@ type NightLEDConfig struct {
 	Enabled   bool        `yaml:"Enabled"`
 	Latitude  float64     `yaml:"Latitude"`
 	Longitude float64     `yaml:"Longitude"`
-	LedRGB    [][]float64 `yaml:"LedRGB,flow"`
+	LedRGB    [][]float64 `yaml:"LedRGB,flow" palette:"color"`
+	// Stops, if given, replace LedRGB with a gradient between colour
+	// stops anchored to clock times or astronomical events.
+	Stops []NightLEDStop `yaml:"Stops"`
+}
+
+// NightLEDStop is a colour stop of the NightLED gradient. At is a
+// clock time ("22:30") or an astronomical event with an optional
+// offset ("civil-dusk+15m"), see util.ParseTimeAnchor.
+type NightLEDStop struct {
+	At     string    `yaml:"At"`
+	LedRGB []float64 `yaml:"LedRGB,flow" palette:"color"`
 }
 
 func (c *NightLEDConfig) Validate() error {
@@ -95,6 +143,17 @@ func (c *NightLEDConfig) Validate() error {
 			return fmt.Errorf("LedRGB[%d] invalid: %w", i, err)
 		}
 	}
+	if len(c.Stops) == 1 {
+		return fmt.Errorf("Stops needs at least two entries")
+	}
+	for i, stop := range c.Stops {
+		if _, err := u.ParseTimeAnchor(stop.At); err != nil {
+			return fmt.Errorf("Stops[%d].At invalid: %w", i, err)
+		}
+		if err := validateRGB(stop.LedRGB); err != nil {
+			return fmt.Errorf("Stops[%d].LedRGB invalid: %w", i, err)
+		}
+	}
 	return nil
 }
 
@@ -105,8 +164,8 @@ type ClockLEDConfig struct {
 	EndLedHour     int       `yaml:"EndLedHour"`
 	StartLedMinute int       `yaml:"StartLedMinute"`
 	EndLedMinute   int       `yaml:"EndLedMinute"`
-	LedHour        []float64 `yaml:"LedHour,flow"`
-	LedMinute      []float64 `yaml:"LedMinute,flow"`
+	LedHour        []float64 `yaml:"LedHour,flow" palette:"color"`
+	LedMinute      []float64 `yaml:"LedMinute,flow" palette:"color"`
 }
 
 func (c *ClockLEDConfig) Validate(ledsTotal int) error {
@@ -145,14 +204,143 @@ type AudioLEDConfig struct {
 	EndLedLeft      int           `yaml:"EndLedLeft"`
 	StartLedRight   int           `yaml:"StartLedRight"`
 	EndLedRight     int           `yaml:"EndLedRight"`
-	LedGreen        []float64     `yaml:"LedGreen,flow"`
-	LedYellow       []float64     `yaml:"LedYellow,flow"`
-	LedRed          []float64     `yaml:"LedRed,flow"`
+	LedGreen        []float64     `yaml:"LedGreen,flow" palette:"color"`
+	LedYellow       []float64     `yaml:"LedYellow,flow" palette:"color"`
+	LedRed          []float64     `yaml:"LedRed,flow" palette:"color"`
 	SampleRate      int           `yaml:"SampleRate"`
 	FramesPerBuffer int           `yaml:"FramesPerBuffer"`
 	UpdateFreq      time.Duration `yaml:"UpdateFreq"`
 	MinDB           float64       `yaml:"MinDB"`
 	MaxDB           float64       `yaml:"MaxDB"`
+	// Source selects the audio input: "PortAudio" (default, the device
+	// matching Device), "File" (WAV or raw PCM, looped), "Stdin",
+	// "Command" (raw PCM from its stdout) or "TestSignal".
+	Source  string   `yaml:"Source"`
+	File    string   `yaml:"File"`
+	Command []string `yaml:"Command,flow"`
+	// PCMFormat ("S16LE" or "F32LE") and Channels (1 or 2) describe raw
+	// PCM input, WAV files bring their own format.
+	PCMFormat string            `yaml:"PCMFormat"`
+	Channels  int               `yaml:"Channels"`
+	Signal    AudioSignalConfig `yaml:"Signal"`
+	// Ballistics ("None", "VU" or "PPM") sets the attack and release time
+	// constants of the VU meter bars, Attack and Release override them.
+	Ballistics string        `yaml:"Ballistics"`
+	Attack     time.Duration `yaml:"Attack"`
+	Release    time.Duration `yaml:"Release"`
+	// PeakLedRGB enables a peak marker, held for PeakHold and then
+	// falling by PeakFallOff (full scale per second).
+	PeakLedRGB  []float64     `yaml:"PeakLedRGB,flow" palette:"color"`
+	PeakHold    time.Duration `yaml:"PeakHold"`
+	PeakFallOff float64       `yaml:"PeakFallOff"`
+	// Layout of the bars: "Linear" (default), "CentreOut" or "Mirror".
+	Layout string `yaml:"Layout"`
+	// Mono shows the mean of both channels on one bar across both ranges.
+	Mono bool `yaml:"Mono"`
+	// Mode is "VU" (default) or "Spectrum".
+	Mode     string              `yaml:"Mode"`
+	Spectrum AudioSpectrumConfig `yaml:"Spectrum"`
+	Beat     BeatConfig          `yaml:"Beat"`
+}
+
+// BeatConfig defines the beat detection on the audio input of the
+// AudioLED producer. Other producers can follow the detected beats.
+type BeatConfig struct {
+	Enabled bool `yaml:"Enabled"`
+	// Sensitivity is the factor by which the bass energy of a buffer
+	// must exceed its recent average to count as a beat.
+	Sensitivity float64       `yaml:"Sensitivity"`
+	MinInterval time.Duration `yaml:"MinInterval"`
+	// FlashLedRGB optionally flashes the AudioLED ranges on every beat,
+	// fading out within FlashDuration.
+	FlashLedRGB   []float64     `yaml:"FlashLedRGB,flow" palette:"color"`
+	FlashDuration time.Duration `yaml:"FlashDuration"`
+}
+
+func (c *BeatConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Sensitivity <= 1 {
+		return fmt.Errorf("Sensitivity must be greater than 1")
+	}
+	if c.MinInterval < 0 {
+		return fmt.Errorf("MinInterval must be non-negative")
+	}
+	if len(c.FlashLedRGB) > 0 {
+		if err := validateRGB(c.FlashLedRGB); err != nil {
+			return fmt.Errorf("FlashLedRGB invalid: %w", err)
+		}
+		if c.FlashDuration <= 0 {
+			return fmt.Errorf("FlashDuration must be positive")
+		}
+	}
+	return nil
+}
+
+// AudioSignalConfig defines the generated test signal: a stereo sine
+// tone (the right channel at half the amplitude) with a bass drum kick
+// at BPM, if BPM isn't 0.
+type AudioSignalConfig struct {
+	Frequency float64 `yaml:"Frequency"`
+	Amplitude float64 `yaml:"Amplitude"`
+	BPM       float64 `yaml:"BPM"`
+}
+
+func (c *AudioSignalConfig) Validate(sampleRate int) error {
+	if c.Frequency <= 0 || c.Frequency >= float64(sampleRate)/2 {
+		return fmt.Errorf("Frequency must be positive and less than half the SampleRate")
+	}
+	if c.Amplitude < 0 || c.Amplitude > 1 {
+		return fmt.Errorf("Amplitude must be between 0 and 1")
+	}
+	if c.BPM < 0 {
+		return fmt.Errorf("BPM must be non-negative")
+	}
+	return nil
+}
+
+// AudioSpectrumConfig defines the spectrum analyser mode of the
+// AudioLED producer.
+type AudioSpectrumConfig struct {
+	Bands    int     `yaml:"Bands"`
+	MinFreq  float64 `yaml:"MinFreq"`
+	MaxFreq  float64 `yaml:"MaxFreq"`
+	LogScale bool    `yaml:"LogScale"`
+	// PeakHold is how long a band keeps its peak level before it
+	// falls off by FallOff (full scale per second).
+	PeakHold time.Duration `yaml:"PeakHold"`
+	FallOff  float64       `yaml:"FallOff"`
+	// Palette is a list of RGB colours, interpolated from the lowest
+	// to the highest band.
+	Palette [][]float64 `yaml:"Palette,flow" palette:"color"`
+}
+
+func (c *AudioSpectrumConfig) Validate(sampleRate, minSegmentLen int) error {
+	if c.Bands <= 0 {
+		return fmt.Errorf("Bands must be positive")
+	}
+	if c.Bands > minSegmentLen {
+		return fmt.Errorf("Bands (%d) must not exceed the number of LEDs per channel (%d)", c.Bands, minSegmentLen)
+	}
+	if c.MinFreq <= 0 || c.MaxFreq <= c.MinFreq {
+		return fmt.Errorf("MinFreq must be positive and less than MaxFreq")
+	}
+	if c.MaxFreq > float64(sampleRate)/2 {
+		return fmt.Errorf("MaxFreq must not exceed half the SampleRate")
+	}
+	if c.PeakHold < 0 || c.FallOff < 0 {
+		return fmt.Errorf("PeakHold and FallOff must be non-negative")
+	}
+	if len(c.Palette) == 0 {
+		return fmt.Errorf("Palette must have at least one colour")
+	}
+	for i, rgb := range c.Palette {
+		if err := validateRGB(rgb); err != nil {
+			return fmt.Errorf("Palette[%d] invalid: %w", i, err)
+		}
+	}
+	return nil
 }
 
 func (c *AudioLEDConfig) Validate(ledsTotal int) error {
@@ -195,6 +383,73 @@ func (c *AudioLEDConfig) Validate(ledsTotal int) error {
 	if c.MinDB >= c.MaxDB {
 		return fmt.Errorf("MinDB (%f) must be less than MaxDB (%f)", c.MinDB, c.MaxDB)
 	}
+	switch strings.ToUpper(c.Source) {
+	case "", "PORTAUDIO", "STDIN":
+	case "FILE":
+		if c.File == "" {
+			return fmt.Errorf("File must be set for the File source")
+		}
+	case "COMMAND":
+		if len(c.Command) == 0 {
+			return fmt.Errorf("Command must be set for the Command source")
+		}
+	case "TESTSIGNAL":
+		if err := c.Signal.Validate(c.SampleRate); err != nil {
+			return fmt.Errorf("Signal invalid: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown Source %q, must be PortAudio, File, Stdin, Command or TestSignal", c.Source)
+	}
+	switch strings.ToUpper(c.Source) {
+	case "STDIN", "COMMAND":
+		if c.Channels != 1 && c.Channels != 2 {
+			return fmt.Errorf("Channels must be 1 or 2 for raw PCM input")
+		}
+	default:
+		if c.Channels < 0 || c.Channels > 2 {
+			return fmt.Errorf("Channels must be 1 or 2")
+		}
+	}
+	switch strings.ToUpper(c.PCMFormat) {
+	case "", "S16LE", "F32LE":
+	default:
+		return fmt.Errorf("unknown PCMFormat %q, must be S16LE or F32LE", c.PCMFormat)
+	}
+	switch strings.ToUpper(c.Ballistics) {
+	case "", "NONE", "VU", "PPM":
+	default:
+		return fmt.Errorf("unknown Ballistics %q, must be None, VU or PPM", c.Ballistics)
+	}
+	if c.Attack < 0 || c.Release < 0 {
+		return fmt.Errorf("Attack and Release must be non-negative")
+	}
+	if len(c.PeakLedRGB) > 0 {
+		if err := validateRGB(c.PeakLedRGB); err != nil {
+			return fmt.Errorf("PeakLedRGB invalid: %w", err)
+		}
+	}
+	if c.PeakHold < 0 || c.PeakFallOff < 0 {
+		return fmt.Errorf("PeakHold and PeakFallOff must be non-negative")
+	}
+	switch strings.ToUpper(c.Layout) {
+	case "", "LINEAR", "CENTREOUT", "MIRROR":
+	default:
+		return fmt.Errorf("unknown Layout %q, must be Linear, CentreOut or Mirror", c.Layout)
+	}
+	switch strings.ToUpper(c.Mode) {
+	case "", "VU":
+	case "SPECTRUM":
+		segmentLen := func(start, end int) int { return max(start, end) - min(start, end) + 1 }
+		minSegmentLen := min(segmentLen(c.StartLedLeft, c.EndLedLeft), segmentLen(c.StartLedRight, c.EndLedRight))
+		if err := c.Spectrum.Validate(c.SampleRate, minSegmentLen); err != nil {
+			return fmt.Errorf("Spectrum invalid: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown Mode %q, must be VU or Spectrum", c.Mode)
+	}
+	if err := c.Beat.Validate(); err != nil {
+		return fmt.Errorf("Beat invalid: %w", err)
+	}
 	return nil
 }
 
@@ -205,7 +460,21 @@ type CylonLEDConfig struct {
 	Delay    time.Duration `yaml:"Delay"`
 	Step     float64       `yaml:"Step"`
 	Width    int           `yaml:"Width"`
-	LedRGB   []float64     `yaml:"LedRGB,flow"`
+	LedRGB   []float64     `yaml:"LedRGB,flow" palette:"color"`
+	// BeatSync scales the speed by the tempo of the audio input
+	// (BPM/120), it needs AudioLED.Beat.
+	BeatSync bool `yaml:"BeatSync"`
+	// Eyes is the number of eyes, 0 means one.
+	Eyes int `yaml:"Eyes"`
+	// HueShift turns the hue of an eye by this many degrees at every
+	// bounce.
+	HueShift float64 `yaml:"HueShift"`
+	// Trail is the length in LEDs of the fading trail behind the eyes,
+	// 0 means no trail.
+	Trail float64 `yaml:"Trail"`
+	// Easing is Linear (the default), Sine or Cubic, the latter slow
+	// the eyes down at the ends.
+	Easing string `yaml:"Easing"`
 }
 
 func (c *CylonLEDConfig) Validate(ledsTotal int) error {
@@ -227,6 +496,23 @@ func (c *CylonLEDConfig) Validate(ledsTotal int) error {
 	if err := validateRGB(c.LedRGB); err != nil {
 		return fmt.Errorf("LedRGB invalid: %w", err)
 	}
+	if c.Eyes < 0 {
+		return fmt.Errorf("Eyes must be non-negative")
+	}
+	if ledsTotal > 0 && c.Eyes*c.Width > ledsTotal {
+		return fmt.Errorf("%d eyes of Width %d don't fit into LedsTotal (%d)", c.Eyes, c.Width, ledsTotal)
+	}
+	if c.HueShift < -360 || c.HueShift > 360 {
+		return fmt.Errorf("HueShift must be between -360 and 360")
+	}
+	if c.Trail < 0 {
+		return fmt.Errorf("Trail must be non-negative")
+	}
+	switch strings.ToUpper(c.Easing) {
+	case "", "LINEAR", "SINE", "CUBIC":
+	default:
+		return fmt.Errorf("unknown Easing %q, must be Linear, Sine or Cubic", c.Easing)
+	}
 	return nil
 }
 
@@ -236,6 +522,50 @@ type MultiBlobLEDConfig struct {
 	Duration time.Duration `yaml:"Duration"`
 	Delay    time.Duration `yaml:"Delay"`
 	BlobCfg  []BlobCfg     `yaml:"BlobCfg"`
+	// BeatColors passes the colours on from blob to blob on every beat
+	// of the audio input, it needs AudioLED.Beat.
+	BeatColors bool `yaml:"BeatColors"`
+	// Physics replaces the fixed speed of the blobs by momentum.
+	Physics MultiBlobPhysicsConfig `yaml:"Physics"`
+}
+
+// MultiBlobPhysicsConfig defines the optional physics of the blobs.
+// Speeds are in LEDs per step like DeltaX.
+type MultiBlobPhysicsConfig struct {
+	Enabled bool `yaml:"Enabled"`
+	// Friction is the share of its speed a blob loses per second.
+	Friction float64 `yaml:"Friction"`
+	// Impulse is the largest random change of the speed per second.
+	Impulse float64 `yaml:"Impulse"`
+	// Blobs colliding slower than MergeSpeed merge, 0 means never. A
+	// merged blob splits into its parts after SplitAfter, 0 means never.
+	MergeSpeed float64       `yaml:"MergeSpeed"`
+	SplitAfter time.Duration `yaml:"SplitAfter"`
+	// SpawnAtTrigger starts the blobs at the LED of the sensor
+	// triggered last instead of their X.
+	SpawnAtTrigger bool `yaml:"SpawnAtTrigger"`
+	// Seed makes the random impulses and thus every run repeatable, 0
+	// means a different seed on every start.
+	Seed uint64 `yaml:"Seed"`
+}
+
+func (c *MultiBlobPhysicsConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Friction < 0 || c.Friction >= 1 {
+		return fmt.Errorf("Friction must be at least 0 and less than 1")
+	}
+	if c.Impulse < 0 {
+		return fmt.Errorf("Impulse must be non-negative")
+	}
+	if c.MergeSpeed < 0 {
+		return fmt.Errorf("MergeSpeed must be non-negative")
+	}
+	if c.SplitAfter < 0 {
+		return fmt.Errorf("SplitAfter must be non-negative")
+	}
+	return nil
 }
 
 func (c *MultiBlobLEDConfig) Validate(ledsTotal int) error {
@@ -250,6 +580,9 @@ func (c *MultiBlobLEDConfig) Validate(ledsTotal int) error {
 			return fmt.Errorf("BlobCfg[%d] invalid: %w", i, err)
 		}
 	}
+	if err := c.Physics.Validate(); err != nil {
+		return fmt.Errorf("Physics invalid: %w", err)
+	}
 	return nil
 }
 
@@ -258,13 +591,18 @@ type BlobCfg struct {
 	DeltaX float64   `yaml:"DeltaX"`
 	X      float64   `yaml:"X"`
 	Width  float64   `yaml:"Width"`
-	LedRGB []float64 `yaml:"LedRGB,flow"`
+	LedRGB []float64 `yaml:"LedRGB,flow" palette:"color"`
+	// Mass is used by the physics, 0 means Width.
+	Mass float64 `yaml:"Mass"`
 }
 
 func (b *BlobCfg) Validate(ledsTotal int) error {
 	if b.Width <= 0 {
 		return fmt.Errorf("Width must be positive")
 	}
+	if b.Mass < 0 {
+		return fmt.Errorf("Mass must be non-negative")
+	}
 	if b.X < 0 || b.X >= float64(ledsTotal) {
 		return fmt.Errorf("X (%f) must be between 0 and %d", b.X, ledsTotal-1)
 	}
@@ -274,13 +612,651 @@ func (b *BlobCfg) Validate(ledsTotal int) error {
 	return nil
 }
 
+// DataSourceConfig selects where a producer reads its values from.
+type DataSourceConfig struct {
+	// Source is "File" or "HTTP" (both read every PollInterval) or
+	// "MQTT" (the payload of every message on the topic).
+	Source       string        `yaml:"Source"`
+	File         string        `yaml:"File"`
+	URL          string        `yaml:"URL"`
+	PollInterval time.Duration `yaml:"PollInterval"`
+	MQTT         MQTTConfig    `yaml:"MQTT"`
+}
+
+// MQTTConfig defines the broker and topic of the MQTT source.
+type MQTTConfig struct {
+	Broker   string `yaml:"Broker"` // host:port
+	Topic    string `yaml:"Topic"`
+	ClientID string `yaml:"ClientID"`
+	Username string `yaml:"Username"`
+	Password string `yaml:"Password"`
+}
+
+func (c *DataSourceConfig) Validate() error {
+	switch strings.ToUpper(c.Source) {
+	case "FILE":
+		if c.File == "" {
+			return fmt.Errorf("File must be set for the File source")
+		}
+	case "HTTP":
+		if parsed, err := url.Parse(c.URL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+			return fmt.Errorf("URL must be an http or https URL for the HTTP source")
+		}
+	case "MQTT":
+		if c.MQTT.Broker == "" || c.MQTT.Topic == "" {
+			return fmt.Errorf("MQTT.Broker and MQTT.Topic must be set for the MQTT source")
+		}
+	default:
+		return fmt.Errorf("unknown Source %q, expected File, HTTP or MQTT", c.Source)
+	}
+	if !strings.EqualFold(c.Source, "MQTT") && c.PollInterval <= 0 {
+		return fmt.Errorf("PollInterval must be positive")
+	}
+	return nil
+}
+
+// WeatherLEDConfig defines the configuration for the WeatherLED
+// producer, which shows the weather read from a JSON document.
+type WeatherLEDConfig struct {
+	Enabled          bool `yaml:"Enabled"`
+	DataSourceConfig `yaml:",inline"`
+	// Fields are the dotted paths of the values in the JSON document,
+	// e.g. "current.temperature_2m" or "hourly.rain.0". An empty path
+	// leaves the value at 0.
+	Fields   WeatherFieldsConfig `yaml:"Fields"`
+	StartLed int                 `yaml:"StartLed"`
+	EndLed   int                 `yaml:"EndLed"`
+	// Delay is the time between two animation steps.
+	Delay time.Duration `yaml:"Delay"`
+	// TemperatureColors maps temperatures to colours as a list of
+	// [temperature, red, green, blue] points, interpolated linearly.
+	TemperatureColors [][]float64 `yaml:"TemperatureColors,flow" palette:"color"`
+	// RainLedRGB is the colour of the rain shimmer, which is densest
+	// at a rain rate of MaxRain. Empty means no shimmer.
+	RainLedRGB []float64 `yaml:"RainLedRGB,flow" palette:"color"`
+	MaxRain    float64   `yaml:"MaxRain"`
+	// WindFactor is the speed of the brightness wave in LEDs per
+	// second per unit of the wind speed, 0 means no wave.
+	WindFactor float64 `yaml:"WindFactor"`
+}
+
+// WeatherFieldsConfig defines where the values are found in the JSON
+// document of the weather source.
+type WeatherFieldsConfig struct {
+	Temperature string `yaml:"Temperature"`
+	Rain        string `yaml:"Rain"`
+	WindSpeed   string `yaml:"WindSpeed"`
+}
+
+func (c *WeatherLEDConfig) Validate(ledsTotal int) error {
+	if !c.Enabled {
+		return nil
+	}
+	if err := c.DataSourceConfig.Validate(); err != nil {
+		return err
+	}
+	if !isValidIndex(c.StartLed, ledsTotal) || !isValidIndex(c.EndLed, ledsTotal) {
+		return fmt.Errorf("StartLed and EndLed must be between 0 and %d", ledsTotal-1)
+	}
+	if c.StartLed > c.EndLed {
+		return fmt.Errorf("StartLed (%d) > EndLed (%d)", c.StartLed, c.EndLed)
+	}
+	if c.Delay <= 0 {
+		return fmt.Errorf("Delay must be positive")
+	}
+	if err := validateColorPoints(c.TemperatureColors); err != nil {
+		return fmt.Errorf("TemperatureColors invalid: %w", err)
+	}
+	if len(c.RainLedRGB) > 0 {
+		if err := validateRGB(c.RainLedRGB); err != nil {
+			return fmt.Errorf("RainLedRGB invalid: %w", err)
+		}
+		if c.MaxRain <= 0 {
+			return fmt.Errorf("MaxRain must be positive")
+		}
+	}
+	if c.WindFactor < 0 {
+		return fmt.Errorf("WindFactor must not be negative")
+	}
+	return nil
+}
+
+// GaugeLEDConfig defines the configuration for the GaugeLED producer,
+// which shows a value (build progress, a sensor reading, ...) as a bar.
+type GaugeLEDConfig struct {
+	Enabled bool `yaml:"Enabled"`
+	// Source "API" takes the values from POST /api/gauge, the other
+	// sources are read like the ones of WeatherLED.
+	DataSourceConfig `yaml:",inline"`
+	// Field is the dotted path of the value in a JSON document, empty
+	// means the document is just the number.
+	Field string  `yaml:"Field"`
+	Min   float64 `yaml:"Min"`
+	Max   float64 `yaml:"Max"`
+	// The bar grows from StartLed towards EndLed, which may be the
+	// smaller index.
+	StartLed int `yaml:"StartLed"`
+	EndLed   int `yaml:"EndLed"`
+	// Colors is the gradient along the bar as a list of [value, red,
+	// green, blue] points, interpolated linearly.
+	Colors [][]float64 `yaml:"Colors,flow" palette:"color"`
+	// Thresholds colour the whole bar once the value reaches them.
+	Thresholds []GaugeThreshold `yaml:"Thresholds"`
+	// Transition is the time the bar takes to move to a new value, 0
+	// means it jumps.
+	Transition time.Duration `yaml:"Transition"`
+	// Delay is the time between two animation steps.
+	Delay time.Duration `yaml:"Delay"`
+}
+
+// GaugeThreshold colours the gauge from Value on, up to the next
+// threshold.
+type GaugeThreshold struct {
+	Value  float64   `yaml:"Value"`
+	LedRGB []float64 `yaml:"LedRGB,flow" palette:"color"`
+	Blink  bool      `yaml:"Blink"`
+}
+
+func (c *GaugeLEDConfig) Validate(ledsTotal int) error {
+	if !c.Enabled {
+		return nil
+	}
+	if !strings.EqualFold(c.Source, "API") {
+		if err := c.DataSourceConfig.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.Min >= c.Max {
+		return fmt.Errorf("Min (%f) must be less than Max (%f)", c.Min, c.Max)
+	}
+	if !isValidIndex(c.StartLed, ledsTotal) || !isValidIndex(c.EndLed, ledsTotal) {
+		return fmt.Errorf("StartLed and EndLed must be between 0 and %d", ledsTotal-1)
+	}
+	if err := validateColorPoints(c.Colors); err != nil {
+		return fmt.Errorf("Colors invalid: %w", err)
+	}
+	for i, threshold := range c.Thresholds {
+		if err := validateRGB(threshold.LedRGB); err != nil {
+			return fmt.Errorf("Thresholds[%d].LedRGB invalid: %w", i, err)
+		}
+		if i > 0 && threshold.Value <= c.Thresholds[i-1].Value {
+			return fmt.Errorf("Thresholds values must be increasing")
+		}
+	}
+	if c.Transition < 0 {
+		return fmt.Errorf("Transition must not be negative")
+	}
+	if c.Delay <= 0 {
+		return fmt.Errorf("Delay must be positive")
+	}
+	return nil
+}
+
+// AmbientConfig holds the settings shared by the ambient producers
+// FireLED, CandleLED, TwinkleLED, NoiseLED, BreathingLED and ImageLED.
+type AmbientConfig struct {
+	Enabled bool `yaml:"Enabled"`
+	// The effect runs from StartLed towards EndLed, which may be the
+	// smaller index.
+	StartLed int `yaml:"StartLed"`
+	EndLed   int `yaml:"EndLed"`
+	// Delay is the time between two animation steps.
+	Delay time.Duration `yaml:"Delay"`
+	// Seed makes the random numbers and thus every run of the effect
+	// repeatable, 0 means a different seed on every start.
+	Seed uint64 `yaml:"Seed"`
+	// AfterSensor runs the producer for Duration after a SensorLED
+	// cycle like CylonLED instead of permanently.
+	AfterSensor bool          `yaml:"AfterSensor"`
+	Duration    time.Duration `yaml:"Duration"`
+}
+
+func (c *AmbientConfig) Validate(ledsTotal int) error {
+	if !isValidIndex(c.StartLed, ledsTotal) || !isValidIndex(c.EndLed, ledsTotal) {
+		return fmt.Errorf("StartLed and EndLed must be between 0 and %d", ledsTotal-1)
+	}
+	if c.Delay <= 0 {
+		return fmt.Errorf("Delay must be positive")
+	}
+	if c.AfterSensor && c.Duration <= 0 {
+		return fmt.Errorf("Duration must be positive with AfterSensor")
+	}
+	return nil
+}
+
+// FireLEDConfig defines the FireLED producer, flames rising from
+// StartLed by heat diffusion.
+type FireLEDConfig struct {
+	AmbientConfig `yaml:",inline"`
+	// Cooling is the largest share of the heat (0..1) an LED loses per
+	// step, higher values give shorter flames.
+	Cooling float64 `yaml:"Cooling"`
+	// Sparking is the probability (0..1) of a new spark per step.
+	Sparking float64 `yaml:"Sparking"`
+	// Colors maps the heat (0..1) to colours as a list of [heat, red,
+	// green, blue] points.
+	Colors [][]float64 `yaml:"Colors,flow" palette:"color"`
+}
+
+func (c *FireLEDConfig) Validate(ledsTotal int) error {
+	if !c.Enabled {
+		return nil
+	}
+	if err := c.AmbientConfig.Validate(ledsTotal); err != nil {
+		return err
+	}
+	if c.Cooling < 0 || c.Cooling > 1 {
+		return fmt.Errorf("Cooling must be between 0 and 1")
+	}
+	if c.Sparking < 0 || c.Sparking > 1 {
+		return fmt.Errorf("Sparking must be between 0 and 1")
+	}
+	if err := validateColorPoints(c.Colors); err != nil {
+		return fmt.Errorf("Colors invalid: %w", err)
+	}
+	return nil
+}
+
+// CandleLEDConfig defines the CandleLED producer, LEDs flickering
+// independently like candles.
+type CandleLEDConfig struct {
+	AmbientConfig `yaml:",inline"`
+	LedRGB        []float64 `yaml:"LedRGB,flow" palette:"color"`
+	// Flicker is the largest share of the brightness (0..1) lost in a
+	// flicker.
+	Flicker float64 `yaml:"Flicker"`
+}
+
+func (c *CandleLEDConfig) Validate(ledsTotal int) error {
+	if !c.Enabled {
+		return nil
+	}
+	if err := c.AmbientConfig.Validate(ledsTotal); err != nil {
+		return err
+	}
+	if err := validateRGB(c.LedRGB); err != nil {
+		return fmt.Errorf("LedRGB invalid: %w", err)
+	}
+	if c.Flicker < 0 || c.Flicker > 1 {
+		return fmt.Errorf("Flicker must be between 0 and 1")
+	}
+	return nil
+}
+
+// TwinkleLEDConfig defines the TwinkleLED producer, stars lighting up
+// and fading at random LEDs.
+type TwinkleLEDConfig struct {
+	AmbientConfig `yaml:",inline"`
+	// Every star gets one of the Colors at random.
+	Colors [][]float64 `yaml:"Colors,flow" palette:"color"`
+	// Density is the share of the LEDs (0..1) lit on average.
+	Density float64 `yaml:"Density"`
+	// Lifetime is the time a star takes to light up and fade again.
+	Lifetime time.Duration `yaml:"Lifetime"`
+}
+
+func (c *TwinkleLEDConfig) Validate(ledsTotal int) error {
+	if !c.Enabled {
+		return nil
+	}
+	if err := c.AmbientConfig.Validate(ledsTotal); err != nil {
+		return err
+	}
+	if len(c.Colors) == 0 {
+		return fmt.Errorf("Colors needs at least one colour")
+	}
+	for i, rgb := range c.Colors {
+		if err := validateRGB(rgb); err != nil {
+			return fmt.Errorf("Colors[%d] invalid: %w", i, err)
+		}
+	}
+	if c.Density <= 0 || c.Density >= 1 {
+		return fmt.Errorf("Density must be greater than 0 and less than 1")
+	}
+	if c.Lifetime < c.Delay {
+		return fmt.Errorf("Lifetime must be at least Delay")
+	}
+	return nil
+}
+
+// NoiseLEDConfig defines the NoiseLED producer, colours flowing along
+// the strip following Perlin noise.
+type NoiseLEDConfig struct {
+	AmbientConfig `yaml:",inline"`
+	// Colors maps the noise (0..1) to colours as a list of [value,
+	// red, green, blue] points.
+	Colors [][]float64 `yaml:"Colors,flow" palette:"color"`
+	// Scale is the size of the features in LEDs.
+	Scale float64 `yaml:"Scale"`
+	// Speed is the rate of change, 1 changes the pattern completely in
+	// about a second.
+	Speed float64 `yaml:"Speed"`
+}
+
+func (c *NoiseLEDConfig) Validate(ledsTotal int) error {
+	if !c.Enabled {
+		return nil
+	}
+	if err := c.AmbientConfig.Validate(ledsTotal); err != nil {
+		return err
+	}
+	if err := validateColorPoints(c.Colors); err != nil {
+		return fmt.Errorf("Colors invalid: %w", err)
+	}
+	if c.Scale <= 0 {
+		return fmt.Errorf("Scale must be positive")
+	}
+	if c.Speed < 0 {
+		return fmt.Errorf("Speed must be non-negative")
+	}
+	return nil
+}
+
+// BreathingLEDConfig defines the BreathingLED producer, all LEDs
+// slowly brightening and dimming like breathing.
+type BreathingLEDConfig struct {
+	AmbientConfig `yaml:",inline"`
+	LedRGB        []float64 `yaml:"LedRGB,flow" palette:"color"`
+	// Period is the time of one breath.
+	Period time.Duration `yaml:"Period"`
+	// MinBrightness is the brightness (0..1) between two breaths.
+	MinBrightness float64 `yaml:"MinBrightness"`
+}
+
+func (c *BreathingLEDConfig) Validate(ledsTotal int) error {
+	if !c.Enabled {
+		return nil
+	}
+	if err := c.AmbientConfig.Validate(ledsTotal); err != nil {
+		return err
+	}
+	if err := validateRGB(c.LedRGB); err != nil {
+		return fmt.Errorf("LedRGB invalid: %w", err)
+	}
+	if c.Period <= 0 {
+		return fmt.Errorf("Period must be positive")
+	}
+	if c.MinBrightness < 0 || c.MinBrightness > 1 {
+		return fmt.Errorf("MinBrightness must be between 0 and 1")
+	}
+	return nil
+}
+
+// ImageLEDConfig defines the ImageLED producer, which plays back a PNG
+// or GIF image: every row is a frame, the columns are mapped to the
+// LEDs. The rows of the frames of an animated GIF follow each other.
+type ImageLEDConfig struct {
+	AmbientConfig `yaml:",inline"`
+	File          string `yaml:"File"`
+	// FrameRate is the number of rows shown per second.
+	FrameRate float64 `yaml:"FrameRate"`
+	// Loop is "Loop" (start again), "PingPong" (play backwards and
+	// forwards) or "Once" (dark after the last row).
+	Loop string `yaml:"Loop"`
+	// Scaling maps the columns to the LEDs: "Linear" or "Nearest"
+	// stretch the row to the range, "None" maps column k to the k-th
+	// LED of the range.
+	Scaling string `yaml:"Scaling"`
+	// Brightness scales the colours of the image (0..1].
+	Brightness float64 `yaml:"Brightness"`
+}
+
+func (c *ImageLEDConfig) Validate(ledsTotal int) error {
+	if !c.Enabled {
+		return nil
+	}
+	if err := c.AmbientConfig.Validate(ledsTotal); err != nil {
+		return err
+	}
+	if c.File == "" {
+		return fmt.Errorf("File must be set")
+	}
+	if c.FrameRate <= 0 {
+		return fmt.Errorf("FrameRate must be positive")
+	}
+	switch strings.ToUpper(c.Loop) {
+	case "LOOP", "PINGPONG", "ONCE":
+	default:
+		return fmt.Errorf("unknown Loop %q, expected Loop, PingPong or Once", c.Loop)
+	}
+	switch strings.ToUpper(c.Scaling) {
+	case "LINEAR", "NEAREST", "NONE":
+	default:
+		return fmt.Errorf("unknown Scaling %q, expected Linear, Nearest or None", c.Scaling)
+	}
+	if c.Brightness <= 0 || c.Brightness > 1 {
+		return fmt.Errorf("Brightness must be greater than 0 and at most 1")
+	}
+	return nil
+}
+
+// MessageLEDConfig defines the MessageLED producer, which scrolls short
+// messages from the end of its range towards the start.
+type MessageLEDConfig struct {
+	Enabled bool `yaml:"Enabled"`
+	// Encoding is "Morse" (dots and dashes), "Digits" (one LED per
+	// digit in its DigitColors colour) or "Bitmap" (a font 5 LEDs high
+	// on the rows of MatrixGroup).
+	Encoding string `yaml:"Encoding"`
+	// The range of Morse and Digits, EndLed may be the smaller index.
+	StartLed int `yaml:"StartLed"`
+	EndLed   int `yaml:"EndLed"`
+	// MatrixGroup is the LedSegments group of Bitmap, its 5 segments
+	// are the rows from top to bottom.
+	MatrixGroup string      `yaml:"MatrixGroup"`
+	LedRGB      []float64   `yaml:"LedRGB,flow" palette:"color"`
+	DigitColors [][]float64 `yaml:"DigitColors,flow" palette:"color"`
+	// Message is shown while no message was sent to /api/message or
+	// set by the schedule, empty means dark.
+	Message string `yaml:"Message"`
+	// Speed is the number of LEDs per second the message moves by.
+	Speed float64       `yaml:"Speed"`
+	Delay time.Duration `yaml:"Delay"`
+}
+
+// MessageRows is the number of rows of the Bitmap font.
+const MessageRows = 5
+
+func (c *MessageLEDConfig) Validate(ledsTotal int) error {
+	if !c.Enabled {
+		return nil
+	}
+	switch strings.ToUpper(c.Encoding) {
+	case "MORSE", "BITMAP":
+		if err := validateRGB(c.LedRGB); err != nil {
+			return fmt.Errorf("LedRGB invalid: %w", err)
+		}
+	case "DIGITS":
+		if len(c.DigitColors) != 10 {
+			return fmt.Errorf("DigitColors needs 10 colours, got %d", len(c.DigitColors))
+		}
+		for i, rgb := range c.DigitColors {
+			if err := validateRGB(rgb); err != nil {
+				return fmt.Errorf("DigitColors[%d] invalid: %w", i, err)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown Encoding %q, expected Morse, Digits or Bitmap", c.Encoding)
+	}
+	if strings.EqualFold(c.Encoding, "Bitmap") {
+		if c.MatrixGroup == "" {
+			return fmt.Errorf("MatrixGroup must be set for the Bitmap encoding")
+		}
+	} else if !isValidIndex(c.StartLed, ledsTotal) || !isValidIndex(c.EndLed, ledsTotal) {
+		return fmt.Errorf("StartLed and EndLed must be between 0 and %d", ledsTotal-1)
+	}
+	if c.Speed <= 0 {
+		return fmt.Errorf("Speed must be positive")
+	}
+	if c.Delay <= 0 {
+		return fmt.Errorf("Delay must be positive")
+	}
+	return nil
+}
+
+// AlertLEDConfig defines the alert overlay, which shows the alerts
+// sent to /api/alert above all other producers. The values are the
+// defaults for alerts that don't give their own.
+type AlertLEDConfig struct {
+	Enabled bool `yaml:"Enabled"`
+	// Delay is the time between two animation steps.
+	Delay time.Duration `yaml:"Delay"`
+	// Period is the length of one cycle of the pattern, Repeat the
+	// number of cycles.
+	Period time.Duration `yaml:"Period"`
+	Repeat int           `yaml:"Repeat"`
+	LedRGB []float64     `yaml:"LedRGB,flow" palette:"color"`
+	// MaxQueue is the number of alerts that can wait, further alerts
+	// are rejected.
+	MaxQueue int `yaml:"MaxQueue"`
+}
+
+func (c *AlertLEDConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Delay <= 0 {
+		return fmt.Errorf("Delay must be positive")
+	}
+	if c.Period < c.Delay {
+		return fmt.Errorf("Period (%s) must not be shorter than Delay (%s)", c.Period, c.Delay)
+	}
+	if c.Repeat <= 0 {
+		return fmt.Errorf("Repeat must be positive")
+	}
+	if err := validateRGB(c.LedRGB); err != nil {
+		return fmt.Errorf("LedRGB invalid: %w", err)
+	}
+	if c.MaxQueue <= 0 {
+		return fmt.Errorf("MaxQueue must be positive")
+	}
+	return nil
+}
+
+// ProducerSections are the names of the producer config sections, as
+// used e.g. by ScheduleRule.Producers.
+var ProducerSections = []string{"SensorLED", "NightLED", "ClockLED", "AudioLED", "CylonLED", "MultiBlobLED", "WeatherLED", "GaugeLED",
+	"FireLED", "CandleLED", "TwinkleLED", "NoiseLED", "BreathingLED", "ImageLED", "MessageLED"}
+
+// ScheduleConfig defines time based rules that disable producers or
+// change their brightness and colour without a config reload.
+type ScheduleConfig struct {
+	Enabled   bool           `yaml:"Enabled"`
+	Latitude  float64        `yaml:"Latitude"`
+	Longitude float64        `yaml:"Longitude"`
+	Holidays  []string       `yaml:"Holidays,flow"`
+	Rules     []ScheduleRule `yaml:"Rules"`
+}
+
+// ScheduleRule defines when a rule is active and what it changes for
+// the listed producers. Later rules override earlier ones.
+type ScheduleRule struct {
+	Producers  []string  `yaml:"Producers,flow"`
+	Days       string    `yaml:"Days"`
+	From       string    `yaml:"From"`
+	To         string    `yaml:"To"`
+	Cron       string    `yaml:"Cron"`
+	Disable    bool      `yaml:"Disable"`
+	Brightness float64   `yaml:"Brightness"`
+	LedRGB     []float64 `yaml:"LedRGB,flow" palette:"color"`
+	// Message replaces the configured message of MessageLED.
+	Message string `yaml:"Message"`
+}
+
+func (c *ScheduleConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Latitude < -90 || c.Latitude > 90 {
+		return fmt.Errorf("Latitude must be between -90 and 90")
+	}
+	if c.Longitude < -180 || c.Longitude > 180 {
+		return fmt.Errorf("Longitude must be between -180 and 180")
+	}
+	for _, holiday := range c.Holidays {
+		if _, err := ParseHoliday(holiday); err != nil {
+			return err
+		}
+	}
+	for i, rule := range c.Rules {
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("Rules[%d] invalid: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// ParseHoliday parses a holiday given either as a date (2006-01-02) or
+// as a date recurring every year (01-02).
+func ParseHoliday(s string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("01-02", s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid holiday %q: expected YYYY-MM-DD or MM-DD", s)
+}
+
+func (r *ScheduleRule) Validate() error {
+	if len(r.Producers) == 0 {
+		return fmt.Errorf("Producers must not be empty")
+	}
+	for _, name := range r.Producers {
+		known := false
+		for _, section := range ProducerSections {
+			known = known || name == section
+		}
+		if !known {
+			return fmt.Errorf("unknown producer %q, expected one of %v", name, ProducerSections)
+		}
+	}
+	if _, err := u.ParseDaySet(r.Days); err != nil {
+		return fmt.Errorf("Days invalid: %w", err)
+	}
+	if r.Cron != "" {
+		if r.From != "" || r.To != "" {
+			return fmt.Errorf("Cron can't be combined with From/To")
+		}
+		if _, err := u.ParseCron(r.Cron); err != nil {
+			return fmt.Errorf("Cron invalid: %w", err)
+		}
+	}
+	if r.To != "" && r.From == "" {
+		return fmt.Errorf("To requires From")
+	}
+	if r.From != "" {
+		if _, err := u.ParseTimeAnchor(r.From); err != nil {
+			return fmt.Errorf("From invalid: %w", err)
+		}
+	}
+	if r.To != "" {
+		if _, err := u.ParseTimeAnchor(r.To); err != nil {
+			return fmt.Errorf("To invalid: %w", err)
+		}
+	}
+	if r.Brightness < 0 || r.Brightness > 1 {
+		return fmt.Errorf("Brightness must be between 0 and 1")
+	}
+	if len(r.LedRGB) > 0 {
+		if err := validateRGB(r.LedRGB); err != nil {
+			return fmt.Errorf("LedRGB invalid: %w", err)
+		}
+	}
+	if !r.Disable && r.Brightness == 0 && len(r.LedRGB) == 0 && r.Message == "" {
+		return fmt.Errorf("rule has no effect: set Disable, Brightness, LedRGB or Message")
+	}
+	return nil
+}
+
 // HardwareConfig defines the hardware configuration.
 type HardwareConfig struct {
-	WebserverPort    uint16        `yaml:"WebserverPort"`
-	LEDType          string        `yaml:"LEDType"`
-	SPIFrequency     int           `yaml:"SPIFrequency"`
-	Display          DisplayConfig `yaml:"Display"`
-	Sensors          SensorsConfig `yaml:"Sensors"`
+	WebserverPort    uint16             `yaml:"WebserverPort"`
+	LEDType          string             `yaml:"LEDType"`
+	SPIFrequency     int                `yaml:"SPIFrequency"`
+	Display          DisplayConfig      `yaml:"Display"`
+	Sensors          SensorsConfig      `yaml:"Sensors"`
+	AmbientLight     AmbientLightConfig `yaml:"AmbientLight"`
 	SpiMultiplexGPIO map[string]struct {
 		Low  []int `yaml:"Low,flow"`
 		High []int `yaml:"High,flow"`
@@ -295,6 +1271,231 @@ type DisplayConfig struct {
 	ColorCorrection   []float64                     `yaml:"ColorCorrection,flow"`
 	APA102_Brightness byte                          `yaml:"APA102_Brightness"`
 	LedSegments       map[string][]LedSegmentConfig `yaml:"LedSegments,flow"`
+	Power             PowerConfig                   `yaml:"Power"`
+	Layout            LayoutConfig                  `yaml:"Layout"`
+	Positions         PositionsConfig               `yaml:"Positions"`
+}
+
+// PowerConfig defines the power model used to limit the current drawn
+// by the LED strips.
+type PowerConfig struct {
+	Enabled bool `yaml:"Enabled"`
+	// ChannelMilliamps is the current of the red, green and blue LED of
+	// a pixel at full value (255 after the colour correction).
+	ChannelMilliamps []float64 `yaml:"ChannelMilliamps,flow"`
+	// IdleMilliamps is the current of a dark pixel (its driver chip).
+	IdleMilliamps float64 `yaml:"IdleMilliamps"`
+	// BudgetMilliamps limits the current of all segments together,
+	// SegmentBudgetMilliamps the current of every single segment. 0
+	// means no limit.
+	BudgetMilliamps        float64 `yaml:"BudgetMilliamps"`
+	SegmentBudgetMilliamps float64 `yaml:"SegmentBudgetMilliamps"`
+}
+
+func (c *PowerConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if len(c.ChannelMilliamps) != 3 {
+		return fmt.Errorf("ChannelMilliamps must have exactly 3 components, got %d", len(c.ChannelMilliamps))
+	}
+	for i, ma := range c.ChannelMilliamps {
+		if ma < 0 {
+			return fmt.Errorf("ChannelMilliamps component %d must not be negative", i)
+		}
+	}
+	if c.IdleMilliamps < 0 || c.BudgetMilliamps < 0 || c.SegmentBudgetMilliamps < 0 {
+		return fmt.Errorf("IdleMilliamps, BudgetMilliamps and SegmentBudgetMilliamps must not be negative")
+	}
+	return nil
+}
+
+// LayoutConfig arranges the virtual strip as a matrix of Width x
+// Height cells, so producers can draw in x/y coordinates. x runs from
+// left to right, y from top to bottom.
+type LayoutConfig struct {
+	Enabled bool `yaml:"Enabled"`
+	Width   int  `yaml:"Width"`
+	Height  int  `yaml:"Height"`
+	// Serpentine means every second row of a block runs backwards
+	// (zig-zag wiring).
+	Serpentine bool `yaml:"Serpentine"`
+	// Segments are the blocks of the matrix, e.g. panels next to each
+	// other. Without segments the matrix is one block starting with
+	// LED 0.
+	Segments []LayoutSegmentConfig `yaml:"Segments"`
+}
+
+// LayoutSegmentConfig defines a block of the matrix whose LEDs are
+// wired row by row, starting with FirstLed at its top left cell X/Y.
+type LayoutSegmentConfig struct {
+	FirstLed int `yaml:"FirstLed"`
+	X        int `yaml:"X"`
+	Y        int `yaml:"Y"`
+	Width    int `yaml:"Width"`
+	Height   int `yaml:"Height"`
+}
+
+// Blocks returns the segments of the layout, or the whole matrix as a
+// single segment if there are none.
+func (c *LayoutConfig) Blocks() []LayoutSegmentConfig {
+	if len(c.Segments) == 0 {
+		return []LayoutSegmentConfig{{Width: c.Width, Height: c.Height}}
+	}
+	return c.Segments
+}
+
+// Cell returns the cell x/y of the LED led, false if the LED isn't
+// part of the matrix.
+func (c *LayoutConfig) Cell(led int) (x, y int, ok bool) {
+	for _, seg := range c.Blocks() {
+		offset := led - seg.FirstLed
+		if offset < 0 || offset >= seg.Width*seg.Height {
+			continue
+		}
+		row, col := offset/seg.Width, offset%seg.Width
+		if c.Serpentine && row%2 == 1 {
+			col = seg.Width - 1 - col
+		}
+		return seg.X + col, seg.Y + row, true
+	}
+	return 0, 0, false
+}
+
+// zone returns the layout of a zone with the LEDs leds, whose LEDs
+// keep their cells. It is disabled if none of them is in the matrix.
+func (c *LayoutConfig) zone(leds []int) LayoutConfig {
+	if !c.Enabled {
+		return LayoutConfig{}
+	}
+	layout := LayoutConfig{Width: c.Width, Height: c.Height}
+	for k, led := range leds {
+		if x, y, ok := c.Cell(led); ok {
+			layout.Segments = append(layout.Segments, LayoutSegmentConfig{FirstLed: k, X: x, Y: y, Width: 1, Height: 1})
+		}
+	}
+	layout.Enabled = len(layout.Segments) > 0
+	return layout
+}
+
+func (c *LayoutConfig) Validate(ledsTotal int) error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Width <= 0 || c.Height <= 0 {
+		return fmt.Errorf("Width and Height must be positive")
+	}
+	cells := make([]bool, c.Width*c.Height)
+	leds := make([]bool, ledsTotal)
+	for i, seg := range c.Blocks() {
+		if seg.Width <= 0 || seg.Height <= 0 {
+			return fmt.Errorf("segment %d: Width and Height must be positive", i)
+		}
+		if seg.X < 0 || seg.Y < 0 || seg.X+seg.Width > c.Width || seg.Y+seg.Height > c.Height {
+			return fmt.Errorf("segment %d doesn't fit into the %dx%d matrix", i, c.Width, c.Height)
+		}
+		last := seg.FirstLed + seg.Width*seg.Height - 1
+		if seg.FirstLed < 0 || last >= ledsTotal {
+			return fmt.Errorf("segment %d needs LEDs %d to %d, but LedsTotal is %d", i, seg.FirstLed, last, ledsTotal)
+		}
+		for led := seg.FirstLed; led <= last; led++ {
+			if leds[led] {
+				return fmt.Errorf("segment %d: LED %d is used twice", i, led)
+			}
+			leds[led] = true
+		}
+		for y := seg.Y; y < seg.Y+seg.Height; y++ {
+			for x := seg.X; x < seg.X+seg.Width; x++ {
+				if cells[y*c.Width+x] {
+					return fmt.Errorf("segment %d: cell %d,%d is covered twice", i, x, y)
+				}
+				cells[y*c.Width+x] = true
+			}
+		}
+	}
+	return nil
+}
+
+// PositionsConfig gives the LEDs of the virtual strip physical
+// coordinates in metres, so effects can move at real speeds and stay
+// continuous across invisible gaps.
+type PositionsConfig struct {
+	Enabled bool `yaml:"Enabled"`
+	// Points anchor LEDs at coordinates, LEDs between two points are
+	// spread evenly between them. The first point must be LED 0, the
+	// last one LED LedsTotal-1.
+	Points []PositionPointConfig `yaml:"Points"`
+}
+
+// PositionPointConfig anchors the LED Led at the point At, which has 1
+// (metres along a path), 2 or 3 components.
+type PositionPointConfig struct {
+	Led int       `yaml:"Led"`
+	At  []float64 `yaml:"At,flow"`
+}
+
+// Dimensions returns the number of components of the points.
+func (c *PositionsConfig) Dimensions() int {
+	if len(c.Points) == 0 {
+		return 0
+	}
+	return len(c.Points[0].At)
+}
+
+// PointOf returns the coordinates of the LED led, spread evenly
+// between the points around it.
+func (c *PositionsConfig) PointOf(led int) []float64 {
+	for i := 1; i < len(c.Points); i++ {
+		from, to := c.Points[i-1], c.Points[i]
+		if led > to.Led && i < len(c.Points)-1 {
+			continue
+		}
+		f := float64(led-from.Led) / float64(to.Led-from.Led)
+		point := make([]float64, len(from.At))
+		for d := range point {
+			point[d] = from.At[d] + f*(to.At[d]-from.At[d])
+		}
+		return point
+	}
+	return nil
+}
+
+// zone returns the positions of a zone with the LEDs leds, whose LEDs
+// keep their coordinates. A zone of a single LED has no positions.
+func (c *PositionsConfig) zone(leds []int) PositionsConfig {
+	if !c.Enabled || len(leds) < 2 {
+		return PositionsConfig{}
+	}
+	positions := PositionsConfig{Enabled: true}
+	for k, led := range leds {
+		positions.Points = append(positions.Points, PositionPointConfig{Led: k, At: c.PointOf(led)})
+	}
+	return positions
+}
+
+func (c *PositionsConfig) Validate(ledsTotal int) error {
+	if !c.Enabled {
+		return nil
+	}
+	if len(c.Points) < 2 {
+		return fmt.Errorf("at least 2 Points are needed")
+	}
+	if c.Points[0].Led != 0 || c.Points[len(c.Points)-1].Led != ledsTotal-1 {
+		return fmt.Errorf("the first point must be LED 0 and the last one LED %d", ledsTotal-1)
+	}
+	dims := c.Dimensions()
+	if dims < 1 || dims > 3 {
+		return fmt.Errorf("points must have 1, 2 or 3 components, got %d", dims)
+	}
+	for i, point := range c.Points {
+		if len(point.At) != dims {
+			return fmt.Errorf("point %d has %d components, expected %d like the first one", i, len(point.At), dims)
+		}
+		if i > 0 && point.Led <= c.Points[i-1].Led {
+			return fmt.Errorf("point %d: Led must be increasing", i)
+		}
+	}
+	return nil
 }
 
 // LedSegmentConfig defines the configuration for a single LED segment.
@@ -311,6 +1512,9 @@ type SensorCfg struct {
 	SpiMultiplex string `yaml:"SpiMultiplex"`
 	AdcChannel   byte   `yaml:"AdcChannel"`
 	TriggerValue int    `yaml:"TriggerValue"`
+	// Position is the point of the sensor in the coordinates of
+	// Display.Positions, nil for the point of its LED.
+	Position []float64 `yaml:"Position,flow"`
 }
 
 // SensorsConfig defines the sensors configuration.
@@ -320,6 +1524,66 @@ type SensorsConfig struct {
 	SensorCfg     map[string]SensorCfg `yaml:"SensorCfg"`
 }
 
+// AmbientLightConfig defines an optional ambient light sensor that
+// scales the brightness of all LEDs.
+type AmbientLightConfig struct {
+	Enabled bool `yaml:"Enabled"`
+	// Source is "ADC" (a channel of an ADC like the IR sensors) or
+	// "BH1750" (an I2C lux sensor).
+	Source       string `yaml:"Source"`
+	SpiMultiplex string `yaml:"SpiMultiplex"`
+	AdcChannel   byte   `yaml:"AdcChannel"`
+	I2CBus       int    `yaml:"I2CBus"`
+	I2CAddress   uint16 `yaml:"I2CAddress"`
+	// ReadInterval is the time between two readings of the sensor.
+	ReadInterval time.Duration `yaml:"ReadInterval"`
+	// Smoothing is the time constant of the exponential smoothing of
+	// the readings, 0 disables smoothing.
+	Smoothing time.Duration `yaml:"Smoothing"`
+	// Curve maps readings to brightness factors as a list of
+	// [reading, factor] points, interpolated linearly.
+	Curve [][]float64 `yaml:"Curve,flow"`
+}
+
+func (c *AmbientLightConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	switch strings.ToUpper(c.Source) {
+	case "ADC":
+		if c.AdcChannel > 7 {
+			return fmt.Errorf("AdcChannel must be between 0 and 7")
+		}
+	case "BH1750":
+		if c.I2CAddress == 0 {
+			return fmt.Errorf("I2CAddress must be set for a BH1750 sensor")
+		}
+	default:
+		return fmt.Errorf("unknown Source %q, expected ADC or BH1750", c.Source)
+	}
+	if c.ReadInterval <= 0 {
+		return fmt.Errorf("ReadInterval must be positive")
+	}
+	if c.Smoothing < 0 {
+		return fmt.Errorf("Smoothing must not be negative")
+	}
+	if len(c.Curve) < 2 {
+		return fmt.Errorf("Curve needs at least two points")
+	}
+	for i, point := range c.Curve {
+		if len(point) != 2 {
+			return fmt.Errorf("Curve[%d] must be a [reading, factor] pair", i)
+		}
+		if point[1] < 0 || point[1] > 1 {
+			return fmt.Errorf("Curve[%d] factor must be between 0 and 1", i)
+		}
+		if i > 0 && point[0] <= c.Curve[i-1][0] {
+			return fmt.Errorf("Curve readings must be increasing")
+		}
+	}
+	return nil
+}
+
 type SingleLoggingConfig struct {
 	Level  string `yaml:"Level"`
 	Format string `yaml:"Format"`
@@ -331,6 +1595,52 @@ type LoggingConfig struct {
 	HW  SingleLoggingConfig `yaml:"HW"`
 }
 
+// ZoneConfig defines a zone: a virtual strip of its own, made of
+// segments of the shared virtual strip, with its own sensors,
+// producers and Schedule. The producer sections and the Schedule are
+// given inline.
+type ZoneConfig struct {
+	Segments []ZoneSegmentConfig `yaml:"Segments"`
+	// Sensors are the names of the sensors in Hardware.Sensors.SensorCfg
+	// triggering the zone.
+	Sensors []string `yaml:"Sensors,flow"`
+	// Links maps the names of other zones to one of their sensors,
+	// which is triggered as well when a sensor of this zone fires.
+	Links  map[string]string `yaml:"Links"`
+	Config `yaml:",inline"`
+}
+
+// ZoneSegmentConfig is a part of the shared virtual strip belonging to
+// a zone. With Reverse, the zone's strip runs from LastLed to
+// FirstLed.
+type ZoneSegmentConfig struct {
+	FirstLed int  `yaml:"FirstLed"`
+	LastLed  int  `yaml:"LastLed"`
+	Reverse  bool `yaml:"Reverse"`
+}
+
+// Leds returns the LED of the shared virtual strip for every LED of
+// the zone's strip.
+func (c *ZoneConfig) Leds() []int {
+	var leds []int
+	for _, seg := range c.Segments {
+		for i := range seg.LastLed - seg.FirstLed + 1 {
+			if seg.Reverse {
+				leds = append(leds, seg.LastLed-i)
+			} else {
+				leds = append(leds, seg.FirstLed+i)
+			}
+		}
+	}
+	return leds
+}
+
+// Index returns the index in the zone's strip of the LED led of the
+// shared virtual strip, -1 if the LED isn't part of the zone.
+func (c *ZoneConfig) Index(led int) int {
+	return slices.Index(c.Leds(), led)
+}
+
 type Config struct {
 	SensorLED    SensorLEDConfig    `yaml:"SensorLED"`
 	NightLED     NightLEDConfig     `yaml:"NightLED"`
@@ -338,8 +1648,27 @@ type Config struct {
 	AudioLED     AudioLEDConfig     `yaml:"AudioLED"`
 	CylonLED     CylonLEDConfig     `yaml:"CylonLED"`
 	MultiBlobLED MultiBlobLEDConfig `yaml:"MultiBlobLED"`
+	WeatherLED   WeatherLEDConfig   `yaml:"WeatherLED"`
+	GaugeLED     GaugeLEDConfig     `yaml:"GaugeLED"`
+	FireLED      FireLEDConfig      `yaml:"FireLED"`
+	CandleLED    CandleLEDConfig    `yaml:"CandleLED"`
+	TwinkleLED   TwinkleLEDConfig   `yaml:"TwinkleLED"`
+	NoiseLED     NoiseLEDConfig     `yaml:"NoiseLED"`
+	BreathingLED BreathingLEDConfig `yaml:"BreathingLED"`
+	ImageLED     ImageLEDConfig     `yaml:"ImageLED"`
+	MessageLED   MessageLEDConfig   `yaml:"MessageLED"`
+	AlertLED     AlertLEDConfig     `yaml:"AlertLED"`
+	Palette      PaletteConfig      `yaml:"Palette"`
+	Schedule     ScheduleConfig     `yaml:"Schedule"`
 	Hardware     HardwareConfig     `yaml:"Hardware"`
 	Logging      LoggingConfig      `yaml:"Logging"`
+	// Zones split the virtual strip into independent zones. With zones,
+	// the producer sections and the Schedule above aren't used.
+	Zones map[string]ZoneConfig `yaml:"Zones"`
+	// ApiZone is the zone whose AlertLED, GaugeLED and MessageLED are
+	// driven by the web API. Empty means the first zone (by name) with
+	// the producer.
+	ApiZone string `yaml:"ApiZone"`
 }
 
 // Validate performs a comprehensive sanity check of the configuration.
@@ -357,6 +1686,11 @@ func (c *Config) Validate() error {
 			return fmt.Errorf("sensor '%s' uses undefined SpiMultiplex key: '%s'", name, sensorCfg.SpiMultiplex)
 		}
 	}
+	if c.Hardware.AmbientLight.Enabled && strings.EqualFold(c.Hardware.AmbientLight.Source, "ADC") {
+		if _, ok := c.Hardware.SpiMultiplexGPIO[c.Hardware.AmbientLight.SpiMultiplex]; !ok {
+			return fmt.Errorf("ambient light sensor uses undefined SpiMultiplex key: '%s'", c.Hardware.AmbientLight.SpiMultiplex)
+		}
+	}
 	// Check LED segments
 	for groupName, segments := range c.Hardware.Display.LedSegments {
 		for i, segmentCfg := range segments {
@@ -392,14 +1726,71 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	if err := c.Hardware.Display.Power.Validate(); err != nil {
+		return fmt.Errorf("Power configuration invalid: %w", err)
+	}
+	if c.Hardware.Display.Power.Enabled && len(c.Hardware.Display.ColorCorrection) != 3 {
+		return fmt.Errorf("the power model needs a ColorCorrection with exactly 3 components")
+	}
+
+	if err := c.Hardware.Display.Layout.Validate(ledsTotal); err != nil {
+		return fmt.Errorf("Layout configuration invalid: %w", err)
+	}
+
+	if err := c.Hardware.Display.Positions.Validate(ledsTotal); err != nil {
+		return fmt.Errorf("Positions configuration invalid: %w", err)
+	}
+	for name, sensorCfg := range c.Hardware.Sensors.SensorCfg {
+		if sensorCfg.Position == nil {
+			continue
+		}
+		if !c.Hardware.Display.Positions.Enabled {
+			return fmt.Errorf("sensor '%s' has a Position, but Positions are not enabled", name)
+		}
+		if dims := c.Hardware.Display.Positions.Dimensions(); len(sensorCfg.Position) != dims {
+			return fmt.Errorf("sensor '%s' has a Position with %d components, expected %d", name, len(sensorCfg.Position), dims)
+		}
+	}
+
+	if err := c.Hardware.AmbientLight.Validate(); err != nil {
+		return fmt.Errorf("AmbientLight configuration invalid: %w", err)
+	}
+
+	if err := c.Palette.Validate(); err != nil {
+		return fmt.Errorf("Palette configuration invalid: %w", err)
+	}
+
+	if len(c.Zones) > 0 {
+		return c.validateZones()
+	}
+	if c.ApiZone != "" {
+		return fmt.Errorf("ApiZone %q is set, but there are no Zones", c.ApiZone)
+	}
+
 	// 4. Producer Enabled Validation
-	if !c.SensorLED.Enabled && !c.NightLED.Enabled && !c.ClockLED.Enabled && !c.AudioLED.Enabled && !c.CylonLED.Enabled && !c.MultiBlobLED.Enabled {
+	ambient := map[string]*AmbientConfig{"FireLED": &c.FireLED.AmbientConfig, "CandleLED": &c.CandleLED.AmbientConfig,
+		"TwinkleLED": &c.TwinkleLED.AmbientConfig, "NoiseLED": &c.NoiseLED.AmbientConfig, "BreathingLED": &c.BreathingLED.AmbientConfig,
+		"ImageLED": &c.ImageLED.AmbientConfig}
+	ambientEnabled := false
+	for _, cfg := range ambient {
+		ambientEnabled = ambientEnabled || cfg.Enabled
+	}
+	if !c.SensorLED.Enabled && !c.NightLED.Enabled && !c.ClockLED.Enabled && !c.AudioLED.Enabled && !c.CylonLED.Enabled && !c.MultiBlobLED.Enabled && !c.WeatherLED.Enabled && !c.GaugeLED.Enabled && !c.MessageLED.Enabled && !ambientEnabled {
 		return fmt.Errorf("at least one producer must be enabled in the configuration")
 	}
 
 	if !c.SensorLED.Enabled && (c.MultiBlobLED.Enabled || c.CylonLED.Enabled) {
 		return fmt.Errorf("MultiBlobLED and CylonLED producers require the SensorLED producer to be enabled")
 	}
+	for _, name := range slices.Sorted(maps.Keys(ambient)) {
+		if cfg := ambient[name]; !c.SensorLED.Enabled && cfg.Enabled && cfg.AfterSensor {
+			return fmt.Errorf("%s with AfterSensor requires the SensorLED producer to be enabled", name)
+		}
+	}
+
+	if (c.CylonLED.BeatSync || c.MultiBlobLED.BeatColors) && !c.AudioLED.Beat.Enabled {
+		return fmt.Errorf("CylonLED.BeatSync and MultiBlobLED.BeatColors require AudioLED.Beat to be enabled")
+	}
 
 	// 5. Producer-Specific Validations
 	if err := c.SensorLED.Validate(); err != nil {
@@ -426,9 +1817,170 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("MultiBlobLED configuration invalid: %w", err)
 	}
 
+	if err := c.WeatherLED.Validate(ledsTotal); err != nil {
+		return fmt.Errorf("WeatherLED configuration invalid: %w", err)
+	}
+
+	if err := c.GaugeLED.Validate(ledsTotal); err != nil {
+		return fmt.Errorf("GaugeLED configuration invalid: %w", err)
+	}
+
+	if err := c.FireLED.Validate(ledsTotal); err != nil {
+		return fmt.Errorf("FireLED configuration invalid: %w", err)
+	}
+
+	if err := c.CandleLED.Validate(ledsTotal); err != nil {
+		return fmt.Errorf("CandleLED configuration invalid: %w", err)
+	}
+
+	if err := c.TwinkleLED.Validate(ledsTotal); err != nil {
+		return fmt.Errorf("TwinkleLED configuration invalid: %w", err)
+	}
+
+	if err := c.NoiseLED.Validate(ledsTotal); err != nil {
+		return fmt.Errorf("NoiseLED configuration invalid: %w", err)
+	}
+
+	if err := c.BreathingLED.Validate(ledsTotal); err != nil {
+		return fmt.Errorf("BreathingLED configuration invalid: %w", err)
+	}
+
+	if err := c.ImageLED.Validate(ledsTotal); err != nil {
+		return fmt.Errorf("ImageLED configuration invalid: %w", err)
+	}
+
+	if err := c.MessageLED.Validate(ledsTotal); err != nil {
+		return fmt.Errorf("MessageLED configuration invalid: %w", err)
+	}
+	if c.MessageLED.Enabled && strings.EqualFold(c.MessageLED.Encoding, "Bitmap") {
+		rows := c.Hardware.Display.LedSegments[c.MessageLED.MatrixGroup]
+		if len(rows) != MessageRows {
+			return fmt.Errorf("MessageLED configuration invalid: MatrixGroup %q must have %d segments", c.MessageLED.MatrixGroup, MessageRows)
+		}
+		for _, row := range rows[1:] {
+			if row.LastLed-row.FirstLed != rows[0].LastLed-rows[0].FirstLed {
+				return fmt.Errorf("MessageLED configuration invalid: the segments of MatrixGroup %q must have the same length", c.MessageLED.MatrixGroup)
+			}
+		}
+	}
+
+	if err := c.AlertLED.Validate(); err != nil {
+		return fmt.Errorf("AlertLED configuration invalid: %w", err)
+	}
+
+	if err := c.Schedule.Validate(); err != nil {
+		return fmt.Errorf("Schedule configuration invalid: %w", err)
+	}
+
+	return nil
+}
+
+// validateZones checks the zones and the configuration of every zone.
+func (c *Config) validateZones() error {
+	ledsTotal := c.Hardware.Display.LedsTotal
+	names := slices.Sorted(maps.Keys(c.Zones))
+	if _, ok := c.Zones[c.ApiZone]; c.ApiZone != "" && !ok {
+		return fmt.Errorf("ApiZone %q isn't one of the Zones", c.ApiZone)
+	}
+	owner := make([]string, ledsTotal) // zone of every LED
+	for _, name := range names {
+		zone := c.Zones[name]
+		if len(zone.Segments) == 0 {
+			return fmt.Errorf("zone %q needs at least one segment", name)
+		}
+		for i, seg := range zone.Segments {
+			if !isValidIndex(seg.FirstLed, ledsTotal) || !isValidIndex(seg.LastLed, ledsTotal) {
+				return fmt.Errorf("zone %q: segment %d has out-of-bounds indices: FirstLed=%d, LastLed=%d (LedsTotal=%d)", name, i, seg.FirstLed, seg.LastLed, ledsTotal)
+			}
+			if seg.FirstLed > seg.LastLed {
+				return fmt.Errorf("zone %q: segment %d has FirstLed > LastLed", name, i)
+			}
+			for led := seg.FirstLed; led <= seg.LastLed; led++ {
+				if owner[led] != "" {
+					return fmt.Errorf("zone %q: LED %d belongs to zone %q too", name, led, owner[led])
+				}
+				owner[led] = name
+			}
+		}
+	}
+
+	sensorZone := map[string]string{}
+	audioZone := ""
+	for _, name := range names {
+		zone := c.Zones[name]
+		for _, uid := range zone.Sensors {
+			sensorCfg, ok := c.Hardware.Sensors.SensorCfg[uid]
+			if !ok {
+				return fmt.Errorf("zone %q: unknown sensor '%s'", name, uid)
+			}
+			if other, ok := sensorZone[uid]; ok {
+				return fmt.Errorf("sensor '%s' belongs to zone %q and zone %q", uid, other, name)
+			}
+			sensorZone[uid] = name
+			if owner[sensorCfg.LedIndex] != name {
+				return fmt.Errorf("zone %q: sensor '%s' at LED %d is outside the zone", name, uid, sensorCfg.LedIndex)
+			}
+		}
+		for _, linked := range slices.Sorted(maps.Keys(zone.Links)) {
+			target, ok := c.Zones[linked]
+			if !ok || linked == name {
+				return fmt.Errorf("zone %q: Links must name other zones, got %q", name, linked)
+			}
+			if !slices.Contains(target.Sensors, zone.Links[linked]) {
+				return fmt.Errorf("zone %q: linked sensor '%s' isn't a sensor of zone %q", name, zone.Links[linked], linked)
+			}
+		}
+		// The audio device can only be opened once.
+		if zone.AudioLED.Enabled || zone.AudioLED.Beat.Enabled {
+			if audioZone != "" {
+				return fmt.Errorf("AudioLED can only be used in one zone, but zones %q and %q use it", audioZone, name)
+			}
+			audioZone = name
+		}
+	}
+
+	for _, name := range names {
+		if err := c.Zone(name).Validate(); err != nil {
+			return fmt.Errorf("zone %q configuration invalid: %w", name, err)
+		}
+	}
 	return nil
 }
 
+// Zone returns the configuration of the zone name: its producer
+// sections and Schedule with the hardware of its virtual strip, whose
+// sensors, matrix layout and positions use LED indices of the zone.
+func (c *Config) Zone(name string) *Config {
+	zone := c.Zones[name]
+	conf := zone.Config
+	conf.Palette = c.Palette
+	conf.Logging = c.Logging
+	conf.Zones = nil
+	conf.ApiZone = ""
+	conf.Hardware = c.Hardware
+	leds := zone.Leds()
+	display := &conf.Hardware.Display
+	display.LedsTotal = len(leds)
+	display.LedSegments = nil
+	// The shared platform applies the power model and the ambient
+	// light to the whole strip.
+	display.Power = PowerConfig{}
+	display.Layout = c.Hardware.Display.Layout.zone(leds)
+	display.Positions = c.Hardware.Display.Positions.zone(leds)
+	conf.Hardware.AmbientLight = AmbientLightConfig{}
+	sensors := make(map[string]SensorCfg, len(zone.Sensors))
+	for _, uid := range zone.Sensors {
+		sensorCfg := c.Hardware.Sensors.SensorCfg[uid]
+		sensorCfg.LedIndex = zone.Index(sensorCfg.LedIndex)
+		if !display.Positions.Enabled {
+			sensorCfg.Position = nil
+		}
+		sensors[uid] = sensorCfg
+	}
+	conf.Hardware.Sensors.SensorCfg = sensors
+	return &conf
+}
+
 func ReadConfig(cfile string) (*Config, error) {
 	slog.Info("Reading config file", "file", cfile)
 	var conf Config
@@ -473,11 +2025,20 @@ func ReadConfig(cfile string) (*Config, error) {
 		return nil, err
 	}
 	defer f.Close()
+	// The colour references are resolved in the YAML tree, so the
+	// producer sections keep plain [red, green, blue] fields.
+	var doc yaml.Node
 	decoder := yaml.NewDecoder(f)
-	err = decoder.Decode(&conf)
+	err = decoder.Decode(&doc)
 	if err != nil {
 		return nil, err
 	}
+	if err := resolveColors(&doc); err != nil {
+		return nil, fmt.Errorf("resolving colours failed: %w", err)
+	}
+	if err := doc.Decode(&conf); err != nil {
+		return nil, err
+	}
 
 	if err := conf.Validate(); err != nil {
 		return nil, fmt.Errorf("configuration validation failed: %w", err)
@@ -186,4 +186,864 @@ func TestReadConfig_InvalidBlobX(t *testing.T) {
 	_, err := ReadConfig(configFile)
 	assert.Error(t, err, "ReadConfig should return an error for Blob X out of bounds")
 	assert.Contains(t, err.Error(), "must be between 0 and 9", "Error message should indicate invalid X range")
-}
\ No newline at end of file
+}
+func TestReadConfig_CylonLED(t *testing.T) {
+	base := strings.Replace(getBaseConfig(), "Step: 1\n  Width: 1\n",
+		"Step: 1\n  Width: 1\n  Eyes: 2\n  HueShift: 60\n  Trail: 3.5\n  Easing: Sine\n", 1)
+	conf, err := ReadConfig(createConfigFile(t, base))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, conf.CylonLED.Eyes)
+	assert.Equal(t, 3.5, conf.CylonLED.Trail)
+
+	tests := map[string]struct {
+		old, new, expected string
+	}{
+		"negative eyes":  {"Eyes: 2", "Eyes: -1", "Eyes must be non-negative"},
+		"too many eyes":  {"Eyes: 2", "Eyes: 11", "11 eyes of Width 1 don't fit"},
+		"hue shift":      {"HueShift: 60", "HueShift: 400", "HueShift must be between"},
+		"negative trail": {"Trail: 3.5", "Trail: -1", "Trail must be non-negative"},
+		"unknown easing": {"Easing: Sine", "Easing: Bounce", `unknown Easing "Bounce"`},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ReadConfig(createConfigFile(t, strings.Replace(base, tc.old, tc.new, 1)))
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.expected)
+			}
+		})
+	}
+}
+
+func TestReadConfig_MultiBlobPhysics(t *testing.T) {
+	base := strings.Replace(getBaseConfig(), "BlobCfg: []\n",
+		"BlobCfg:\n    - { DeltaX: 0.1, X: 2, Width: 1, Mass: 2, LedRGB: [0, 0, 0] }\n"+
+			"  Physics:\n    Enabled: true\n    Friction: 0.2\n    Impulse: 0.5\n    MergeSpeed: 0.1\n    SplitAfter: 5s\n    SpawnAtTrigger: true\n", 1)
+	conf, err := ReadConfig(createConfigFile(t, base))
+	assert.NoError(t, err)
+	assert.Equal(t, 2.0, conf.MultiBlobLED.BlobCfg[0].Mass)
+	assert.Equal(t, 5*time.Second, conf.MultiBlobLED.Physics.SplitAfter)
+	assert.True(t, conf.MultiBlobLED.Physics.SpawnAtTrigger)
+
+	tests := map[string]struct {
+		old, new, expected string
+	}{
+		"negative mass":    {"Mass: 2", "Mass: -1", "Mass must be non-negative"},
+		"friction":         {"Friction: 0.2", "Friction: 1", "Friction must be at least 0"},
+		"negative impulse": {"Impulse: 0.5", "Impulse: -1", "Impulse must be non-negative"},
+		"negative merge":   {"MergeSpeed: 0.1", "MergeSpeed: -1", "MergeSpeed must be non-negative"},
+		"negative split":   {"SplitAfter: 5s", "SplitAfter: -5s", "SplitAfter must be non-negative"},
+		"disabled physics": {"Enabled: true\n    Friction: 0.2", "Enabled: false\n    Friction: 2", ""},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ReadConfig(createConfigFile(t, strings.Replace(base, tc.old, tc.new, 1)))
+			if tc.expected == "" {
+				assert.NoError(t, err)
+			} else if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.expected)
+			}
+		})
+	}
+}
+
+func TestReadConfig_Schedule(t *testing.T) {
+	schedule := `
+Schedule:
+  Enabled: true
+  Holidays: [12-25, 2024-05-01]
+  Rules:
+    - Producers: [AudioLED]
+      From: "23:00"
+      To: "06:00"
+      Disable: true
+    - Producers: [SensorLED]
+      Cron: "* 0-5 * * *"
+      LedRGB: [10, 10, 10]
+`
+	conf, err := ReadConfig(createConfigFile(t, getBaseConfig()+schedule))
+	assert.NoError(t, err)
+	assert.Len(t, conf.Schedule.Rules, 2)
+	assert.True(t, conf.Schedule.Rules[0].Disable)
+}
+
+func TestReadConfig_InvalidSchedule(t *testing.T) {
+	tests := map[string]struct {
+		rule     string
+		expected string
+	}{
+		"unknown producer": {"{ Producers: [FooLED], Disable: true }", "unknown producer"},
+		"cron and from":    {"{ Producers: [ClockLED], Cron: '* * * * *', From: '10:00' }", "can't be combined"},
+		"bad cron":         {"{ Producers: [ClockLED], Cron: '61 * * * *' }", "Cron invalid"},
+		"bad days":         {"{ Producers: [ClockLED], Days: 'someday' }", "Days invalid"},
+		"bad anchor":       {"{ Producers: [ClockLED], From: 'noon' }", "From invalid"},
+		"to without from":  {"{ Producers: [ClockLED], To: '10:00' }", "To requires From"},
+		"bad brightness":   {"{ Producers: [ClockLED], Brightness: 1.5 }", "Brightness"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			schedule := "\nSchedule:\n  Enabled: true\n  Rules:\n    - " + tc.rule + "\n"
+			_, err := ReadConfig(createConfigFile(t, getBaseConfig()+schedule))
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.expected)
+			}
+		})
+	}
+
+	// A disabled schedule is not validated.
+	schedule := "\nSchedule:\n  Enabled: false\n  Rules:\n    - { Producers: [FooLED] }\n"
+	_, err := ReadConfig(createConfigFile(t, getBaseConfig()+schedule))
+	assert.NoError(t, err)
+}
+
+func TestReadConfig_NightLEDStops(t *testing.T) {
+	stops := "\n  Stops:\n    - { At: sunset, LedRGB: [0, 0, 0] }\n    - { At: civil-dusk+10m, LedRGB: [20, 5, 0] }\n    - { At: '06:00', LedRGB: [0, 0, 0] }\n"
+	configData := strings.Replace(getBaseConfig(), "  LedRGB: [[0, 0, 0]]\n", "  LedRGB: [[0, 0, 0]]"+stops, 1)
+	conf, err := ReadConfig(createConfigFile(t, configData))
+	assert.NoError(t, err)
+	assert.Len(t, conf.NightLED.Stops, 3)
+
+	invalid := strings.Replace(configData, "civil-dusk+10m", "teatime", 1)
+	_, err = ReadConfig(createConfigFile(t, invalid))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Stops[1].At invalid")
+	}
+}
+
+func TestReadConfig_AmbientLight(t *testing.T) {
+	ambient := "  AmbientLight:\n    Enabled: true\n    Source: ADC\n    SpiMultiplex: ADC1\n    AdcChannel: 3\n" +
+		"    ReadInterval: 500ms\n    Smoothing: 10s\n    Curve: [[0, 0.1], [600, 1]]\n"
+	base := strings.Replace(getBaseConfig(), "  SpiMultiplexGPIO: {}\n", ambient+"  SpiMultiplexGPIO: { ADC1: { Low: [1] } }\n", 1)
+	conf, err := ReadConfig(createConfigFile(t, base))
+	assert.NoError(t, err)
+	assert.Equal(t, 10*time.Second, conf.Hardware.AmbientLight.Smoothing)
+
+	tests := map[string]struct {
+		old, new, expected string
+	}{
+		"undefined multiplex": {"SpiMultiplex: ADC1", "SpiMultiplex: ADC9", "undefined SpiMultiplex"},
+		"unknown source":      {"Source: ADC", "Source: TSL2561", "unknown Source"},
+		"single point":        {"[[0, 0.1], [600, 1]]", "[[0, 0.1]]", "at least two points"},
+		"decreasing curve":    {"[[0, 0.1], [600, 1]]", "[[600, 0.1], [0, 1]]", "must be increasing"},
+		"factor too high":     {"[[0, 0.1], [600, 1]]", "[[0, 0.1], [600, 2]]", "between 0 and 1"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ReadConfig(createConfigFile(t, strings.Replace(base, tc.old, tc.new, 1)))
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.expected)
+			}
+		})
+	}
+}
+
+func TestReadConfig_Power(t *testing.T) {
+	power := "    ColorCorrection: [1, 1, 1]\n    Power:\n      Enabled: true\n      ChannelMilliamps: [20, 20, 20]\n" +
+		"      IdleMilliamps: 1\n      BudgetMilliamps: 2000\n      SegmentBudgetMilliamps: 1000\n"
+	base := strings.Replace(getBaseConfig(), "    LedsTotal: 10\n", "    LedsTotal: 10\n"+power, 1)
+	conf, err := ReadConfig(createConfigFile(t, base))
+	assert.NoError(t, err)
+	assert.Equal(t, 2000.0, conf.Hardware.Display.Power.BudgetMilliamps)
+
+	tests := map[string]struct {
+		old, new, expected string
+	}{
+		"two channels":         {"ChannelMilliamps: [20, 20, 20]", "ChannelMilliamps: [20, 20]", "exactly 3 components"},
+		"negative channel":     {"ChannelMilliamps: [20, 20, 20]", "ChannelMilliamps: [20, -1, 20]", "must not be negative"},
+		"negative budget":      {"BudgetMilliamps: 2000", "BudgetMilliamps: -5", "must not be negative"},
+		"no colour correction": {"    ColorCorrection: [1, 1, 1]\n", "", "ColorCorrection"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ReadConfig(createConfigFile(t, strings.Replace(base, tc.old, tc.new, 1)))
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.expected)
+			}
+		})
+	}
+}
+
+func TestReadConfig_AudioSpectrum(t *testing.T) {
+	spectrum := "  Mode: Spectrum\n  Spectrum:\n    Bands: 2\n    MinFreq: 50\n    MaxFreq: 16000\n    LogScale: true\n" +
+		"    PeakHold: 200ms\n    FallOff: 1.5\n    Palette: [[255, 0, 0], [0, 0, 255]]\n"
+	base := strings.Replace(getBaseConfig(), "  MaxDB: -10\n", "  MaxDB: -10\n"+spectrum, 1)
+	conf, err := ReadConfig(createConfigFile(t, base))
+	assert.NoError(t, err)
+	assert.Equal(t, 200*time.Millisecond, conf.AudioLED.Spectrum.PeakHold)
+
+	tests := map[string]struct {
+		old, new, expected string
+	}{
+		"unknown mode":      {"Mode: Spectrum", "Mode: Oscilloscope", "unknown Mode"},
+		"too many bands":    {"Bands: 2", "Bands: 3", "must not exceed the number of LEDs"},
+		"above nyquist":     {"MaxFreq: 16000", "MaxFreq: 30000", "half the SampleRate"},
+		"inverted range":    {"MinFreq: 50", "MinFreq: 20000", "less than MaxFreq"},
+		"empty palette":     {"[[255, 0, 0], [0, 0, 255]]", "[]", "at least one colour"},
+		"invalid palette":   {"[0, 0, 255]", "[0, 0, 256]", "Palette[1] invalid"},
+		"negative fall-off": {"FallOff: 1.5", "FallOff: -1", "non-negative"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ReadConfig(createConfigFile(t, strings.Replace(base, tc.old, tc.new, 1)))
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.expected)
+			}
+		})
+	}
+}
+
+func TestReadConfig_Beat(t *testing.T) {
+	beat := "  Beat:\n    Enabled: true\n    Sensitivity: 1.4\n    MinInterval: 250ms\n" +
+		"    FlashLedRGB: [0, 0, 80]\n    FlashDuration: 150ms\n"
+	base := strings.Replace(getBaseConfig(), "  MaxDB: -10\n", "  MaxDB: -10\n"+beat, 1)
+	base = strings.Replace(base, "\nMultiBlobLED:", "  BeatSync: true\n\nMultiBlobLED:", 1)
+	conf, err := ReadConfig(createConfigFile(t, base))
+	assert.NoError(t, err)
+	assert.Equal(t, 150*time.Millisecond, conf.AudioLED.Beat.FlashDuration)
+
+	tests := map[string]struct {
+		old, new, expected string
+	}{
+		"beats disabled":    {"    Enabled: true\n    Sensitivity", "    Enabled: false\n    Sensitivity", "require AudioLED.Beat"},
+		"low sensitivity":   {"Sensitivity: 1.4", "Sensitivity: 1", "greater than 1"},
+		"invalid flash":     {"FlashLedRGB: [0, 0, 80]", "FlashLedRGB: [0, 80]", "FlashLedRGB invalid"},
+		"no flash duration": {"FlashDuration: 150ms", "FlashDuration: 0s", "FlashDuration must be positive"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ReadConfig(createConfigFile(t, strings.Replace(base, tc.old, tc.new, 1)))
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.expected)
+			}
+		})
+	}
+}
+
+func TestReadConfig_AudioSource(t *testing.T) {
+	source := "  Source: TestSignal\n  Signal: { Frequency: 440, Amplitude: 0.3, BPM: 120 }\n" +
+		"  File: /tmp/music.wav\n  Command: [parec, --format=s16le]\n  PCMFormat: S16LE\n  Channels: 2\n"
+	base := strings.Replace(getBaseConfig(), "  MaxDB: -10\n", "  MaxDB: -10\n"+source, 1)
+	conf, err := ReadConfig(createConfigFile(t, base))
+	assert.NoError(t, err)
+	assert.Equal(t, 120.0, conf.AudioLED.Signal.BPM)
+
+	for _, valid := range []string{"File", "Command", "Stdin", "PortAudio"} {
+		_, err := ReadConfig(createConfigFile(t, strings.Replace(base, "Source: TestSignal", "Source: "+valid, 1)))
+		assert.NoError(t, err, valid)
+	}
+
+	tests := map[string]struct {
+		source, old, new, expected string
+	}{
+		"unknown source":   {"Radio", "", "", "unknown Source"},
+		"no file":          {"File", "File: /tmp/music.wav", "File: ''", "File must be set"},
+		"no command":       {"Command", "[parec, --format=s16le]", "[]", "Command must be set"},
+		"raw mono/stereo":  {"Stdin", "Channels: 2", "Channels: 0", "Channels must be 1 or 2"},
+		"unknown format":   {"", "PCMFormat: S16LE", "PCMFormat: S24LE", "unknown PCMFormat"},
+		"signal frequency": {"", "Frequency: 440", "Frequency: 30000", "less than half the SampleRate"},
+		"signal amplitude": {"", "Amplitude: 0.3", "Amplitude: 2", "between 0 and 1"},
+		"negative tempo":   {"", "BPM: 120", "BPM: -1", "BPM must be non-negative"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			data := strings.Replace(base, tc.old, tc.new, 1)
+			if tc.source != "" {
+				data = strings.Replace(data, "Source: TestSignal", "Source: "+tc.source, 1)
+			}
+			_, err := ReadConfig(createConfigFile(t, data))
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.expected)
+			}
+		})
+	}
+}
+
+func TestReadConfig_VUMeter(t *testing.T) {
+	vu := "  Ballistics: PPM\n  Attack: 5ms\n  Release: 1s\n  PeakLedRGB: [0, 0, 100]\n  PeakHold: 1s\n" +
+		"  PeakFallOff: 0.5\n  Layout: CentreOut\n  Mono: true\n"
+	base := strings.Replace(getBaseConfig(), "  MaxDB: -10\n", "  MaxDB: -10\n"+vu, 1)
+	conf, err := ReadConfig(createConfigFile(t, base))
+	assert.NoError(t, err)
+	assert.True(t, conf.AudioLED.Mono)
+
+	tests := map[string]struct {
+		old, new, expected string
+	}{
+		"unknown ballistics": {"Ballistics: PPM", "Ballistics: Slow", "unknown Ballistics"},
+		"negative attack":    {"Attack: 5ms", "Attack: -5ms", "Attack and Release"},
+		"invalid peak":       {"[0, 0, 100]", "[0, 0, 300]", "PeakLedRGB invalid"},
+		"negative fall-off":  {"PeakFallOff: 0.5", "PeakFallOff: -1", "PeakHold and PeakFallOff"},
+		"unknown layout":     {"Layout: CentreOut", "Layout: Spiral", "unknown Layout"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ReadConfig(createConfigFile(t, strings.Replace(base, tc.old, tc.new, 1)))
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.expected)
+			}
+		})
+	}
+}
+
+func TestReadConfig_WeatherLED(t *testing.T) {
+	weather := `
+WeatherLED:
+  Enabled: true
+  Source: HTTP
+  URL: http://localhost:8000/weather.json
+  PollInterval: 15m
+  MQTT: { Broker: "localhost:1883", Topic: weather/current }
+  Fields: { Temperature: current.temperature_2m, Rain: hourly.rain.0 }
+  StartLed: 2
+  EndLed: 9
+  Delay: 50ms
+  TemperatureColors: [[-10, 0, 0, 60], [30, 80, 0, 0]]
+  RainLedRGB: [20, 20, 80]
+  MaxRain: 5
+  WindFactor: 0.2
+`
+	// The WeatherLED producer alone is enough.
+	base := strings.Replace(getBaseConfig(), "Enabled: true", "Enabled: false", 1) + weather
+	conf, err := ReadConfig(createConfigFile(t, base))
+	assert.NoError(t, err)
+	assert.Equal(t, "hourly.rain.0", conf.WeatherLED.Fields.Rain)
+	assert.Equal(t, 15*time.Minute, conf.WeatherLED.PollInterval)
+
+	valid := map[string]struct{ old, new string }{
+		"mqtt without poll interval": {"Source: HTTP\n  URL: http://localhost:8000/weather.json\n  PollInterval: 15m", "Source: MQTT"},
+		"file":                       {"Source: HTTP", "Source: File\n  File: /tmp/weather.json"},
+		"no rain":                    {"RainLedRGB: [20, 20, 80]\n  MaxRain: 5", "RainLedRGB: []"},
+	}
+	for name, tc := range valid {
+		_, err := ReadConfig(createConfigFile(t, strings.Replace(base, tc.old, tc.new, 1)))
+		assert.NoError(t, err, name)
+	}
+
+	tests := map[string]struct {
+		source, old, new, expected string
+	}{
+		"unknown source":   {"Radio", "", "", "unknown Source"},
+		"no file":          {"File", "", "", "File must be set"},
+		"no url":           {"", "URL: http://localhost:8000/weather.json", "URL: localhost", "http or https URL"},
+		"no broker":        {"MQTT", `Broker: "localhost:1883", `, "", "MQTT.Broker and MQTT.Topic"},
+		"no poll interval": {"", "PollInterval: 15m", "PollInterval: 0s", "PollInterval must be positive"},
+		"range too long":   {"", "EndLed: 9", "EndLed: 10", "StartLed and EndLed"},
+		"reversed range":   {"", "StartLed: 2\n  EndLed: 9", "StartLed: 9\n  EndLed: 2", "StartLed (9) > EndLed (2)"},
+		"no delay":         {"", "Delay: 50ms", "Delay: 0s", "Delay must be positive"},
+		"no colours":       {"", "[[-10, 0, 0, 60], [30, 80, 0, 0]]", "[]", "at least one point"},
+		"short point":      {"", "[-10, 0, 0, 60]", "[-10, 0, 0]", "point 0 must be"},
+		"invalid colour":   {"", "[30, 80, 0, 0]", "[30, 300, 0, 0]", "point 1 invalid"},
+		"unsorted":         {"", "[30, 80, 0, 0]", "[-20, 80, 0, 0]", "must be increasing"},
+		"invalid rain":     {"", "[20, 20, 80]", "[20, 20]", "RainLedRGB invalid"},
+		"no max rain":      {"", "MaxRain: 5", "MaxRain: 0", "MaxRain must be positive"},
+		"negative wind":    {"", "WindFactor: 0.2", "WindFactor: -1", "WindFactor must not be negative"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			data := strings.Replace(base, tc.old, tc.new, 1)
+			if tc.source != "" {
+				data = strings.Replace(data, "Source: HTTP", "Source: "+tc.source, 1)
+			}
+			_, err := ReadConfig(createConfigFile(t, data))
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.expected)
+			}
+		})
+	}
+}
+
+func TestReadConfig_GaugeLED(t *testing.T) {
+	gauge := `
+GaugeLED:
+  Enabled: true
+  Source: API
+  Min: 0
+  Max: 100
+  StartLed: 9
+  EndLed: 0
+  Colors: [[0, 0, 80, 0], [100, 80, 0, 0]]
+  Thresholds:
+    - { Value: 80, LedRGB: [80, 40, 0] }
+    - { Value: 95, LedRGB: [80, 0, 0], Blink: true }
+  Transition: 500ms
+  Delay: 25ms
+`
+	base := getBaseConfig() + gauge
+	conf, err := ReadConfig(createConfigFile(t, base))
+	assert.NoError(t, err)
+	assert.Equal(t, 9, conf.GaugeLED.StartLed)
+	assert.True(t, conf.GaugeLED.Thresholds[1].Blink)
+
+	// The other sources are read from a data source.
+	_, err = ReadConfig(createConfigFile(t, strings.Replace(base, "Source: API", "Source: MQTT\n  MQTT: { Broker: \"localhost:1883\", Topic: printer/progress }", 1)))
+	assert.NoError(t, err)
+
+	tests := map[string]struct {
+		old, new, expected string
+	}{
+		"no source":           {"Source: API", "Source: File", "File must be set"},
+		"empty range":         {"Min: 0\n  Max: 100", "Min: 100\n  Max: 100", "must be less than Max"},
+		"range too long":      {"EndLed: 0", "EndLed: 10", "StartLed and EndLed"},
+		"no colours":          {"[[0, 0, 80, 0], [100, 80, 0, 0]]", "[]", "Colors invalid"},
+		"invalid threshold":   {"[80, 40, 0]", "[80, 40]", "Thresholds[0].LedRGB invalid"},
+		"unsorted":            {"Value: 95", "Value: 80", "must be increasing"},
+		"negative transition": {"Transition: 500ms", "Transition: -1s", "Transition must not be negative"},
+		"no delay":            {"Delay: 25ms", "Delay: 0s", "Delay must be positive"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ReadConfig(createConfigFile(t, strings.Replace(base, tc.old, tc.new, 1)))
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.expected)
+			}
+		})
+	}
+}
+
+func TestReadConfig_AmbientLED(t *testing.T) {
+	ambient := `
+FireLED:
+  Enabled: true
+  StartLed: 9
+  EndLed: 0
+  Delay: 30ms
+  Seed: 42
+  Cooling: 0.1
+  Sparking: 0.5
+  Colors: [[0, 0, 0, 0], [1, 200, 160, 60]]
+CandleLED:
+  Enabled: true
+  StartLed: 0
+  EndLed: 4
+  Delay: 40ms
+  LedRGB: [120, 50, 5]
+  Flicker: 0.4
+TwinkleLED:
+  Enabled: true
+  StartLed: 0
+  EndLed: 9
+  Delay: 30ms
+  Colors: [[60, 60, 60], warm]
+  Density: 0.1
+  Lifetime: 2s
+NoiseLED:
+  Enabled: true
+  StartLed: 0
+  EndLed: 9
+  Delay: 30ms
+  Colors: [[0, 0, 0, 60], [1, 40, 0, 60]]
+  Scale: 12
+  Speed: 0.2
+BreathingLED:
+  Enabled: true
+  StartLed: 0
+  EndLed: 9
+  Delay: 30ms
+  AfterSensor: true
+  Duration: 1m
+  LedRGB: [0, 40, 80]
+  Period: 5s
+  MinBrightness: 0.1
+Palette:
+  Colors:
+    warm: [80, 40, 0]
+`
+	base := getBaseConfig() + ambient
+	conf, err := ReadConfig(createConfigFile(t, base))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(42), conf.FireLED.Seed)
+	assert.Equal(t, 9, conf.FireLED.StartLed)
+	assert.Equal(t, []float64{80, 40, 0}, conf.TwinkleLED.Colors[1])
+	assert.True(t, conf.BreathingLED.AfterSensor)
+	assert.Equal(t, time.Minute, conf.BreathingLED.Duration)
+
+	tests := map[string]struct {
+		old, new, expected string
+	}{
+		"range too long":     {"EndLed: 4", "EndLed: 10", "CandleLED configuration invalid: StartLed and EndLed"},
+		"no delay":           {"Delay: 40ms", "Delay: 0s", "CandleLED configuration invalid: Delay must be positive"},
+		"no duration":        {"Duration: 1m", "Duration: 0s", "Duration must be positive with AfterSensor"},
+		"cooling":            {"Cooling: 0.1", "Cooling: 1.5", "Cooling must be between 0 and 1"},
+		"sparking":           {"Sparking: 0.5", "Sparking: -1", "Sparking must be between 0 and 1"},
+		"fire colours":       {"[[0, 0, 0, 0], [1, 200, 160, 60]]", "[]", "FireLED configuration invalid: Colors invalid"},
+		"candle colour":      {"[120, 50, 5]", "[120, 50]", "CandleLED configuration invalid: LedRGB invalid"},
+		"flicker":            {"Flicker: 0.4", "Flicker: 2", "Flicker must be between 0 and 1"},
+		"no twinkle colours": {"[[60, 60, 60], warm]", "[]", "Colors needs at least one colour"},
+		"density":            {"Density: 0.1", "Density: 1", "Density must be greater than 0"},
+		"lifetime":           {"Lifetime: 2s", "Lifetime: 10ms", "Lifetime must be at least Delay"},
+		"scale":              {"Scale: 12", "Scale: 0", "Scale must be positive"},
+		"speed":              {"Speed: 0.2", "Speed: -1", "Speed must be non-negative"},
+		"period":             {"Period: 5s", "Period: 0s", "Period must be positive"},
+		"min brightness":     {"MinBrightness: 0.1", "MinBrightness: 2", "MinBrightness must be between 0 and 1"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ReadConfig(createConfigFile(t, strings.Replace(base, tc.old, tc.new, 1)))
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.expected)
+			}
+		})
+	}
+
+	// Running after the sensor needs the SensorLED, running permanently
+	// doesn't.
+	noSensor := strings.Replace(base, "SensorLED:\n  Enabled: true", "SensorLED:\n  Enabled: false", 1)
+	_, err = ReadConfig(createConfigFile(t, noSensor))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "BreathingLED with AfterSensor requires the SensorLED")
+	}
+	_, err = ReadConfig(createConfigFile(t, strings.Replace(noSensor, "AfterSensor: true", "AfterSensor: false", 1)))
+	assert.NoError(t, err)
+}
+
+func TestReadConfig_ImageLED(t *testing.T) {
+	image := `
+ImageLED:
+  Enabled: true
+  StartLed: 0
+  EndLed: 9
+  Delay: 20ms
+  File: animation.gif
+  FrameRate: 25
+  Loop: PingPong
+  Scaling: Linear
+  Brightness: 0.5
+`
+	base := getBaseConfig() + image
+	conf, err := ReadConfig(createConfigFile(t, base))
+	assert.NoError(t, err)
+	assert.Equal(t, "animation.gif", conf.ImageLED.File)
+	assert.Equal(t, 25.0, conf.ImageLED.FrameRate)
+
+	tests := map[string]struct {
+		old, new, expected string
+	}{
+		"no file":         {"File: animation.gif", `File: ""`, "File must be set"},
+		"no frame rate":   {"FrameRate: 25", "FrameRate: 0", "FrameRate must be positive"},
+		"unknown loop":    {"Loop: PingPong", "Loop: Bounce", `unknown Loop "Bounce"`},
+		"unknown scaling": {"Scaling: Linear", "Scaling: Cubic", `unknown Scaling "Cubic"`},
+		"brightness":      {"Brightness: 0.5", "Brightness: 0", "Brightness must be greater than 0"},
+		"range":           {"EndLed: 9", "EndLed: 10", "ImageLED configuration invalid: StartLed and EndLed"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ReadConfig(createConfigFile(t, strings.Replace(base, tc.old, tc.new, 1)))
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.expected)
+			}
+		})
+	}
+}
+
+func TestReadConfig_Layout(t *testing.T) {
+	layout := `    LedsTotal: 10
+    Layout:
+      Enabled: true
+      Width: 4
+      Height: 2
+      Serpentine: true
+      Segments:
+        - { FirstLed: 0, X: 0, Y: 0, Width: 2, Height: 2 }
+        - { FirstLed: 4, X: 2, Y: 0, Width: 2, Height: 2 }
+`
+	base := strings.Replace(getBaseConfig(), "    LedsTotal: 10\n", layout, 1)
+	conf, err := ReadConfig(createConfigFile(t, base))
+	if assert.NoError(t, err) {
+		assert.Len(t, conf.Hardware.Display.Layout.Blocks(), 2)
+	}
+
+	tests := map[string]struct {
+		old, new, expected string
+	}{
+		"no width":        {"Width: 4", "Width: 0", "Width and Height must be positive"},
+		"outside":         {"X: 2, Y: 0", "X: 3, Y: 0", "segment 1 doesn't fit into the 4x2 matrix"},
+		"too few leds":    {"FirstLed: 4", "FirstLed: 8", "segment 1 needs LEDs 8 to 11, but LedsTotal is 10"},
+		"led used twice":  {"FirstLed: 4", "FirstLed: 2", "segment 1: LED 2 is used twice"},
+		"cell used twice": {"X: 2, Y: 0", "X: 1, Y: 0", "segment 1: cell 1,0 is covered twice"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ReadConfig(createConfigFile(t, strings.Replace(base, tc.old, tc.new, 1)))
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.expected)
+			}
+		})
+	}
+
+	// Without segments the whole matrix is one block from LED 0.
+	whole := "    LedsTotal: 10\n    Layout: { Enabled: true, Width: 5, Height: 2 }\n"
+	conf, err = ReadConfig(createConfigFile(t, strings.Replace(getBaseConfig(), "    LedsTotal: 10\n", whole, 1)))
+	if assert.NoError(t, err) {
+		assert.Equal(t, []LayoutSegmentConfig{{Width: 5, Height: 2}}, conf.Hardware.Display.Layout.Blocks())
+	}
+	_, err = ReadConfig(createConfigFile(t, strings.Replace(getBaseConfig(), "    LedsTotal: 10\n", strings.Replace(whole, "Width: 5", "Width: 6", 1), 1)))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "segment 0 needs LEDs 0 to 11, but LedsTotal is 10")
+	}
+}
+
+func TestReadConfig_Positions(t *testing.T) {
+	positions := `    LedsTotal: 10
+    Positions:
+      Enabled: true
+      Points:
+        - { Led: 0, At: [0, 0] }
+        - { Led: 4, At: [1, 0] }
+        - { Led: 9, At: [1, 2] }
+`
+	base := strings.Replace(getBaseConfig(), "    LedsTotal: 10\n", positions, 1)
+	base = strings.Replace(base, "  LatchLedRGB: [0, 0, 0]\n", "  LatchLedRGB: [0, 0, 0]\n  RunUpSpeed: 1.5\n  RunDownSpeed: 0.5\n", 1)
+	conf, err := ReadConfig(createConfigFile(t, base))
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2, conf.Hardware.Display.Positions.Dimensions())
+		assert.Equal(t, 1.5, conf.SensorLED.RunUpSpeed)
+	}
+
+	tests := map[string]struct {
+		old, new, expected string
+	}{
+		"one point":       {"        - { Led: 4, At: [1, 0] }\n        - { Led: 9, At: [1, 2] }\n", "", "at least 2 Points are needed"},
+		"not from 0":      {"Led: 0,", "Led: 1,", "the first point must be LED 0 and the last one LED 9"},
+		"not to the end":  {"Led: 9,", "Led: 8,", "the first point must be LED 0 and the last one LED 9"},
+		"too many dims":   {"At: [0, 0]", "At: [0, 0, 0, 0]", "points must have 1, 2 or 3 components, got 4"},
+		"mixed dims":      {"At: [1, 0]", "At: [1]", "point 1 has 1 components, expected 2"},
+		"colour point":    {"At: [1, 0]", `At: "#ff0000"`, "cannot unmarshal"},
+		"not increasing":  {"Led: 4,", "Led: 0,", "point 1: Led must be increasing"},
+		"negative speed":  {"RunUpSpeed: 1.5", "RunUpSpeed: -1", "RunUpSpeed and RunDownSpeed must be non-negative"},
+		"speed, no delay": {"RunUpDelay: 10ms", "RunUpDelay: 0s", "RunUpDelay and RunDownDelay must be positive with a speed"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ReadConfig(createConfigFile(t, strings.Replace(base, tc.old, tc.new, 1)))
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.expected)
+			}
+		})
+	}
+
+	// Sensors can have a point of the same dimensions.
+	sensor := strings.Replace(base, "SpiMultiplexGPIO: {}", "SpiMultiplexGPIO: { ADC: { Low: [1], High: [2] } }", 1)
+	sensor = strings.Replace(sensor, "SensorCfg: {}", "SensorCfg: { S0: { LedIndex: 2, SpiMultiplex: ADC, Position: [1, 0.5] } }", 1)
+	conf, err = ReadConfig(createConfigFile(t, sensor))
+	if assert.NoError(t, err) {
+		assert.Equal(t, []float64{1, 0.5}, conf.Hardware.Sensors.SensorCfg["S0"].Position)
+	}
+	_, err = ReadConfig(createConfigFile(t, strings.Replace(sensor, "Position: [1, 0.5]", "Position: [1]", 1)))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "sensor 'S0' has a Position with 1 components, expected 2")
+	}
+	_, err = ReadConfig(createConfigFile(t, strings.Replace(sensor, "Enabled: true\n      Points", "Enabled: false\n      Points", 1)))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "sensor 'S0' has a Position, but Positions are not enabled")
+	}
+}
+
+func TestReadConfig_Zones(t *testing.T) {
+	// The producer sections of the zones are indented below their zone.
+	sections := strings.ReplaceAll(validSensorLED+validNightLED+validClockLED+validAudioLED+validCylonLED+validMultiBlobLED, "\n", "\n    ")
+	hardware := strings.Replace(commonHardware, "SpiMultiplexGPIO: {}", "SpiMultiplexGPIO: { ADC: { Low: [1], High: [2] } }", 1)
+	hardware = strings.Replace(hardware, "SensorCfg: {}", "SensorCfg: { S0: { LedIndex: 1, SpiMultiplex: ADC }, S1: { LedIndex: 8, SpiMultiplex: ADC } }", 1)
+	base := hardware + `
+Zones:
+  a:
+    Segments: [{ FirstLed: 0, LastLed: 4 }]
+    Sensors: [S0]
+    Links: { b: S1 }` + sections + `
+  b:
+    Segments: [{ FirstLed: 5, LastLed: 9, Reverse: true }]
+    Sensors: [S1]` + sections
+	conf, err := ReadConfig(createConfigFile(t, base))
+	if assert.NoError(t, err) {
+		zoneB := conf.Zones["b"]
+		assert.Equal(t, []int{9, 8, 7, 6, 5}, zoneB.Leds())
+		b := conf.Zone("b")
+		assert.Equal(t, 5, b.Hardware.Display.LedsTotal)
+		assert.Equal(t, 1, b.Hardware.Sensors.SensorCfg["S1"].LedIndex)
+		assert.NotContains(t, b.Hardware.Sensors.SensorCfg, "S0")
+		assert.True(t, b.SensorLED.Enabled)
+	}
+
+	tests := map[string]struct {
+		old, new, expected string
+	}{
+		"no segments":    {"Segments: [{ FirstLed: 5, LastLed: 9, Reverse: true }]", "Segments: []", `zone "b" needs at least one segment`},
+		"out of bounds":  {"LastLed: 9", "LastLed: 10", `zone "b": segment 0 has out-of-bounds indices`},
+		"overlap":        {"FirstLed: 5", "FirstLed: 4", `zone "b": LED 4 belongs to zone "a" too`},
+		"unknown sensor": {"Sensors: [S0]", "Sensors: [S2]", `zone "a": unknown sensor 'S2'`},
+		"two zones":      {"Sensors: [S1]", "Sensors: [S1, S0]", `sensor 'S0' belongs to zone "a" and zone "b"`},
+		"sensor outside": {"LedIndex: 8", "LedIndex: 3", `zone "b": sensor 'S1' at LED 3 is outside the zone`},
+		"unknown link":   {"Links: { b: S1 }", "Links: { c: S1 }", `zone "a": Links must name other zones, got "c"`},
+		"link to itself": {"Links: { b: S1 }", "Links: { a: S0 }", `zone "a": Links must name other zones, got "a"`},
+		"linked sensor":  {"Links: { b: S1 }", "Links: { b: S0 }", `zone "a": linked sensor 'S0' isn't a sensor of zone "b"`},
+		"zone producer":  {"RunUpDelay: 10ms", "RunUpDelay: -1s", `zone "a" configuration invalid: SensorLED configuration invalid`},
+		"zone range":     {"EndLedMinute: 3", "EndLedMinute: 5", `zone "a" configuration invalid: ClockLED configuration invalid`},
+		"api zone":       {"\nZones:\n", "\nApiZone: c\nZones:\n", `ApiZone "c" isn't one of the Zones`},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ReadConfig(createConfigFile(t, strings.Replace(base, tc.old, tc.new, 1)))
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.expected)
+			}
+		})
+	}
+
+	// Only one zone can use the audio input.
+	_, err = ReadConfig(createConfigFile(t, strings.ReplaceAll(base, "AudioLED:\n      Enabled: false", "AudioLED:\n      Enabled: true")))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `AudioLED can only be used in one zone, but zones "a" and "b" use it`)
+	}
+
+	_, err = ReadConfig(createConfigFile(t, getBaseConfig()+"ApiZone: a\n"))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `ApiZone "a" is set, but there are no Zones`)
+	}
+
+	// The LEDs of a zone keep their cells of the matrix and their
+	// positions.
+	mapped := strings.Replace(base, "    LedsTotal: 10\n", `    LedsTotal: 10
+    Layout: { Enabled: true, Width: 5, Height: 2 }
+    Positions: { Enabled: true, Points: [{ Led: 0, At: [0] }, { Led: 9, At: [4.5] }] }
+`, 1)
+	mapped = strings.Replace(mapped, "LedIndex: 8, SpiMultiplex: ADC", "LedIndex: 8, SpiMultiplex: ADC, Position: [4.2]", 1)
+	conf, err = ReadConfig(createConfigFile(t, mapped))
+	if assert.NoError(t, err) {
+		b := conf.Zone("b")
+		layout := b.Hardware.Display.Layout
+		assert.True(t, layout.Enabled)
+		x, y, ok := layout.Cell(0)
+		assert.True(t, ok)
+		assert.Equal(t, []int{4, 1}, []int{x, y})
+		positions := b.Hardware.Display.Positions
+		assert.Equal(t, []float64{4.5}, positions.PointOf(0))
+		assert.Equal(t, []float64{2.5}, positions.PointOf(4))
+		assert.Equal(t, []float64{4.2}, b.Hardware.Sensors.SensorCfg["S1"].Position)
+	}
+}
+
+func TestReadConfig_MessageLED(t *testing.T) {
+	message := `
+MessageLED:
+  Enabled: true
+  Encoding: Digits
+  StartLed: 0
+  EndLed: 9
+  DigitColors: [[0, 0, 0], [20, 10, 0], [50, 0, 0], [50, 20, 0], [50, 50, 0], [0, 50, 0], [0, 0, 50], [30, 0, 30], [10, 10, 10], [50, 50, 50]]
+  Message: "42"
+  Speed: 10
+  Delay: 50ms
+`
+	base := getBaseConfig() + message
+	conf, err := ReadConfig(createConfigFile(t, base))
+	assert.NoError(t, err)
+	assert.Equal(t, "42", conf.MessageLED.Message)
+
+	tests := map[string]struct {
+		old, new, expected string
+	}{
+		"unknown encoding": {"Encoding: Digits", "Encoding: Semaphore", `unknown Encoding "Semaphore"`},
+		"no colour":        {"Encoding: Digits", "Encoding: Morse", "LedRGB invalid"},
+		"digit colours":    {"[0, 0, 0], [20, 10, 0], ", "", "DigitColors needs 10 colours, got 8"},
+		"no speed":         {"Speed: 10", "Speed: 0", "Speed must be positive"},
+		"no delay":         {"Delay: 50ms", "Delay: 0s", "Delay must be positive"},
+		"range":            {"EndLed: 9", "EndLed: 10", "MessageLED configuration invalid: StartLed and EndLed"},
+		"no group":         {"Encoding: Digits", "Encoding: Bitmap\n  LedRGB: [0, 0, 50]", "MatrixGroup must be set"},
+		"missing group":    {"Encoding: Digits", "Encoding: Bitmap\n  LedRGB: [0, 0, 50]\n  MatrixGroup: Matrix", `MatrixGroup "Matrix" must have 5 segments`},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ReadConfig(createConfigFile(t, strings.Replace(base, tc.old, tc.new, 1)))
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.expected)
+			}
+		})
+	}
+
+	schedule := "\nSchedule:\n  Enabled: true\n  Rules:\n    - { Producers: [MessageLED], From: '08:00', To: '09:00', Message: '07' }\n"
+	conf, err = ReadConfig(createConfigFile(t, base+schedule))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "07", conf.Schedule.Rules[0].Message)
+	}
+}
+
+func TestReadConfig_Palette(t *testing.T) {
+	palette := `
+Palette:
+  Colors:
+    warm: kelvin(2700, 0.5)
+    alarm: "#ff0000"
+    sky: [0, 40, 80]
+  Gradients:
+    heat: [[-10, sky], [10, "hsv(120, 1, 0.5)"], [30, 200, 0, 0]]
+WeatherLED:
+  Enabled: false
+  TemperatureColors: heat
+`
+	base := getBaseConfig() + palette
+	base = strings.Replace(base, "LedRGB: [255, 0, 0]", "LedRGB: warm", 1)
+	base = strings.Replace(base, "LedHour: [0, 0, 0]", "LedHour: alarm", 1)
+	base = strings.Replace(base, "LedRGB: [[0, 0, 0]]", `LedRGB: [sky, "#000000"]`, 1)
+	conf, err := ReadConfig(createConfigFile(t, base))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, conf.Palette.Colors["warm"], conf.SensorLED.LedRGB)
+	assert.Equal(t, 127.5, conf.SensorLED.LedRGB[0])
+	assert.Equal(t, []float64{255, 0, 0}, conf.ClockLED.LedHour)
+	assert.Equal(t, [][]float64{{0, 40, 80}, {0, 0, 0}}, conf.NightLED.LedRGB)
+	assert.Equal(t, [][]float64{{-10, 0, 40, 80}, {10, 0, 127.5, 0}, {30, 200, 0, 0}}, conf.WeatherLED.TemperatureColors)
+
+	tests := map[string]struct {
+		old, new, expected string
+	}{
+		"unknown colour":   {"LedMinute: [0, 0, 0]", "LedMinute: amber", `unknown colour "amber"`},
+		"unknown gradient": {"TemperatureColors: heat", "TemperatureColors: cold", `unknown gradient "cold"`},
+		"invalid notation": {"kelvin(2700, 0.5)", "kelvin(100)", "temperature must be between"},
+		"short colour":     {"sky: [0, 40, 80]", "sky: [0, 40]", `colour "sky" must be`},
+		"invalid colour":   {"sky: [0, 40, 80]", "sky: [0, 40, 800]", "Colors.sky invalid"},
+		"unsorted":         {"[30, 200, 0, 0]", "[0, 200, 0, 0]", "Gradients.heat invalid"},
+		"point in list":    {"[-10, sky]", "[-10, nothing]", `unknown colour "nothing"`},
+		"unquoted":         {`"hsv(120, 1, 0.5)"`, "hsv(120, 1, 0.5)", "must be quoted"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ReadConfig(createConfigFile(t, strings.Replace(base, tc.old, tc.new, 1)))
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.expected)
+			}
+		})
+	}
+}
+
+func TestReadConfig_AlertLED(t *testing.T) {
+	alert := `
+AlertLED:
+  Enabled: true
+  Delay: 20ms
+  Period: 1s
+  Repeat: 3
+  LedRGB: [255, 0, 0]
+  MaxQueue: 10
+`
+	base := getBaseConfig() + alert
+	conf, err := ReadConfig(createConfigFile(t, base))
+	assert.NoError(t, err)
+	assert.Equal(t, 10, conf.AlertLED.MaxQueue)
+
+	tests := map[string]struct {
+		old, new, expected string
+	}{
+		"no delay":       {"Delay: 20ms\n  Period", "Delay: 0s\n  Period", "Delay must be positive"},
+		"short period":   {"Period: 1s", "Period: 10ms", "must not be shorter than Delay"},
+		"no repeat":      {"Repeat: 3", "Repeat: 0", "Repeat must be positive"},
+		"invalid colour": {"[255, 0, 0]", "[256, 0, 0]", "LedRGB invalid"},
+		"no queue":       {"MaxQueue: 10", "MaxQueue: 0", "MaxQueue must be positive"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ReadConfig(createConfigFile(t, strings.Replace(base, tc.old, tc.new, 1)))
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.expected)
+			}
+		})
+	}
+}
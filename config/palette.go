@@ -0,0 +1,243 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	u "lautenbacher.net/goleds/util"
+)
+
+// PaletteConfig defines named colours and gradients. Every colour in
+// the config, i.e. every field tagged palette:"color", can be given as the name of a colour or a notation of
+// util.ParseColor ("#ff8000", "hsv(30, 1, 1)", "hsl(30, 1, 0.5)",
+// "oklab(0.7, 0.1, 0.1)", "kelvin(2700, 0.5)")
+// instead of [red, green, blue], every gradient as the name of a
+// gradient. The names are resolved when the config is read.
+type PaletteConfig struct {
+	// Colors maps names to [red, green, blue] or a colour notation.
+	Colors map[string][]float64 `yaml:"Colors"`
+	// Gradients maps names to lists of [value, colour] points, the
+	// colour is a name, a notation or red, green, blue.
+	Gradients map[string][][]float64 `yaml:"Gradients"`
+}
+
+func (c *PaletteConfig) Validate() error {
+	for _, name := range slices.Sorted(maps.Keys(c.Colors)) {
+		if err := validateRGB(c.Colors[name]); err != nil {
+			return fmt.Errorf("Colors.%s invalid: %w", name, err)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(c.Gradients)) {
+		if err := validateColorPoints(c.Gradients[name]); err != nil {
+			return fmt.Errorf("Gradients.%s invalid: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// colorResolver replaces the colour and gradient references of a YAML
+// document by their values before it is decoded.
+type colorResolver struct {
+	colors    map[string][3]float64
+	gradients map[string][]*yaml.Node
+}
+
+// resolveColors resolves the Palette section of doc first and then the
+// references in the whole document. The fields of Config tagged
+// palette:"color" tell where a colour ([]float64) or a list of colours
+// or gradient ([][]float64) is expected.
+func resolveColors(doc *yaml.Node) error {
+	r := &colorResolver{colors: map[string][3]float64{}, gradients: map[string][]*yaml.Node{}}
+	root := doc
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+	if palette := mappingValue(root, "Palette"); palette != nil {
+		if colors := mappingValue(palette, "Colors"); colors != nil && colors.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(colors.Content); i += 2 {
+				name, value := colors.Content[i].Value, colors.Content[i+1]
+				if err := r.resolveColor(value); err != nil {
+					return err
+				}
+				var rgb []float64
+				if err := value.Decode(&rgb); err != nil || len(rgb) != 3 {
+					return fmt.Errorf("line %d: colour %q must be [red, green, blue] or a colour notation", value.Line, name)
+				}
+				r.colors[name] = [3]float64(rgb)
+			}
+		}
+		if gradients := mappingValue(palette, "Gradients"); gradients != nil && gradients.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(gradients.Content); i += 2 {
+				name, value := gradients.Content[i].Value, gradients.Content[i+1]
+				if value.Kind != yaml.SequenceNode {
+					return fmt.Errorf("line %d: gradient %q must be a list of [value, colour] points", value.Line, name)
+				}
+				if err := r.resolve(value, reflect.TypeFor[[][]float64](), true); err != nil {
+					return err
+				}
+				r.gradients[name] = value.Content
+			}
+		}
+	}
+	return r.resolve(root, reflect.TypeFor[Config](), false)
+}
+
+// mappingValue returns the value of key in a mapping node, nil if the
+// node isn't a mapping or has no such key.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// isString reports whether node is a string scalar, numbers are not.
+func isString(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.ShortTag() == "!!str"
+}
+
+// resolve walks node, which is decoded into a value of type t. color
+// tells whether the value is a colour field or in one.
+func (r *colorResolver) resolve(node *yaml.Node, t reflect.Type, color bool) error {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case !color:
+	case t == reflect.TypeFor[[]float64]():
+		return r.resolveColor(node)
+	case t == reflect.TypeFor[[][]float64]():
+		if isString(node) {
+			points, ok := r.gradients[node.Value]
+			if !ok {
+				return fmt.Errorf("line %d: unknown gradient %q", node.Line, node.Value)
+			}
+			*node = yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Style: yaml.FlowStyle, Content: points,
+				Line: node.Line, Column: node.Column}
+			return nil
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if field, ok := fields[node.Content[i].Value]; ok {
+				if err := r.resolve(node.Content[i+1], field.Type, field.Tag.Get("palette") == "color"); err != nil {
+					return err
+				}
+			}
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		for i := 1; i < len(node.Content); i += 2 {
+			if err := r.resolve(node.Content[i], t.Elem(), color); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if node.Kind != yaml.SequenceNode {
+			return nil
+		}
+		for _, item := range node.Content {
+			if err := r.resolve(item, t.Elem(), color); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// resolveColor replaces a colour given as a string by [red, green,
+// blue]. In a list of numbers, e.g. a [value, colour] point, strings
+// are replaced by their three components.
+func (r *colorResolver) resolveColor(node *yaml.Node) error {
+	if isString(node) {
+		rgb, err := r.lookup(node.Value)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		*node = yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Style: yaml.FlowStyle, Content: numberNodes(rgb[:]),
+			Line: node.Line, Column: node.Column}
+		return nil
+	}
+	if node.Kind != yaml.SequenceNode {
+		return nil
+	}
+	content := make([]*yaml.Node, 0, len(node.Content))
+	for _, item := range node.Content {
+		if !isString(item) {
+			content = append(content, item)
+			continue
+		}
+		rgb, err := r.lookup(item.Value)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", item.Line, err)
+		}
+		content = append(content, numberNodes(rgb[:])...)
+	}
+	node.Content = content
+	return nil
+}
+
+// lookup returns the palette colour name or parses a colour notation.
+func (r *colorResolver) lookup(name string) ([3]float64, error) {
+	if rgb, ok := r.colors[name]; ok {
+		return rgb, nil
+	}
+	if strings.Contains(name, "(") && !strings.HasSuffix(name, ")") {
+		// The commas of the notation split an unquoted flow sequence.
+		return [3]float64{}, fmt.Errorf("invalid colour %q: notations in [...] lists must be quoted", name)
+	}
+	if strings.HasPrefix(name, "#") || strings.Contains(name, "(") {
+		return u.ParseColor(name)
+	}
+	return [3]float64{}, fmt.Errorf("unknown colour %q", name)
+}
+
+func numberNodes(values []float64) []*yaml.Node {
+	nodes := make([]*yaml.Node, len(values))
+	for i, v := range values {
+		nodes[i] = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: strconv.FormatFloat(v, 'g', -1, 64)}
+	}
+	return nodes
+}
+
+// yamlFields maps the YAML keys of the struct type t to its fields,
+// including the fields of inlined structs.
+func yamlFields(t reflect.Type) map[string]reflect.StructField {
+	fields := map[string]reflect.StructField{}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		switch {
+		case name == "-" || !field.IsExported():
+			continue
+		case slices.Contains(strings.Split(opts, ","), "inline"):
+			maps.Copy(fields, yamlFields(field.Type))
+		case name == "":
+			fields[strings.ToLower(field.Name)] = field
+		default:
+			fields[name] = field
+		}
+	}
+	return fields
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+)
+
+// GaugeRequest is the JSON body of /api/gauge.
+type GaugeRequest struct {
+	Value float64 `json:"Value"`
+}
+
+// gaugeHandler returns (GET) or sets (POST) the value of the gauge.
+func (a *App) gaugeHandler(w http.ResponseWriter, r *http.Request) {
+	gauge := a.gauge.Load()
+	if gauge == nil {
+		http.Error(w, "GaugeLED is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(GaugeRequest{Value: gauge.Value()}); err != nil {
+			slog.Error("Failed to encode gauge value to JSON", "error", err)
+		}
+	case http.MethodPost:
+		var req GaugeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		slog.Info("Setting gauge value", "value", req.Value)
+		gauge.SetValue(req.Value)
+		w.WriteHeader(http.StatusOK)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	c "lautenbacher.net/goleds/config"
+	p "lautenbacher.net/goleds/producer"
+	u "lautenbacher.net/goleds/util"
+)
+
+func TestGaugeHandler(t *testing.T) {
+	app := NewApp(make(chan os.Signal, 1))
+
+	rec := httptest.NewRecorder()
+	app.gaugeHandler(rec, httptest.NewRequest(http.MethodPost, "/api/gauge", bytes.NewBufferString(`{"Value": 1}`)))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d without GaugeLED, got %d", http.StatusServiceUnavailable, rec.Code)
+	}
+
+	cfg := c.GaugeLEDConfig{Enabled: true, DataSourceConfig: c.DataSourceConfig{Source: "API"}, Max: 100, EndLed: 9,
+		Colors: [][]float64{{0, 0, 255, 0}}}
+	app.gauge.Store(p.NewGaugeProducer(GAUGE_LED_UID, u.NewAtomicMapEvent[p.LedProducer](), 10, cfg))
+
+	tests := []struct {
+		body       string
+		wantStatus int
+	}{
+		{`{"Value": "full"}`, http.StatusBadRequest},
+		{`{"Value": 42.5}`, http.StatusOK},
+	}
+	for _, tc := range tests {
+		rec := httptest.NewRecorder()
+		app.gaugeHandler(rec, httptest.NewRequest(http.MethodPost, "/api/gauge", bytes.NewBufferString(tc.body)))
+		if rec.Code != tc.wantStatus {
+			t.Errorf("%s: expected status %d, got %d", tc.body, tc.wantStatus, rec.Code)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	app.gaugeHandler(rec, httptest.NewRequest(http.MethodGet, "/api/gauge", nil))
+	var resp GaugeRequest
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode gauge value: %v", err)
+	}
+	if resp.Value != 42.5 {
+		t.Errorf("Expected value 42.5, got %v", resp.Value)
+	}
+
+	rec = httptest.NewRecorder()
+	app.gaugeHandler(rec, httptest.NewRequest(http.MethodDelete, "/api/gauge", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d for DELETE, got %d", http.StatusMethodNotAllowed, rec.Code)
+	}
+}
@@ -35,6 +35,7 @@ import (
 	"os/signal"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
@@ -42,16 +43,27 @@ import (
 	l "lautenbacher.net/goleds/logging"
 	pl "lautenbacher.net/goleds/platform"
 	p "lautenbacher.net/goleds/producer"
+	"lautenbacher.net/goleds/schedule"
 	u "lautenbacher.net/goleds/util"
 )
 
 // UIDs for the different types of producers
 const (
-	NIGHT_LED_UID  = "__night_producer"
-	CLOCK_UID      = "__clock_producer"
-	AUDIO_LED_UID  = "__audio_producer"
-	MULTI_BLOB_UID = "__multiblob_producer"
-	CYLON_LED_UID  = "__cylon_producer"
+	NIGHT_LED_UID   = "__night_producer"
+	CLOCK_UID       = "__clock_producer"
+	AUDIO_LED_UID   = "__audio_producer"
+	MULTI_BLOB_UID  = "__multiblob_producer"
+	CYLON_LED_UID   = "__cylon_producer"
+	WEATHER_LED_UID = "__weather_producer"
+	ALERT_LED_UID   = "__alert_producer"
+	GAUGE_LED_UID   = "__gauge_producer"
+	FIRE_LED_UID    = "__fire_producer"
+	CANDLE_LED_UID  = "__candle_producer"
+	TWINKLE_LED_UID = "__twinkle_producer"
+	NOISE_LED_UID   = "__noise_producer"
+	BREATH_LED_UID  = "__breathing_producer"
+	IMAGE_LED_UID   = "__image_producer"
+	MESSAGE_LED_UID = "__message_producer"
 )
 
 // App holds the global state of the application
@@ -66,14 +78,45 @@ type App struct {
 	platform     pl.Platform
 	sensorProdWg sync.WaitGroup
 	afterProdWg  sync.WaitGroup
+	recorder     *u.TriggerRecorder
+	replay       []u.TriggerRecord
+	replaySpeed  float64
+	clock        u.Clock
+	// sections maps producer UIDs to their config section names.
+	sections       map[string]string
+	schedule       *schedule.Schedule
+	scheduleStates map[string]schedule.State
+	// brightness is the master brightness factor, it is kept across
+	// config reloads.
+	brightness *u.AtomicEvent[float64]
+	// audio is the shared audio input of the AudioLED producer and the
+	// producers following its beats.
+	audio *p.AudioCapture
+	// alerts is the overlay of /api/alert, it is nil if AlertLED is
+	// disabled. The web handler reads it concurrently to a reload.
+	alerts atomic.Pointer[p.AlertProducer]
+	// gauge receives the values of /api/gauge, it is nil if GaugeLED is
+	// disabled.
+	gauge atomic.Pointer[p.GaugeProducer]
+	// message receives the messages of /api/message, it is nil if
+	// MessageLED is disabled.
+	message atomic.Pointer[p.MessageProducer]
+	// zones are the Apps of the zones sharing the platform, they
+	// replace the producers of this App.
+	zones []*App
 }
 
 var startWeb sync.Once
 
 // NewApp creates a new App instance
 func NewApp(ossignal chan os.Signal) *App {
+	brightness := u.NewAtomicEvent[float64]()
+	brightness.Send(1)
+	<-brightness.Channel() // The initial value needs no display update.
 	return &App{
-		ossignal: ossignal,
+		ossignal:   ossignal,
+		clock:      u.RealClock{},
+		brightness: brightness,
 	}
 }
 
@@ -88,12 +131,25 @@ func main() {
 	}
 	ossignal := make(chan os.Signal, 1)
 	exPath := filepath.Dir(ex)
+
+	if len(os.Args) > 1 && os.Args[1] == "render" {
+		if err := runRender(os.Args[2:], exPath+"/"+c.CONFILE); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	cfile := flag.String("config", exPath+"/"+c.CONFILE, "Config file to use")
 	realp := flag.Bool("real", false, "Set to true if program runs on real hardware")
 	sensp := flag.Bool("show-sensors", false,
 		"Set to true if program should only display sensor values.\n"+
 			"* will be using live data from the sensor hardware if -real is given - useful for calibrating the sensors' trigger values\n"+
 			"* will be using random values if -real is not given - useful only for development of the viewer component itself")
+	recordp := flag.String("record", "", "Record all sensor events to the given file (not with -show-sensors without -real)")
+	replayp := flag.String("replay", "", "Replay the sensor events recorded in the given file (once, not again after a config reload;\n"+
+		"not with -show-sensors without -real)")
+	speedp := flag.Float64("replay-speed", 1.0, "Speed factor for -replay: 1 is real time, values <= 0 replay without delays")
 	flag.Parse()
 
 	if err := l.InitialSetup(); err != nil {
@@ -102,7 +158,42 @@ func main() {
 		os.Exit(1)
 	}
 
+	if *sensp && !*realp && (*recordp != "" || *replayp != "") {
+		// The sensor viewer development mode uses random values and
+		// no platform, so there are no sensor events to record or replay.
+		l.Close()
+		fmt.Fprintln(os.Stderr, "Error: -record and -replay cannot be used with -show-sensors without -real")
+		os.Exit(1)
+	}
+
 	app := NewApp(ossignal)
+	if *recordp != "" {
+		recfile, err := os.Create(*recordp)
+		if err != nil {
+			l.Close()
+			fmt.Fprintf(os.Stderr, "Error: Failed to create recording file: %v\n", err)
+			os.Exit(1)
+		}
+		// Records are written unbuffered, so the file needs no explicit
+		// close on exit.
+		app.recorder = u.NewTriggerRecorder(recfile, time.Now())
+	}
+	if *replayp != "" {
+		replayfile, err := os.Open(*replayp)
+		if err != nil {
+			l.Close()
+			fmt.Fprintf(os.Stderr, "Error: Failed to open replay file: %v\n", err)
+			os.Exit(1)
+		}
+		app.replay, err = u.ReadTriggerLog(replayfile)
+		replayfile.Close()
+		if err != nil {
+			l.Close()
+			fmt.Fprintf(os.Stderr, "Error: Failed to read replay file: %v\n", err)
+			os.Exit(1)
+		}
+		app.replaySpeed = *speedp
+	}
 	if err := app.initialise(*cfile, *realp, *sensp); err != nil {
 		l.Close()
 		fmt.Fprintf(os.Stderr, "Error: Failed to initialize application: %v\n", err)
@@ -194,10 +285,7 @@ func watchConfigFile(cfile string, reloadEvent *u.AtomicEvent[bool]) {
 func (a *App) initialise(cfile string, realp bool, sensp bool) error {
 	slog.Info("Initializing...")
 
-	a.afterProd = make([]p.LedProducer, 0)
-	a.permProd = make([]p.LedProducer, 0)
-	a.stopsignal = make(chan struct{})
-	a.ledproducers = make(map[string]p.LedProducer)
+	a.reset()
 
 	conf, err := c.ReadConfig(cfile)
 	if err != nil {
@@ -233,15 +321,78 @@ func (a *App) initialise(cfile string, realp bool, sensp bool) error {
 	// Standard platform setup
 	if realp {
 		rpiPlatform := pl.NewRaspberryPiPlatform(conf)
+		rpiPlatform.SetClock(a.clock)
 		if sensp {
 			viewer := pl.NewSensorViewer(conf.Hardware.Sensors, a.ossignal, false)
 			rpiPlatform.SetSensorViewer(viewer)
 		}
 		a.platform = rpiPlatform
 	} else {
-		a.platform = pl.NewTUIPlatform(conf, a.ossignal)
+		tuiPlatform := pl.NewTUIPlatform(conf, a.ossignal)
+		tuiPlatform.SetClock(a.clock)
+		a.platform = tuiPlatform
+	}
+
+	if a.recorder != nil {
+		a.platform = pl.NewRecordingPlatform(a.platform, a.recorder)
+	}
+	var replayPlatform *pl.ReplayPlatform
+	if a.replay != nil {
+		replayPlatform = pl.NewReplayPlatform(a.platform, a.replay, a.replaySpeed)
+		replayPlatform.SetClock(a.clock)
+		a.platform = replayPlatform
+		// A recording is replayed only once and not again after a config reload.
+		a.replay = nil
+	}
+
+	if err := a.start(conf); err != nil {
+		return err
 	}
 
+	if replayPlatform != nil {
+		// Only now the recorded events can be consumed in time.
+		replayPlatform.Play()
+	}
+
+	// Start the web server in a separate goroutine - only once.
+	startWeb.Do(func() {
+		http.Handle("/", http.FileServer(http.Dir("./web")))
+		http.HandleFunc("/api/config", c.ConfigHandler(cfile))
+		http.HandleFunc("/api/brightness", a.brightnessHandler)
+		http.HandleFunc("/api/alert", a.alertHandler)
+		http.HandleFunc("/api/gauge", a.gaugeHandler)
+		http.HandleFunc("/api/message", a.messageHandler)
+		go func() {
+			slog.Info("Starting web server", "address", "http://localhost:8080")
+			if err := http.ListenAndServe(fmt.Sprintf(":%d", conf.Hardware.WebserverPort), nil); err != nil {
+				slog.Error("Web server failed", "error", err)
+			}
+		}()
+	})
+
+	return nil
+}
+
+// reset clears the producers and signals of a previous run.
+func (a *App) reset() {
+	a.afterProd = make([]p.LedProducer, 0)
+	a.permProd = make([]p.LedProducer, 0)
+	a.stopsignal = make(chan struct{})
+	a.ledproducers = make(map[string]p.LedProducer)
+	a.sections = make(map[string]string)
+	a.schedule = nil
+	a.scheduleStates = nil
+	a.audio = nil
+	a.alerts.Store(nil)
+	a.gauge.Store(nil)
+	a.message.Store(nil)
+	a.zones = nil
+}
+
+// start starts a.platform, creates the producers enabled in conf and
+// launches the go-routines that drive the display. It is shared by
+// the normal run and the render subcommand.
+func (a *App) start(conf *c.Config) error {
 	ledsTotal := a.platform.GetLedsTotal()
 	ledBufferPool := &sync.Pool{
 		New: func() any {
@@ -259,30 +410,101 @@ func (a *App) initialise(cfile string, realp bool, sensp bool) error {
 	<-a.platform.Ready()
 	slog.Info("Platform is ready, starting producers...")
 
-	// These producers runs all the time and will be started right away here
+	if len(conf.Zones) > 0 {
+		return a.startZones(conf, ledBufferPool)
+	}
+
+	// These producers run all the time, they are started below.
 	if conf.NightLED.Enabled {
 		cfg := conf.NightLED
 		prodnight := p.NewNightlightProducer(NIGHT_LED_UID, ledReader,
-			ledsTotal, cfg.Latitude, cfg.Longitude, cfg.LedRGB)
+			ledsTotal, cfg.Latitude, cfg.Longitude, cfg.LedRGB, cfg.Stops)
 		a.ledproducers[NIGHT_LED_UID] = prodnight
+		a.sections[NIGHT_LED_UID] = "NightLED"
 		a.permProd = append(a.permProd, prodnight)
-		prodnight.Start()
+		prodnight.SetClock(a.clock)
 	}
 
 	if conf.ClockLED.Enabled {
 		cfg := conf.ClockLED
 		prodclock := p.NewClockProducer(CLOCK_UID, ledReader, ledsTotal, cfg)
 		a.ledproducers[CLOCK_UID] = prodclock
+		a.sections[CLOCK_UID] = "ClockLED"
 		a.permProd = append(a.permProd, prodclock)
-		prodclock.Start()
+		prodclock.SetClock(a.clock)
+	}
+
+	if conf.AudioLED.Enabled || conf.AudioLED.Beat.Enabled {
+		a.audio = p.NewAudioCapture(conf.AudioLED)
+		a.audio.SetClock(a.clock)
 	}
 
 	if conf.AudioLED.Enabled {
 		cfg := conf.AudioLED
-		prodaudio := p.NewAudioLEDProducer(AUDIO_LED_UID, ledReader, ledsTotal, cfg)
+		prodaudio := p.NewAudioLEDProducer(AUDIO_LED_UID, ledReader, ledsTotal, cfg, a.audio)
 		a.ledproducers[AUDIO_LED_UID] = prodaudio
+		a.sections[AUDIO_LED_UID] = "AudioLED"
 		a.permProd = append(a.permProd, prodaudio)
-		prodaudio.Start()
+		prodaudio.SetClock(a.clock)
+	}
+
+	if conf.WeatherLED.Enabled {
+		prodweather := p.NewWeatherProducer(WEATHER_LED_UID, ledReader, ledsTotal, conf.WeatherLED)
+		a.ledproducers[WEATHER_LED_UID] = prodweather
+		a.sections[WEATHER_LED_UID] = "WeatherLED"
+		a.permProd = append(a.permProd, prodweather)
+		prodweather.SetClock(a.clock)
+	}
+
+	if conf.GaugeLED.Enabled {
+		prodgauge := p.NewGaugeProducer(GAUGE_LED_UID, ledReader, ledsTotal, conf.GaugeLED)
+		a.ledproducers[GAUGE_LED_UID] = prodgauge
+		a.sections[GAUGE_LED_UID] = "GaugeLED"
+		a.permProd = append(a.permProd, prodgauge)
+		prodgauge.SetClock(a.clock)
+		a.gauge.Store(prodgauge)
+	}
+
+	if conf.MessageLED.Enabled {
+		cfg := conf.MessageLED
+		prodmessage := p.NewMessageProducer(MESSAGE_LED_UID, ledReader, ledsTotal, cfg,
+			conf.Hardware.Display.LedSegments[cfg.MatrixGroup])
+		a.ledproducers[MESSAGE_LED_UID] = prodmessage
+		a.sections[MESSAGE_LED_UID] = "MessageLED"
+		a.permProd = append(a.permProd, prodmessage)
+		prodmessage.SetClock(a.clock)
+		a.message.Store(prodmessage)
+	}
+
+	// The ambient producers run permanently or, with AfterSensor, like
+	// the producers below.
+	if conf.FireLED.Enabled {
+		cfg := conf.FireLED
+		a.addAmbient(p.NewFireProducer(FIRE_LED_UID, ledReader, ledsTotal, cfg, a.ambientWg(cfg.AmbientConfig)), "FireLED", cfg.AmbientConfig)
+	}
+	if conf.CandleLED.Enabled {
+		cfg := conf.CandleLED
+		a.addAmbient(p.NewCandleProducer(CANDLE_LED_UID, ledReader, ledsTotal, cfg, a.ambientWg(cfg.AmbientConfig)), "CandleLED", cfg.AmbientConfig)
+	}
+	if conf.TwinkleLED.Enabled {
+		cfg := conf.TwinkleLED
+		a.addAmbient(p.NewTwinkleProducer(TWINKLE_LED_UID, ledReader, ledsTotal, cfg, a.ambientWg(cfg.AmbientConfig)), "TwinkleLED", cfg.AmbientConfig)
+	}
+	if conf.NoiseLED.Enabled {
+		cfg := conf.NoiseLED
+		a.addAmbient(p.NewNoiseProducer(NOISE_LED_UID, ledReader, ledsTotal, cfg, a.ambientWg(cfg.AmbientConfig)), "NoiseLED", cfg.AmbientConfig)
+	}
+	if conf.BreathingLED.Enabled {
+		cfg := conf.BreathingLED
+		a.addAmbient(p.NewBreathingProducer(BREATH_LED_UID, ledReader, ledsTotal, cfg, a.ambientWg(cfg.AmbientConfig)), "BreathingLED", cfg.AmbientConfig)
+	}
+	if conf.ImageLED.Enabled {
+		cfg := conf.ImageLED
+		prodimage, err := p.NewImageProducer(IMAGE_LED_UID, ledReader, ledsTotal, cfg, a.ambientWg(cfg.AmbientConfig))
+		if err != nil {
+			return fmt.Errorf("failed to load ImageLED: %w", err)
+		}
+		a.addAmbient(prodimage, "ImageLED", cfg.AmbientConfig)
 	}
 
 	// These producers will be started and stopped on demand depending
@@ -292,7 +514,13 @@ func (a *App) initialise(cfile string, realp bool, sensp bool) error {
 		prodmulti := p.NewMultiBlobProducer(MULTI_BLOB_UID, ledReader,
 			ledsTotal, cfg.Duration, cfg.Delay, cfg.BlobCfg, &a.afterProdWg)
 		a.ledproducers[MULTI_BLOB_UID] = prodmulti
+		a.sections[MULTI_BLOB_UID] = "MultiBlobLED"
 		a.afterProd = append(a.afterProd, prodmulti)
+		prodmulti.SetClock(a.clock)
+		prodmulti.SetPhysics(cfg.Physics)
+		if cfg.BeatColors {
+			prodmulti.SetBeatSource(a.audio)
+		}
 	}
 
 	if conf.CylonLED.Enabled {
@@ -300,7 +528,16 @@ func (a *App) initialise(cfile string, realp bool, sensp bool) error {
 		prodcylon := p.NewCylonProducer(CYLON_LED_UID, ledReader, ledsTotal,
 			cfg.Duration, cfg.Delay, cfg.Step, cfg.Width, cfg.LedRGB, &a.afterProdWg)
 		a.ledproducers[CYLON_LED_UID] = prodcylon
+		a.sections[CYLON_LED_UID] = "CylonLED"
 		a.afterProd = append(a.afterProd, prodcylon)
+		prodcylon.SetClock(a.clock)
+		prodcylon.SetEyes(cfg.Eyes)
+		prodcylon.SetHueShift(cfg.HueShift)
+		prodcylon.SetTrail(cfg.Trail)
+		prodcylon.SetEasing(cfg.Easing)
+		if cfg.BeatSync {
+			prodcylon.SetBeatSource(a.audio)
+		}
 	}
 
 	// This producer reacts on sensor triggers to light the strips.
@@ -310,45 +547,129 @@ func (a *App) initialise(cfile string, realp bool, sensp bool) error {
 		for uid, ledIndex := range a.platform.GetSensorLedIndices() {
 			producer := p.NewSensorLedProducer(uid, ledIndex, ledReader,
 				ledsTotal, cfg, &a.sensorProdWg)
+			producer.SetPositions(a.platform.GetPositions(), conf.Hardware.Sensors.SensorCfg[uid].Position)
 			a.ledproducers[uid] = producer
+			a.sections[uid] = "SensorLED"
 			a.sensorProd = append(a.sensorProd, producer)
+			producer.SetClock(a.clock)
 		}
 	}
 
 	// *FUTURE* initialize more types of ledproducers if needed/wanted
 
+	// The schedule is applied before the permanent producers are
+	// started, so producers disabled right now don't flash up.
+	if conf.Schedule.Enabled {
+		sched, err := schedule.New(conf.Schedule)
+		if err != nil {
+			return fmt.Errorf("failed to set up schedule: %w", err)
+		}
+		a.schedule = sched
+		a.updateSchedule()
+	}
+	for _, prod := range a.permProd {
+		if a.scheduledOn(prod) {
+			prod.Start()
+		}
+	}
+
+	// The alert overlay runs independently of the state manager and
+	// the schedule.
+	if conf.AlertLED.Enabled {
+		prodalert := p.NewAlertProducer(ALERT_LED_UID, ledReader, ledsTotal, conf.AlertLED)
+		prodalert.SetClock(a.clock)
+		prodalert.Start()
+		a.alerts.Store(prodalert)
+	}
+
 	a.shutdownWg.Add(2)
 
 	go a.combineAndUpdateDisplay(ledReader, ledBufferPool)
 	go a.stateManager()
 
-	// Start the web server in a separate goroutine - only once.
-	startWeb.Do(func() {
-		http.Handle("/", http.FileServer(http.Dir("./web")))
-		http.HandleFunc("/api/config", c.ConfigHandler(cfile))
-		go func() {
-			slog.Info("Starting web server", "address", "http://localhost:8080")
-			if err := http.ListenAndServe(fmt.Sprintf(":%d", conf.Hardware.WebserverPort), nil); err != nil {
-				slog.Error("Web server failed", "error", err)
-			}
-		}()
-	})
+	return nil
+}
 
+// ambientWg returns the wait group of the after producers for an
+// ambient producer running after SensorLED, nil otherwise.
+func (a *App) ambientWg(cfg c.AmbientConfig) *sync.WaitGroup {
+	if cfg.AfterSensor {
+		return &a.afterProdWg
+	}
 	return nil
 }
 
+// addAmbient registers an ambient producer of the config section as a
+// permanent or, with AfterSensor, as an after producer, and gives it
+// the matrix layout and the LED positions of the platform.
+func (a *App) addAmbient(prod *p.AmbientProducer, section string, cfg c.AmbientConfig) {
+	prod.SetLayout(a.platform.GetLayout())
+	prod.SetPositions(a.platform.GetPositions())
+	a.ledproducers[prod.GetUID()] = prod
+	a.sections[prod.GetUID()] = section
+	if cfg.AfterSensor {
+		a.afterProd = append(a.afterProd, prod)
+	} else {
+		a.permProd = append(a.permProd, prod)
+	}
+	prod.SetClock(a.clock)
+}
+
+// updateSchedule evaluates the schedule and applies the scheduled
+// brightness and colour to the producers. It returns the previous
+// states.
+func (a *App) updateSchedule() map[string]schedule.State {
+	old := a.scheduleStates
+	a.scheduleStates = a.schedule.Evaluate(a.clock.Now())
+	for uid, prod := range a.ledproducers {
+		state := a.scheduleStates[a.sections[uid]]
+		if dimmable, ok := prod.(p.Dimmable); ok {
+			dimmable.SetBrightness(state.Brightness)
+		}
+		if recolorable, ok := prod.(p.Recolorable); ok {
+			recolorable.SetColor(state.LedRGB)
+		}
+		if messageable, ok := prod.(p.Messageable); ok {
+			messageable.SetText(state.Message)
+		}
+	}
+	return old
+}
+
+// untilNextMinute returns the time from now to the next full minute.
+func untilNextMinute(now time.Time) time.Duration {
+	return now.Truncate(time.Minute).Add(time.Minute).Sub(now)
+}
+
+// scheduledOn reports whether prod is not disabled by the schedule.
+func (a *App) scheduledOn(prod p.LedProducer) bool {
+	state, ok := a.scheduleStates[a.sections[prod.GetUID()]]
+	return !ok || !state.Disabled
+}
+
 func (a *App) shutdown() {
 	slog.Info("Shutting down...")
+	for _, zone := range a.zones {
+		zone.shutdown()
+	}
 	for _, prod := range a.ledproducers {
 		slog.Info("Exiting producer", "uid", prod.GetUID())
 		prod.Exit()
 	}
+	// With zones, the alert overlay belongs to a zone.
+	if alerts := a.alerts.Load(); alerts != nil && a.zones == nil {
+		alerts.Exit()
+	}
 
 	slog.Info("Stopping running go-routines...")
 	close(a.stopsignal)
 	a.shutdownWg.Wait()
 	slog.Info("Main go-routines from goleds.go successfully terminated")
 
+	if a.audio != nil {
+		a.audio.Close()
+	}
+
 	if a.platform != nil {
 		slog.Info("Stopping main platform...", "class", fmt.Sprintf("%T", a.platform))
 		a.platform.Stop()
@@ -365,10 +686,13 @@ func (a *App) combineAndUpdateDisplay(ledreader *u.AtomicMapEvent[p.LedProducer]
 	forceupdatedelay := a.platform.GetForceUpdateDelay()
 	ledsTotal := a.platform.GetLedsTotal()
 	allLedRanges := make(map[string][]p.Led)
-	var ticker *time.Ticker
+	// A nil channel blocks forever, so without a force update delay
+	// the corresponding select case is simply never chosen.
+	var forceupdate <-chan time.Time
 	if forceupdatedelay > 0 {
-		ticker = time.NewTicker(forceupdatedelay)
+		ticker := a.clock.NewTicker(forceupdatedelay)
 		defer ticker.Stop()
+		forceupdate = ticker.C()
 	}
 
 	for {
@@ -384,7 +708,7 @@ func (a *App) combineAndUpdateDisplay(ledreader *u.AtomicMapEvent[p.LedProducer]
 				prod.GetLeds(allLedRanges[key])
 			}
 			ledsToSend := ledBufferPool.Get().([]p.Led)
-			p.CombineLeds(allLedRanges, ledsToSend)
+			a.combineLeds(allLedRanges, ledsToSend)
 			newLedshash := hashLEDs(ledsToSend)
 			if newLedshash != oldLedsHash {
 				a.platform.SetLeds(ledsToSend)
@@ -393,13 +717,18 @@ func (a *App) combineAndUpdateDisplay(ledreader *u.AtomicMapEvent[p.LedProducer]
 				ledBufferPool.Put(ledsToSend)
 			}
 			oldLedsHash = newLedshash
-		case <-ticker.C:
+		case <-forceupdate:
 			// We do this purely because there occasionally are
 			// artifacts on the led stripe from - maybe/somehow -
 			// electrical distortions or cross talk so we make sure to
 			// regularly force an update of the Led stripe
 			ledsToSend := ledBufferPool.Get().([]p.Led)
-			p.CombineLeds(allLedRanges, ledsToSend)
+			a.combineLeds(allLedRanges, ledsToSend)
+			a.platform.SetLeds(ledsToSend)
+		case <-a.brightness.Channel():
+			ledsToSend := ledBufferPool.Get().([]p.Led)
+			a.combineLeds(allLedRanges, ledsToSend)
+			oldLedsHash = hashLEDs(ledsToSend)
 			a.platform.SetLeds(ledsToSend)
 		case <-a.stopsignal:
 			slog.Info("Ending combineAndupdateDisplay go-routine")
@@ -408,6 +737,18 @@ func (a *App) combineAndUpdateDisplay(ledreader *u.AtomicMapEvent[p.LedProducer]
 	}
 }
 
+// combineLeds combines the LEDs of all producers into target, draws
+// the alert overlay above them and applies the master brightness.
+func (a *App) combineLeds(allLedRanges map[string][]p.Led, target []p.Led) {
+	p.CombineLeds(allLedRanges, target)
+	if overlay, ok := allLedRanges[ALERT_LED_UID]; ok {
+		p.OverlayLeds(overlay, target)
+	}
+	if brightness := a.brightness.Value(); brightness != 1 {
+		p.ScaleLeds(target, brightness)
+	}
+}
+
 // This go routine distributes sensor events and handles the
 // transition of the states the LED strip can be in.
 func (a *App) stateManager() {
@@ -429,10 +770,23 @@ func (a *App) stateManager() {
 	afterProdDoneChan := make(chan struct{})
 
 	var sensorRun uint64 // The generation counter for sensor producer runs.
+	// The LED of the sensor triggered last, the after producers may
+	// start there.
+	lastTriggerLed := -1
 
 	// We are in idle State when starting
 	currentState := stateIdle
 
+	// The schedule is evaluated at every full minute, so the rules apply
+	// on time.
+	var scheduleTimer u.Timer
+	var scheduleTick <-chan time.Time
+	if a.schedule != nil {
+		scheduleTimer = a.clock.NewTimer(untilNextMinute(a.clock.Now()))
+		defer scheduleTimer.Stop()
+		scheduleTick = scheduleTimer.C()
+	}
+
 	// sensorWaiter waits for all sensor producers to finish and then sends the
 	// given run number on the done channel, which it captures from the parent scope.
 	sensorWaiter := func(run uint64) {
@@ -451,6 +805,13 @@ func (a *App) stateManager() {
 				slog.Warn("Received sensor event for unknown producer", "uid", event.ID)
 				continue
 			}
+			if !a.scheduledOn(producer) {
+				slog.Info("Ignoring sensor event, SensorLED is disabled by the schedule", "uid", event.ID)
+				continue
+			}
+			if index, ok := a.platform.GetSensorLedIndices()[event.ID]; ok {
+				lastTriggerLed = index
+			}
 
 			switch currentState {
 			case stateIdle:
@@ -512,6 +873,12 @@ func (a *App) stateManager() {
 			slog.Info("      Received valid [SensorLedProducer(s) finished] event, switching to afterProd state", "run", recvdRun)
 			currentState = stateAfterProd
 			for _, prod := range a.afterProd {
+				if !a.scheduledOn(prod) {
+					continue
+				}
+				if spawnable, ok := prod.(p.Spawnable); ok && lastTriggerLed >= 0 {
+					spawnable.SetSpawnLed(lastTriggerLed)
+				}
 				slog.Info("      ===> Starting afterProd Producer", "uid", prod.GetUID())
 				prod.Start()
 			}
@@ -526,6 +893,9 @@ func (a *App) stateManager() {
 				slog.Info("      Received [AfterProdProducer(s) finished] event, switching to idle state")
 				currentState = stateIdle
 				for _, prod := range a.permProd {
+					if !a.scheduledOn(prod) {
+						continue
+					}
 					slog.Info("===> Starting permProd Producer", "uid", prod.GetUID())
 					prod.Start()
 				}
@@ -533,6 +903,20 @@ func (a *App) stateManager() {
 				slog.Info("      Received [AfterProdProducer(s) finished] event, but not in afterProd state, ignoring")
 			}
 
+		case <-scheduleTick:
+			scheduleTimer.Reset(untilNextMinute(a.clock.Now()))
+			old := a.updateSchedule()
+			for _, prod := range a.permProd {
+				wasOn, isOn := !old[a.sections[prod.GetUID()]].Disabled, a.scheduledOn(prod)
+				if wasOn && !isOn {
+					slog.Info("<=== Stopping permProd Producer, disabled by schedule", "uid", prod.GetUID())
+					prod.TryStop()
+				} else if !wasOn && isOn && currentState == stateIdle {
+					slog.Info("===> Starting permProd Producer, enabled by schedule", "uid", prod.GetUID())
+					prod.Start()
+				}
+			}
+
 		case <-a.stopsignal:
 			slog.Info("Ending stateManager go-routine")
 			return
@@ -9,6 +9,7 @@ import (
 	c "lautenbacher.net/goleds/config"
 	pl "lautenbacher.net/goleds/platform"
 	p "lautenbacher.net/goleds/producer"
+	"lautenbacher.net/goleds/schedule"
 	u "lautenbacher.net/goleds/util"
 )
 
@@ -105,8 +106,9 @@ type MockLedProducer struct {
 
 func NewMockLedProducer(uid string, wg *sync.WaitGroup) *MockLedProducer {
 	return &MockLedProducer{
-		uid: uid,
-		wg:  wg,
+		AbstractProducer: p.NewAbstractProducer(uid, u.NewAtomicMapEvent[p.LedProducer](), nil, 10),
+		uid:              uid,
+		wg:               wg,
 	}
 }
 
@@ -234,6 +236,70 @@ func TestStateManager(t *testing.T) {
 	}
 }
 
+// TestStateManager_Schedule checks that the state manager stops
+// permanent producers and ignores sensor events once the schedule
+// disables them, without a config reload.
+func TestStateManager_Schedule(t *testing.T) {
+	ossignal := make(chan os.Signal, 1)
+	app := NewApp(ossignal)
+	app.reset()
+	clock := u.NewFakeClock(time.Date(2024, 6, 21, 22, 59, 30, 0, time.UTC))
+	app.clock = clock
+
+	mockPlatform := NewMockPlatform()
+	app.platform = mockPlatform
+	mockPlatform.sensors["sensor1"] = c.SensorCfg{LedIndex: 0}
+
+	permProd := NewMockLedProducer("perm", nil)
+	sensorProd := NewMockLedProducer("sensor1", &app.sensorProdWg)
+	app.permProd = []p.LedProducer{permProd}
+	app.sensorProd = []p.LedProducer{sensorProd}
+	app.ledproducers["perm"] = permProd
+	app.ledproducers["sensor1"] = sensorProd
+	app.sections["perm"] = "AudioLED"
+	app.sections["sensor1"] = "SensorLED"
+
+	sched, err := schedule.New(c.ScheduleConfig{
+		Enabled: true,
+		Rules: []c.ScheduleRule{
+			{Producers: []string{"AudioLED", "SensorLED"}, From: "23:00", To: "06:00", Disable: true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create schedule: %v", err)
+	}
+	app.schedule = sched
+	app.updateSchedule()
+	if !app.scheduledOn(permProd) {
+		t.Fatal("Expected permProd to be enabled before 23:00")
+	}
+	permProd.Start()
+
+	app.shutdownWg.Add(1)
+	go app.stateManager()
+	t.Cleanup(func() {
+		close(app.stopsignal)
+		app.shutdownWg.Wait()
+	})
+
+	// The rule applies at 23:00 sharp, not a minute after the start.
+	clock.BlockUntil(1)
+	clock.Advance(30 * time.Second)
+	deadline := time.Now().Add(time.Second)
+	for _, stop, _ := permProd.getCalls(); stop == 0; _, stop, _ = permProd.getCalls() {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected permProd to be stopped by the schedule")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	mockPlatform.sensorEvents <- u.NewTrigger("sensor1", 100, clock.Now())
+	time.Sleep(25 * time.Millisecond)
+	if _, _, trigger := sensorProd.getCalls(); trigger != 0 {
+		t.Errorf("Expected the sensor event to be ignored, got %d triggers", trigger)
+	}
+}
+
 func TestCombineAndUpdateDisplay(t *testing.T) {
 	ossignal := make(chan os.Signal, 1)
 	app := NewApp(ossignal)
@@ -282,3 +348,107 @@ func TestCombineAndUpdateDisplay(t *testing.T) {
 		t.Error("Expected leds to be written")
 	}
 }
+
+// litCount returns the number of LEDs that are not dark in a frame.
+func litCount(frame []p.Led) int {
+	count := 0
+	for _, led := range frame {
+		if !led.IsEmpty() {
+			count++
+		}
+	}
+	return count
+}
+
+// TestReplayFrameSequence feeds a recorded sensor event through the
+// state manager into a real SensorLedProducer and checks the
+// resulting sequence of frames: a run-up from the sensor position
+// until the whole strip is lit, followed by a run-down until it is
+// dark again.
+func TestReplayFrameSequence(t *testing.T) {
+	ossignal := make(chan os.Signal, 1)
+	app := NewApp(ossignal)
+	app.ledproducers = make(map[string]p.LedProducer)
+	app.stopsignal = make(chan struct{})
+
+	mockPlatform := NewMockPlatform()
+	records := []u.TriggerRecord{{Offset: 10 * time.Millisecond, ID: "S0", Value: 300}}
+	replay := pl.NewReplayPlatform(mockPlatform, records, 1)
+	capture := pl.NewCapturePlatform(replay)
+	app.platform = capture
+
+	ledsTotal := replay.GetLedsTotal()
+	ledReader := u.NewAtomicMapEvent[p.LedProducer]()
+	cfg := c.SensorLEDConfig{
+		RunUpDelay:   2 * time.Millisecond,
+		RunDownDelay: 2 * time.Millisecond,
+		HoldTime:     30 * time.Millisecond,
+		LedRGB:       []float64{10, 10, 10},
+		LatchLedRGB:  []float64{0, 0, 0},
+	}
+	sensorProd := p.NewSensorLedProducer("S0", 4, ledReader, ledsTotal, cfg, &app.sensorProdWg)
+	app.ledproducers["S0"] = sensorProd
+	app.sensorProd = []p.LedProducer{sensorProd}
+
+	ledBufferPool := &sync.Pool{
+		New: func() any {
+			return make([]p.Led, ledsTotal)
+		},
+	}
+	if err := capture.Start(ledBufferPool); err != nil {
+		t.Fatalf("Failed to start replay platform: %v", err)
+	}
+
+	app.shutdownWg.Add(2)
+	go app.combineAndUpdateDisplay(ledReader, ledBufferPool)
+	go app.stateManager()
+	t.Cleanup(func() {
+		sensorProd.Exit()
+		close(app.stopsignal)
+		app.shutdownWg.Wait()
+		capture.Stop()
+	})
+	replay.Play()
+
+	<-replay.Done()
+	deadline := time.Now().Add(2 * time.Second)
+	var frames [][]p.Led
+	for time.Now().Before(deadline) {
+		frames = frames[:0]
+		for _, frame := range capture.Frames() {
+			frames = append(frames, frame.Leds)
+		}
+		if len(frames) > 1 && litCount(frames[len(frames)-1]) == 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if len(frames) < 3 {
+		t.Fatalf("Expected a sequence of frames, got %d", len(frames))
+	}
+	if frames[0][4].IsEmpty() {
+		t.Errorf("Expected the first frame to light the sensor position, got %+v", frames[0])
+	}
+	if litCount(frames[len(frames)-1]) != 0 {
+		t.Errorf("Expected the strip to be dark at the end, got %+v", frames[len(frames)-1])
+	}
+
+	// The number of lit LEDs must grow to the full strip and then shrink again.
+	peak := false
+	for i := 1; i < len(frames); i++ {
+		prev, curr := litCount(frames[i-1]), litCount(frames[i])
+		if !peak && curr < prev {
+			t.Errorf("Frame %d: lit LEDs decreased during run-up (%d -> %d)", i, prev, curr)
+		}
+		if peak && curr > prev {
+			t.Errorf("Frame %d: lit LEDs increased during run-down (%d -> %d)", i, prev, curr)
+		}
+		if curr == ledsTotal {
+			peak = true
+		}
+	}
+	if !peak {
+		t.Error("Expected the strip to be fully lit at some point")
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+)
+
+// MessageRequest is the JSON body of /api/message. Repeat is the
+// number of passes, 0 shows the message until it's replaced.
+type MessageRequest struct {
+	Text   string `json:"Text"`
+	Repeat int    `json:"Repeat"`
+}
+
+// messageHandler returns (GET), shows (POST) or removes (DELETE) the
+// message of the MessageLED producer.
+func (a *App) messageHandler(w http.ResponseWriter, r *http.Request) {
+	message := a.message.Load()
+	if message == nil {
+		http.Error(w, "MessageLED is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		text, repeat := message.Message()
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(MessageRequest{Text: text, Repeat: repeat}); err != nil {
+			slog.Error("Failed to encode message to JSON", "error", err)
+		}
+	case http.MethodPost:
+		var req MessageRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if err := message.ShowMessage(req.Text, req.Repeat); err != nil {
+			http.Error(w, "Invalid message: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		slog.Info("Showing message", "text", req.Text, "repeat", req.Repeat)
+		w.WriteHeader(http.StatusOK)
+	case http.MethodDelete:
+		slog.Info("Clearing message")
+		message.ClearMessage()
+		w.WriteHeader(http.StatusOK)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
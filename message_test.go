@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	c "lautenbacher.net/goleds/config"
+	p "lautenbacher.net/goleds/producer"
+	u "lautenbacher.net/goleds/util"
+)
+
+func TestMessageHandler(t *testing.T) {
+	app := NewApp(make(chan os.Signal, 1))
+
+	rec := httptest.NewRecorder()
+	app.messageHandler(rec, httptest.NewRequest(http.MethodPost, "/api/message", bytes.NewBufferString(`{"Text": "SOS"}`)))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d without MessageLED, got %d", http.StatusServiceUnavailable, rec.Code)
+	}
+
+	cfg := c.MessageLEDConfig{Enabled: true, Encoding: "Morse", EndLed: 9, LedRGB: []float64{0, 0, 100},
+		Message: "HI", Speed: 10, Delay: 100 * time.Millisecond}
+	app.message.Store(p.NewMessageProducer(MESSAGE_LED_UID, u.NewAtomicMapEvent[p.LedProducer](), 10, cfg, nil))
+
+	tests := []struct {
+		body       string
+		wantStatus int
+	}{
+		{`{"Text": 42}`, http.StatusBadRequest},
+		{`{"Text": "SOS", "Repeat": -1}`, http.StatusBadRequest},
+		{`{"Text": "SOS", "Repeat": 3}`, http.StatusOK},
+	}
+	for _, tc := range tests {
+		rec := httptest.NewRecorder()
+		app.messageHandler(rec, httptest.NewRequest(http.MethodPost, "/api/message", bytes.NewBufferString(tc.body)))
+		if rec.Code != tc.wantStatus {
+			t.Errorf("%s: expected status %d, got %d", tc.body, tc.wantStatus, rec.Code)
+		}
+	}
+
+	get := func() MessageRequest {
+		rec := httptest.NewRecorder()
+		app.messageHandler(rec, httptest.NewRequest(http.MethodGet, "/api/message", nil))
+		var resp MessageRequest
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode message: %v", err)
+		}
+		return resp
+	}
+	if resp := get(); resp != (MessageRequest{Text: "SOS", Repeat: 3}) {
+		t.Errorf("Expected the message SOS with 3 passes, got %+v", resp)
+	}
+
+	rec = httptest.NewRecorder()
+	app.messageHandler(rec, httptest.NewRequest(http.MethodDelete, "/api/message", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected status %d for DELETE, got %d", http.StatusOK, rec.Code)
+	}
+	if resp := get(); resp.Text != "HI" {
+		t.Errorf("Expected the configured message after DELETE, got %+v", resp)
+	}
+
+	rec = httptest.NewRecorder()
+	app.messageHandler(rec, httptest.NewRequest(http.MethodPut, "/api/message", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d for PUT, got %d", http.StatusMethodNotAllowed, rec.Code)
+	}
+}
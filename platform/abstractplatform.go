@@ -17,6 +17,8 @@ type AbstractPlatform struct {
 	sensorEvents    chan *u.Trigger
 	sensors         map[string]*sensor
 	segments        map[string][]*segment
+	layout          *p.Layout    // nil without matrix layout
+	positions       *p.Positions // nil without physical positions
 	displayFunc     func([]p.Led)
 	displayWg       sync.WaitGroup
 	displayStopChan chan bool
@@ -24,17 +26,34 @@ type AbstractPlatform struct {
 	shutdownMutex   sync.RWMutex
 	isShuttingDown  bool
 	ledBufferPool   *sync.Pool
+	clock           u.Clock
+	ambient         *ambientLight // nil without ambient light sensor
+	ambientWg       sync.WaitGroup
+	ambientStopChan chan bool
 }
 
 func newAbstractPlatform(conf *c.Config, displayFunc func([]p.Led)) *AbstractPlatform {
-	return &AbstractPlatform{
+	inst := &AbstractPlatform{
 		config:          conf,
 		ledsEvent:       u.NewAtomicEvent[[]p.Led](),
 		sensorEvents:    make(chan *u.Trigger),
 		sensors:         make(map[string]*sensor),
 		displayFunc:     displayFunc,
 		displayStopChan: make(chan bool),
+		clock:           u.RealClock{},
+		layout:          p.NewLayout(conf.Hardware.Display.Layout, conf.Hardware.Display.LedsTotal),
+		positions:       p.NewPositions(conf.Hardware.Display.Positions, conf.Hardware.Display.LedsTotal),
 	}
+	if conf.Hardware.AmbientLight.Enabled {
+		inst.ambient = newAmbientLight(conf.Hardware.AmbientLight)
+		inst.ambientStopChan = make(chan bool)
+	}
+	return inst
+}
+
+// SetClock sets the clock used for sensor polling and trigger timestamps.
+func (s *AbstractPlatform) SetClock(clock u.Clock) {
+	s.clock = clock
 }
 
 func (s *AbstractPlatform) SetLeds(leds []p.Led) {
@@ -57,6 +76,14 @@ func (s *AbstractPlatform) GetLedsTotal() int {
 	return s.config.Hardware.Display.LedsTotal
 }
 
+func (s *AbstractPlatform) GetLayout() *p.Layout {
+	return s.layout
+}
+
+func (s *AbstractPlatform) GetPositions() *p.Positions {
+	return s.positions
+}
+
 func (s *AbstractPlatform) GetForceUpdateDelay() time.Duration {
 	return s.config.Hardware.Display.ForceUpdateDelay
 }
@@ -69,6 +96,10 @@ func (s *AbstractPlatform) setInShutdown() {
 
 func (s *AbstractPlatform) displayDriver() {
 	defer s.displayWg.Done()
+	var limiter *powerLimiter
+	if s.config.Hardware.Display.Power.Enabled {
+		limiter = newPowerLimiter(s.config.Hardware.Display, s.config.Hardware.LEDType, s.segments)
+	}
 	for {
 		select {
 		case <-s.displayStopChan:
@@ -78,6 +109,12 @@ func (s *AbstractPlatform) displayDriver() {
 			sumLeds := s.ledsEvent.Value()
 			s.shutdownMutex.RLock()
 			if !s.isShuttingDown {
+				if s.ambient != nil {
+					p.ScaleLeds(sumLeds, s.ambient.brightness())
+				}
+				if limiter != nil {
+					limiter.limit(sumLeds)
+				}
 				s.displayFunc(sumLeds)
 			}
 			s.shutdownMutex.RUnlock()
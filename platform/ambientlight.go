@@ -0,0 +1,105 @@
+package platform
+
+import (
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	c "lautenbacher.net/goleds/config"
+)
+
+// ambientLight turns the readings of an ambient light sensor into a
+// global brightness factor: the readings are smoothed exponentially
+// and mapped to a factor by the configured curve.
+type ambientLight struct {
+	curve     [][]float64
+	smoothing time.Duration
+	mutex     sync.RWMutex
+	reading   float64
+	lastRead  time.Time
+	factor    float64
+}
+
+func newAmbientLight(cfg c.AmbientLightConfig) *ambientLight {
+	return &ambientLight{
+		curve:     cfg.Curve,
+		smoothing: cfg.Smoothing,
+		factor:    1,
+	}
+}
+
+// update adds a new raw reading taken at now and returns the new
+// brightness factor.
+func (a *ambientLight) update(reading float64, now time.Time) float64 {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if a.lastRead.IsZero() || a.smoothing <= 0 {
+		a.reading = reading
+	} else {
+		alpha := 1 - math.Exp(-float64(now.Sub(a.lastRead))/float64(a.smoothing))
+		a.reading += alpha * (reading - a.reading)
+	}
+	a.lastRead = now
+	a.factor = curveValue(a.curve, a.reading)
+	return a.factor
+}
+
+// brightness returns the current brightness factor.
+func (a *ambientLight) brightness() float64 {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	return a.factor
+}
+
+// curveValue interpolates the [x, y] points of curve linearly at x.
+// Outside of the curve the first or last y value is used.
+func curveValue(curve [][]float64, x float64) float64 {
+	if x <= curve[0][0] {
+		return curve[0][1]
+	}
+	for i := 1; i < len(curve); i++ {
+		if x <= curve[i][0] {
+			x0, y0, x1, y1 := curve[i-1][0], curve[i-1][1], curve[i][0], curve[i][1]
+			return y0 + (x-x0)/(x1-x0)*(y1-y0)
+		}
+	}
+	return curve[len(curve)-1][1]
+}
+
+// startAmbientLight starts reading the ambient light sensor with read
+// every ReadInterval, if the sensor is enabled.
+func (s *AbstractPlatform) startAmbientLight(read func() (float64, error)) {
+	if s.ambient == nil {
+		return
+	}
+	s.ambientWg.Add(1)
+	go func() {
+		defer s.ambientWg.Done()
+		ticker := s.clock.NewTicker(s.config.Hardware.AmbientLight.ReadInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.ambientStopChan:
+				slog.Info("Ending ambient light go-routine...")
+				return
+			case <-ticker.C():
+				reading, err := read()
+				if err != nil {
+					slog.Error("Error reading ambient light sensor", "error", err)
+					continue
+				}
+				s.ambient.update(reading, s.clock.Now())
+			}
+		}
+	}()
+}
+
+// stopAmbientLight stops the go-routine started by startAmbientLight.
+func (s *AbstractPlatform) stopAmbientLight() {
+	if s.ambient == nil {
+		return
+	}
+	close(s.ambientStopChan)
+	s.ambientWg.Wait()
+}
@@ -0,0 +1,98 @@
+package platform
+
+import (
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	c "lautenbacher.net/goleds/config"
+	p "lautenbacher.net/goleds/producer"
+	u "lautenbacher.net/goleds/util"
+)
+
+var ambientCurve = [][]float64{{0, 0.1}, {100, 0.5}, {300, 1}}
+
+func TestCurveValue(t *testing.T) {
+	tests := []struct{ x, expected float64 }{
+		{-10, 0.1}, {0, 0.1}, {50, 0.3}, {100, 0.5}, {200, 0.75}, {300, 1}, {1000, 1},
+	}
+	for _, tc := range tests {
+		if got := curveValue(ambientCurve, tc.x); math.Abs(got-tc.expected) > 1e-9 {
+			t.Errorf("curveValue(%v): expected %v, got %v", tc.x, tc.expected, got)
+		}
+	}
+}
+
+func TestAmbientLight_Smoothing(t *testing.T) {
+	a := newAmbientLight(c.AmbientLightConfig{Curve: ambientCurve, Smoothing: time.Second})
+	if a.brightness() != 1 {
+		t.Errorf("Expected full brightness before the first reading, got %v", a.brightness())
+	}
+	start := time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC)
+
+	// The first reading is taken as is.
+	if factor := a.update(0, start); factor != 0.1 {
+		t.Errorf("Expected factor 0.1 for the first reading, got %v", factor)
+	}
+	// After one time constant, 63% of a jump is reached.
+	a.update(100, start.Add(time.Second))
+	if expected := 100 * (1 - math.Exp(-1)); math.Abs(a.reading-expected) > 1e-9 {
+		t.Errorf("Expected smoothed reading %v, got %v", expected, a.reading)
+	}
+	// Many time constants later the reading has settled.
+	a.update(100, start.Add(time.Minute))
+	if math.Abs(a.brightness()-0.5) > 1e-9 {
+		t.Errorf("Expected factor 0.5 once settled, got %v", a.brightness())
+	}
+}
+
+// TestAmbientLight_ScalesDisplay checks that the display driver scales
+// the LEDs by the factor of the ambient light readings.
+func TestAmbientLight_ScalesDisplay(t *testing.T) {
+	conf := &c.Config{}
+	conf.Hardware.AmbientLight = c.AmbientLightConfig{
+		Enabled:      true,
+		Source:       "ADC",
+		ReadInterval: 100 * time.Millisecond,
+		Curve:        ambientCurve,
+	}
+	displayed := make(chan []p.Led, 1)
+	platform := newAbstractPlatform(conf, func(leds []p.Led) {
+		frame := make([]p.Led, len(leds))
+		copy(frame, leds)
+		displayed <- frame
+	})
+	clock := u.NewFakeClock(time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC))
+	platform.SetClock(clock)
+	platform.ledBufferPool = &sync.Pool{New: func() any { return make([]p.Led, 1) }}
+
+	platform.displayWg.Add(1)
+	go platform.displayDriver()
+	platform.startAmbientLight(func() (float64, error) { return 100, nil })
+	defer func() {
+		platform.stopAmbientLight()
+		close(platform.displayStopChan)
+		platform.displayWg.Wait()
+	}()
+
+	clock.BlockUntil(1)
+	clock.Advance(100 * time.Millisecond)
+	deadline := time.Now().Add(time.Second)
+	for platform.ambient.brightness() != 0.5 {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the ambient light reading")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	platform.SetLeds([]p.Led{{Red: 100, Green: 50, Blue: 10}})
+	select {
+	case frame := <-displayed:
+		if frame[0] != (p.Led{Red: 50, Green: 25, Blue: 5}) {
+			t.Errorf("Expected LEDs scaled by 0.5, got %+v", frame[0])
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for the display update")
+	}
+}
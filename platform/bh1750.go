@@ -0,0 +1,50 @@
+package platform
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// i2cSlave is the ioctl request selecting the I2C slave address.
+	i2cSlave = 0x0703
+	// bh1750ContinuousHighRes starts continuous measurements with a
+	// resolution of 1 lx.
+	bh1750ContinuousHighRes = 0x10
+)
+
+// bh1750 reads a BH1750 lux sensor via the Linux I2C device interface.
+type bh1750 struct {
+	device *os.File
+}
+
+func openBH1750(bus int, address uint16) (*bh1750, error) {
+	device, err := os.OpenFile(fmt.Sprintf("/dev/i2c-%d", bus), os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open I2C bus: %w", err)
+	}
+	if err := unix.IoctlSetInt(int(device.Fd()), i2cSlave, int(address)); err != nil {
+		device.Close()
+		return nil, fmt.Errorf("failed to select I2C address 0x%x: %w", address, err)
+	}
+	if _, err := device.Write([]byte{bh1750ContinuousHighRes}); err != nil {
+		device.Close()
+		return nil, fmt.Errorf("failed to start BH1750 measurement: %w", err)
+	}
+	return &bh1750{device: device}, nil
+}
+
+// readLux returns the last measured illuminance in lux.
+func (b *bh1750) readLux() (float64, error) {
+	data := make([]byte, 2)
+	if _, err := b.device.Read(data); err != nil {
+		return 0, fmt.Errorf("failed to read BH1750: %w", err)
+	}
+	return float64(uint16(data[0])<<8|uint16(data[1])) / 1.2, nil
+}
+
+func (b *bh1750) close() error {
+	return b.device.Close()
+}
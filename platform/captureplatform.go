@@ -0,0 +1,65 @@
+package platform
+
+import (
+	"sync"
+	"time"
+
+	p "lautenbacher.net/goleds/producer"
+	u "lautenbacher.net/goleds/util"
+)
+
+// Frame is a copy of the LEDs handed to SetLeds together with the
+// time they were handed over.
+type Frame struct {
+	Time time.Time
+	Leds []p.Led
+}
+
+// CapturePlatform wraps another Platform and keeps a copy of every
+// frame handed to SetLeds before passing it on. The captured frames can
+// be inspected via Frames(), e.g. to assert the LED sequence that
+// results from a replayed recording, or exported as images (see
+// WritePNGStrip and WriteGIF).
+type CapturePlatform struct {
+	Platform
+	framesMutex sync.Mutex
+	frames      []Frame
+	clock       u.Clock
+}
+
+func NewCapturePlatform(inner Platform) *CapturePlatform {
+	return &CapturePlatform{
+		Platform: inner,
+		clock:    u.RealClock{},
+	}
+}
+
+// SetClock sets the clock used to timestamp the captured frames.
+func (s *CapturePlatform) SetClock(clock u.Clock) {
+	s.clock = clock
+}
+
+func (s *CapturePlatform) SetLeds(leds []p.Led) {
+	frame := Frame{Time: s.clock.Now(), Leds: make([]p.Led, len(leds))}
+	copy(frame.Leds, leds)
+	s.framesMutex.Lock()
+	s.frames = append(s.frames, frame)
+	s.framesMutex.Unlock()
+	s.Platform.SetLeds(leds)
+}
+
+// Frames returns a copy of all frames captured so far.
+func (s *CapturePlatform) Frames() []Frame {
+	s.framesMutex.Lock()
+	defer s.framesMutex.Unlock()
+	ret := make([]Frame, len(s.frames))
+	copy(ret, s.frames)
+	return ret
+}
+
+// ClearFrames discards all frames captured so far.
+func (s *CapturePlatform) ClearFrames() {
+	s.framesMutex.Lock()
+	s.frames = nil
+	s.framesMutex.Unlock()
+}
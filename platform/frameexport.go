@@ -0,0 +1,139 @@
+package platform
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"image/png"
+	"io"
+	"math"
+	"time"
+
+	p "lautenbacher.net/goleds/producer"
+)
+
+// ExportOptions control how captured frames are turned into images.
+type ExportOptions struct {
+	// PixelSize is the edge length in pixels of the square drawn for
+	// a single LED. Values < 1 default to 8.
+	PixelSize int
+	// Gain multiplies the LED values before they are clamped to 255.
+	// The values sent to real strips are often quite small (e.g. 10)
+	// and would be almost black in an image. Values <= 0 default to 1.
+	Gain float64
+}
+
+func (o ExportOptions) pixelSize() int {
+	if o.PixelSize < 1 {
+		return 8
+	}
+	return o.PixelSize
+}
+
+func (o ExportOptions) gain() float64 {
+	if o.Gain <= 0 {
+		return 1
+	}
+	return o.Gain
+}
+
+// ledColor converts an LED to an opaque image color.
+func ledColor(led p.Led, gain float64) color.RGBA {
+	conv := func(v float64) uint8 {
+		return uint8(math.Round(math.Max(0, math.Min(v*gain, 255))))
+	}
+	return color.RGBA{R: conv(led.Red), G: conv(led.Green), B: conv(led.Blue), A: 255}
+}
+
+// stripWidth returns the number of LEDs of the widest frame.
+func stripWidth(frames []Frame) int {
+	width := 0
+	for _, frame := range frames {
+		width = max(width, len(frame.Leds))
+	}
+	return width
+}
+
+// WritePNGStrip writes all frames as one PNG image: each frame is a
+// row of LEDs, time runs from top to bottom.
+func WritePNGStrip(w io.Writer, frames []Frame, opts ExportOptions) error {
+	if len(frames) == 0 {
+		return fmt.Errorf("no frames to export")
+	}
+	size := opts.pixelSize()
+	img := image.NewRGBA(image.Rect(0, 0, stripWidth(frames)*size, len(frames)*size))
+	for row, frame := range frames {
+		for col, led := range frame.Leds {
+			fillSquare(img, col*size, row*size, size, ledColor(led, opts.gain()))
+		}
+	}
+	return png.Encode(w, img)
+}
+
+// WriteGIF writes the frames as an animated GIF. The delay of every
+// image is the time between its frame and the next one, the last frame
+// is shown for lastDelay.
+func WriteGIF(w io.Writer, frames []Frame, lastDelay time.Duration, opts ExportOptions) error {
+	if len(frames) == 0 {
+		return fmt.Errorf("no frames to export")
+	}
+	size := opts.pixelSize()
+	bounds := image.Rect(0, 0, stripWidth(frames)*size, size)
+	pal := gifPalette(frames, opts.gain())
+
+	anim := &gif.GIF{}
+	for i, frame := range frames {
+		img := image.NewPaletted(bounds, pal)
+		for col, led := range frame.Leds {
+			fillSquare(img, col*size, 0, size, ledColor(led, opts.gain()))
+		}
+		delay := lastDelay
+		if i < len(frames)-1 {
+			delay = frames[i+1].Time.Sub(frame.Time)
+		}
+		anim.Image = append(anim.Image, img)
+		anim.Delay = append(anim.Delay, gifDelay(delay))
+	}
+	return gif.EncodeAll(w, anim)
+}
+
+// gifPalette returns the exact colors of all frames if they fit into
+// a GIF palette, otherwise a generic one (colors are then mapped to
+// the nearest palette entry).
+func gifPalette(frames []Frame, gain float64) color.Palette {
+	seen := map[color.RGBA]bool{{A: 255}: true}
+	pal := color.Palette{color.RGBA{A: 255}}
+	for _, frame := range frames {
+		for _, led := range frame.Leds {
+			col := ledColor(led, gain)
+			if !seen[col] {
+				if len(pal) == 256 {
+					return palette.Plan9
+				}
+				seen[col] = true
+				pal = append(pal, col)
+			}
+		}
+	}
+	return pal
+}
+
+// gifDelay converts a duration to the GIF delay unit of 10ms. Most
+// viewers treat delays below 20ms as 100ms, so these are rounded up.
+func gifDelay(d time.Duration) int {
+	return max(int(d.Round(10*time.Millisecond)/(10*time.Millisecond)), 2)
+}
+
+type settableImage interface {
+	Set(x, y int, c color.Color)
+}
+
+func fillSquare(img settableImage, x, y, size int, col color.Color) {
+	for dy := 0; dy < size; dy++ {
+		for dx := 0; dx < size; dx++ {
+			img.Set(x+dx, y+dy, col)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package platform
+
+import (
+	"bytes"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"testing"
+	"time"
+
+	p "lautenbacher.net/goleds/producer"
+)
+
+var exportStart = time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC)
+
+func exportFrames() []Frame {
+	return []Frame{
+		{Time: exportStart, Leds: []p.Led{{Red: 255}, {}, {}}},
+		{Time: exportStart.Add(50 * time.Millisecond), Leds: []p.Led{{}, {Green: 10}, {}}},
+		{Time: exportStart.Add(250 * time.Millisecond), Leds: []p.Led{{}, {}, {Blue: 300}}},
+	}
+}
+
+func TestWritePNGStrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WritePNGStrip(&buf, exportFrames(), ExportOptions{PixelSize: 2, Gain: 10}); err != nil {
+		t.Fatalf("WritePNGStrip failed: %v", err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("Failed to decode PNG: %v", err)
+	}
+	if bounds := img.Bounds(); bounds.Dx() != 6 || bounds.Dy() != 6 {
+		t.Fatalf("Expected a 6x6 image, got %v", bounds)
+	}
+
+	tests := []struct {
+		x, y     int
+		expected color.RGBA
+	}{
+		{0, 0, color.RGBA{R: 255, A: 255}}, // clamped after gain
+		{3, 3, color.RGBA{G: 100, A: 255}}, // 10 * gain
+		{5, 5, color.RGBA{B: 255, A: 255}}, // clamped
+		{2, 0, color.RGBA{A: 255}},
+	}
+	for _, tt := range tests {
+		if got := color.RGBAModel.Convert(img.At(tt.x, tt.y)); got != tt.expected {
+			t.Errorf("Pixel (%d,%d): expected %v, got %v", tt.x, tt.y, tt.expected, got)
+		}
+	}
+}
+
+func TestWriteGIF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteGIF(&buf, exportFrames(), time.Second, ExportOptions{PixelSize: 1}); err != nil {
+		t.Fatalf("WriteGIF failed: %v", err)
+	}
+	anim, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatalf("Failed to decode GIF: %v", err)
+	}
+	if len(anim.Image) != 3 {
+		t.Fatalf("Expected 3 images, got %d", len(anim.Image))
+	}
+	expectedDelays := []int{5, 20, 100}
+	for i, delay := range anim.Delay {
+		if delay != expectedDelays[i] {
+			t.Errorf("Image %d: expected delay %d, got %d", i, expectedDelays[i], delay)
+		}
+	}
+	if got := color.RGBAModel.Convert(anim.Image[1].At(1, 0)); got != (color.RGBA{G: 10, A: 255}) {
+		t.Errorf("Expected exact palette color for LED 1 of image 1, got %v", got)
+	}
+}
+
+func TestExportWithoutFrames(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WritePNGStrip(&buf, nil, ExportOptions{}); err == nil {
+		t.Error("Expected an error for PNG export without frames")
+	}
+	if err := WriteGIF(&buf, nil, time.Second, ExportOptions{}); err == nil {
+		t.Error("Expected an error for GIF export without frames")
+	}
+}
@@ -0,0 +1,75 @@
+package platform
+
+import (
+	"fmt"
+	"sync"
+
+	c "lautenbacher.net/goleds/config"
+	p "lautenbacher.net/goleds/producer"
+	u "lautenbacher.net/goleds/util"
+)
+
+// HeadlessPlatform is a Platform without any hardware or terminal. It
+// captures every frame handed to SetLeds (see CapturePlatform) and
+// takes sensor triggers from code via Trigger(). This makes it usable
+// in CI, e.g. for golden-image tests of the producers. Longer scripted
+// sequences of triggers can be fed by wrapping it in a ReplayPlatform.
+type HeadlessPlatform struct {
+	*CapturePlatform
+	sink *headlessSink
+}
+
+// headlessSink is the innermost platform of a HeadlessPlatform. It
+// provides the configuration and sensor events, but displays nothing.
+type headlessSink struct {
+	*AbstractPlatform
+	readyChan chan bool
+}
+
+func NewHeadlessPlatform(conf *c.Config) *HeadlessPlatform {
+	sink := &headlessSink{readyChan: make(chan bool)}
+	sink.AbstractPlatform = newAbstractPlatform(conf, nil)
+	return &HeadlessPlatform{
+		CapturePlatform: NewCapturePlatform(sink),
+		sink:            sink,
+	}
+}
+
+// SetClock sets the clock used for the frame and trigger timestamps.
+func (s *HeadlessPlatform) SetClock(clock u.Clock) {
+	s.CapturePlatform.SetClock(clock)
+	s.sink.SetClock(clock)
+}
+
+// Trigger sends a sensor event for the sensor uid with the given
+// value. It blocks until the application has received the event.
+func (s *HeadlessPlatform) Trigger(uid string, value int) error {
+	if _, exist := s.sink.sensors[uid]; !exist {
+		return fmt.Errorf("unknown sensor: %s", uid)
+	}
+	s.sink.sensorEvents <- u.NewTrigger(uid, value, s.sink.clock.Now())
+	return nil
+}
+
+func (s *headlessSink) Start(pool *sync.Pool) error {
+	s.ledBufferPool = pool
+	s.initSensors(s.config.Hardware.Sensors)
+	close(s.readyChan)
+	return nil
+}
+
+func (s *headlessSink) Stop() {
+	s.setInShutdown()
+}
+
+func (s *headlessSink) Ready() <-chan bool {
+	return s.readyChan
+}
+
+// SetLeds only returns the buffer to the pool, the frame has already
+// been copied by the CapturePlatform.
+func (s *headlessSink) SetLeds(leds []p.Led) {
+	if s.ledBufferPool != nil {
+		s.ledBufferPool.Put(leds)
+	}
+}
@@ -0,0 +1,181 @@
+package platform
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	c "lautenbacher.net/goleds/config"
+	p "lautenbacher.net/goleds/producer"
+	u "lautenbacher.net/goleds/util"
+)
+
+var updateGolden = flag.Bool("update", false, "Rewrite the golden images in testdata/golden")
+
+func headlessConfig() *c.Config {
+	conf := &c.Config{}
+	conf.Hardware.Display.LedsTotal = 20
+	conf.Hardware.Sensors.SmoothingSize = 1
+	conf.Hardware.Sensors.SensorCfg = map[string]c.SensorCfg{
+		"S0": {LedIndex: 0, TriggerValue: 100},
+		"S1": {LedIndex: 19, TriggerValue: 100},
+	}
+	return conf
+}
+
+func TestHeadlessPlatform_TriggerAndCapture(t *testing.T) {
+	clock := u.NewFakeClock(exportStart)
+	headless := NewHeadlessPlatform(headlessConfig())
+	headless.SetClock(clock)
+	pool := &sync.Pool{New: func() any { return make([]p.Led, 20) }}
+	if err := headless.Start(pool); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer headless.Stop()
+
+	select {
+	case <-headless.Ready():
+	case <-time.After(time.Second):
+		t.Fatal("Headless platform did not become ready")
+	}
+	if indices := headless.GetSensorLedIndices(); indices["S1"] != 19 {
+		t.Errorf("Expected LED index 19 for S1, got %v", indices)
+	}
+
+	go func() {
+		if err := headless.Trigger("S1", 300); err != nil {
+			t.Errorf("Trigger failed: %v", err)
+		}
+	}()
+	trigger := receiveTrigger(t, headless.GetSensorEvents())
+	if trigger.ID != "S1" || trigger.Value != 300 || !trigger.Timestamp.Equal(exportStart) {
+		t.Errorf("Unexpected trigger: %+v", trigger)
+	}
+	if err := headless.Trigger("S9", 300); err == nil {
+		t.Error("Expected an error for an unknown sensor")
+	}
+
+	leds := pool.Get().([]p.Led)
+	leds[3] = p.Led{Red: 7}
+	headless.SetLeds(leds)
+	clock.Advance(time.Second)
+	leds = pool.Get().([]p.Led)
+	headless.SetLeds(leds)
+
+	frames := headless.Frames()
+	if len(frames) != 2 {
+		t.Fatalf("Expected 2 frames, got %d", len(frames))
+	}
+	if frames[0].Leds[3].Red != 7 || frames[1].Time.Sub(frames[0].Time) != time.Second {
+		t.Errorf("Unexpected frames: %+v", frames)
+	}
+}
+
+// captureProducer runs prod on a FakeClock: the first frame is taken
+// once the producer has started, then the clock is advanced by step
+// for each further frame. Every step must change the producer's LEDs.
+func captureProducer(t *testing.T, prod p.LedProducer, ledsChanged *u.AtomicMapEvent[p.LedProducer],
+	clock *u.FakeClock, start func(), waiters int, steps int, step time.Duration,
+) []Frame {
+	t.Helper()
+	headless := NewHeadlessPlatform(headlessConfig())
+	headless.SetClock(clock)
+	if err := headless.Start(nil); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer headless.Stop()
+
+	capture := func() {
+		t.Helper()
+		select {
+		case <-ledsChanged.Channel():
+			ledsChanged.ConsumeValues()
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Producer %s did not update its LEDs at %s", prod.GetUID(), clock.Now())
+		}
+		leds := make([]p.Led, headless.GetLedsTotal())
+		prod.GetLeds(leds)
+		headless.SetLeds(leds)
+	}
+
+	start()
+	defer prod.Exit()
+	clock.BlockUntil(waiters)
+	capture()
+	for i := 0; i < steps; i++ {
+		clock.Advance(step)
+		capture()
+	}
+	return headless.Frames()
+}
+
+// assertGolden compares the PNG strip of the frames with the golden
+// image testdata/golden/<name>.png. Run the tests with -update to
+// (re)create the golden images after an intended visual change.
+func assertGolden(t *testing.T, name string, frames []Frame) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := WritePNGStrip(&buf, frames, ExportOptions{PixelSize: 4, Gain: 1}); err != nil {
+		t.Fatalf("Failed to export frames: %v", err)
+	}
+	path := filepath.Join("testdata", "golden", name+".png")
+	if *updateGolden {
+		if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+			t.Fatalf("Failed to write golden image: %v", err)
+		}
+		return
+	}
+	golden, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read golden image (run with -update to create it): %v", err)
+	}
+	if !bytes.Equal(golden, buf.Bytes()) {
+		failed := filepath.Join(t.TempDir(), name+".png")
+		os.WriteFile(failed, buf.Bytes(), 0o644)
+		t.Errorf("Frames differ from golden image %s, got %s", path, failed)
+	}
+}
+
+func TestGolden_CylonProducer(t *testing.T) {
+	clock := u.NewFakeClock(exportStart)
+	ledsChanged := u.NewAtomicMapEvent[p.LedProducer]()
+	prod := p.NewCylonProducer("cylon", ledsChanged, 20, time.Hour, 20*time.Millisecond, 1, 4, []float64{255, 40, 0}, nil)
+	prod.SetClock(clock)
+	// The first frame needs one tick, so the first capture waits for it.
+	start := func() {
+		prod.Start()
+		clock.BlockUntil(2)
+		clock.Advance(20 * time.Millisecond)
+	}
+	assertGolden(t, "cylon", captureProducer(t, prod, ledsChanged, clock, start, 2, 45, 20*time.Millisecond))
+}
+
+func TestGolden_ClockProducer(t *testing.T) {
+	clock := u.NewFakeClock(time.Date(2024, 1, 1, 10, 42, 0, 0, time.Local))
+	ledsChanged := u.NewAtomicMapEvent[p.LedProducer]()
+	prod := p.NewClockProducer("clock", ledsChanged, 20, c.ClockLEDConfig{
+		StartLedHour: 0, EndLedHour: 9, StartLedMinute: 10, EndLedMinute: 19,
+		LedHour: []float64{0, 0, 255}, LedMinute: []float64{0, 255, 0},
+	})
+	prod.SetClock(clock)
+	// Every 6 minutes the minute hand moves to the next LED.
+	assertGolden(t, "clock", captureProducer(t, prod, ledsChanged, clock, prod.Start, 1, 8, 6*time.Minute))
+}
+
+func TestGolden_SensorLedProducer(t *testing.T) {
+	clock := u.NewFakeClock(exportStart)
+	ledsChanged := u.NewAtomicMapEvent[p.LedProducer]()
+	var wg sync.WaitGroup
+	prod := p.NewSensorLedProducer("S0", 6, ledsChanged, 20, c.SensorLEDConfig{
+		RunUpDelay: 10 * time.Millisecond, RunDownDelay: 10 * time.Millisecond, HoldTime: time.Hour,
+		LedRGB: []float64{200, 160, 60}, LatchLedRGB: []float64{0, 0, 0},
+	}, &wg)
+	prod.SetClock(clock)
+	start := func() { prod.SendTrigger(u.NewTrigger("S0", 300, clock.Now())) }
+	// The run-up reaches the far end after 13 steps.
+	assertGolden(t, "sensorled", captureProducer(t, prod, ledsChanged, clock, start, 1, 13, 10*time.Millisecond))
+}
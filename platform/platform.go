@@ -32,6 +32,14 @@ type Platform interface {
 	// GetLedsTotal returns the total number of configured LEDs.
 	GetLedsTotal() int
 
+	// GetLayout returns the matrix layout of the LEDs, nil if none is
+	// configured.
+	GetLayout() *p.Layout
+
+	// GetPositions returns the physical positions of the LEDs, nil if
+	// none are configured.
+	GetPositions() *p.Positions
+
 	// GetForceUpdateDelay returns the configured delay for forcing a display update.
 	GetForceUpdateDelay() time.Duration
 
@@ -0,0 +1,120 @@
+package platform
+
+import (
+	"log/slog"
+	"math"
+	"strings"
+
+	c "lautenbacher.net/goleds/config"
+	p "lautenbacher.net/goleds/producer"
+)
+
+// powerLimiter estimates the current a frame draws on the visible
+// segments and scales the frame down to the configured budgets.
+//
+// The estimate follows what the LED drivers send: every channel value
+// is colour corrected and capped at 255, the current of a channel is
+// proportional to that value. APA102 strips additionally scale all
+// channels by their global brightness.
+type powerLimiter struct {
+	channelMilliamps [3]float64 // at a (corrected) value of 255
+	correction       [3]float64
+	idleMilliamps    float64
+	budget           float64
+	segmentBudget    float64
+	segments         []*segment
+	limiting         bool
+}
+
+func newPowerLimiter(display c.DisplayConfig, ledType string, segments map[string][]*segment) *powerLimiter {
+	cfg := display.Power
+	inst := &powerLimiter{
+		idleMilliamps: cfg.IdleMilliamps,
+		budget:        cfg.BudgetMilliamps,
+		segmentBudget: cfg.SegmentBudgetMilliamps,
+	}
+	scale := 1.0
+	if strings.EqualFold(ledType, "APA102") {
+		scale = float64(display.APA102_Brightness&0x1F) / 31
+	}
+	for i := range inst.channelMilliamps {
+		inst.channelMilliamps[i] = cfg.ChannelMilliamps[i] * scale
+		inst.correction[i] = display.ColorCorrection[i]
+	}
+	for _, segarray := range segments {
+		for _, seg := range segarray {
+			if seg.visible {
+				inst.segments = append(inst.segments, seg)
+			}
+		}
+	}
+	return inst
+}
+
+// current returns the estimated current of leds, split into the part
+// depending on the LED values and the idle current.
+func (l *powerLimiter) current(leds []p.Led) (dynamic, idle float64) {
+	for _, led := range leds {
+		for i, value := range [3]float64{led.Red, led.Green, led.Blue} {
+			dynamic += math.Min(value*l.correction[i], 255) / 255 * l.channelMilliamps[i]
+		}
+	}
+	return dynamic, float64(len(leds)) * l.idleMilliamps
+}
+
+// scale scales leds so that their dynamic current is multiplied by
+// factor. Values beyond what the driver can send are capped first, so
+// the scaling really reduces the current.
+func (l *powerLimiter) scale(leds []p.Led, factor float64) {
+	capped := func(value float64, i int) float64 {
+		if l.correction[i] <= 0 {
+			return 0
+		}
+		return math.Min(value, 255/l.correction[i]) * factor
+	}
+	for j, led := range leds {
+		leds[j] = p.Led{Red: capped(led.Red, 0), Green: capped(led.Green, 1), Blue: capped(led.Blue, 2)}
+	}
+}
+
+// limitFactor returns the factor that brings the current down to
+// budget, or 1 if it is within budget.
+func limitFactor(dynamic, idle, budget float64) float64 {
+	if budget <= 0 || dynamic+idle <= budget {
+		return 1
+	}
+	return math.Max(budget-idle, 0) / dynamic
+}
+
+// limit scales leds down to the segment and total budgets and returns
+// the estimated current afterwards in milliamps.
+func (l *powerLimiter) limit(leds []p.Led) float64 {
+	var dynamicTotal, idleTotal float64
+	limited := false
+	for _, seg := range l.segments {
+		segLeds := leds[seg.firstLed : seg.lastLed+1]
+		dynamic, idle := l.current(segLeds)
+		if factor := limitFactor(dynamic, idle, l.segmentBudget); factor < 1 {
+			l.scale(segLeds, factor)
+			dynamic *= factor
+			limited = true
+		}
+		dynamicTotal += dynamic
+		idleTotal += idle
+	}
+	if factor := limitFactor(dynamicTotal, idleTotal, l.budget); factor < 1 {
+		l.scale(leds, factor)
+		dynamicTotal *= factor
+		limited = true
+	}
+
+	if limited != l.limiting {
+		l.limiting = limited
+		if limited {
+			slog.Info("Power limit reached, dimming LEDs", "milliamps", math.Round(dynamicTotal+idleTotal))
+		} else {
+			slog.Info("LEDs back within power budget")
+		}
+	}
+	return dynamicTotal + idleTotal
+}
@@ -0,0 +1,98 @@
+package platform
+
+import (
+	"math"
+	"testing"
+
+	c "lautenbacher.net/goleds/config"
+	p "lautenbacher.net/goleds/producer"
+)
+
+func powerTestDisplay(power c.PowerConfig) c.DisplayConfig {
+	return c.DisplayConfig{
+		LedsTotal:         10,
+		ColorCorrection:   []float64{1, 1, 0.5},
+		APA102_Brightness: 31,
+		LedSegments: map[string][]c.LedSegmentConfig{
+			"A": {{FirstLed: 0, LastLed: 3, SpiMultiplex: "L1"}, {FirstLed: 6, LastLed: 9, SpiMultiplex: "L2"}},
+		},
+		Power: power,
+	}
+}
+
+func fullWhite(n int) []p.Led {
+	leds := make([]p.Led, n)
+	for i := range leds {
+		leds[i] = p.Led{Red: 255, Green: 255, Blue: 255}
+	}
+	return leds
+}
+
+func TestPowerLimiter_Current(t *testing.T) {
+	display := powerTestDisplay(c.PowerConfig{Enabled: true, ChannelMilliamps: []float64{20, 20, 20}, IdleMilliamps: 1})
+	limiter := newPowerLimiter(display, "WS2801", parseDisplaySegments(display))
+
+	// Blue is colour corrected by 0.5, values beyond 255 are capped.
+	dynamic, idle := limiter.current([]p.Led{{Red: 255, Green: 510, Blue: 255}})
+	if dynamic != 50 || idle != 1 {
+		t.Errorf("Expected 50mA dynamic and 1mA idle, got %v and %v", dynamic, idle)
+	}
+
+	// The invisible gap (LEDs 4 and 5) draws no current.
+	if total := limiter.limit(fullWhite(10)); total != 8*(20+20+10+1) {
+		t.Errorf("Expected %v mA without budget, got %v", 8*(20+20+10+1), total)
+	}
+
+	// APA102 strips scale the current with their global brightness.
+	display.APA102_Brightness = 15
+	limiter = newPowerLimiter(display, "APA102", parseDisplaySegments(display))
+	if dynamic, _ := limiter.current([]p.Led{{Red: 255}}); math.Abs(dynamic-20*15.0/31) > 1e-9 {
+		t.Errorf("Expected the current scaled by the APA102 brightness, got %v", dynamic)
+	}
+}
+
+func TestPowerLimiter_Budgets(t *testing.T) {
+	display := powerTestDisplay(c.PowerConfig{
+		Enabled:                true,
+		ChannelMilliamps:       []float64{20, 20, 20},
+		IdleMilliamps:          1,
+		SegmentBudgetMilliamps: 104, // 4 idle + 100 dynamic
+		BudgetMilliamps:        158, // 8 idle + 150 dynamic
+	})
+	limiter := newPowerLimiter(display, "WS2801", parseDisplaySegments(display))
+
+	// A dark frame is within budget and unchanged.
+	leds := make([]p.Led, 10)
+	if total := limiter.limit(leds); total != 8 {
+		t.Errorf("Expected the idle current of 8mA, got %v", total)
+	}
+
+	// Segment L1 at full white draws 4*50+4 mA and is scaled to its
+	// budget, L2 only has one red LED.
+	leds = fullWhite(10)
+	for i := 4; i < 10; i++ {
+		leds[i] = p.Led{}
+	}
+	leds[9] = p.Led{Red: 255}
+	if total := limiter.limit(leds); math.Abs(total-(104+20+4)) > 1e-9 {
+		t.Errorf("Expected %v mA after the segment limit, got %v", 104+20+4, total)
+	}
+	if math.Abs(leds[0].Red-255/2.0) > 1e-9 || leds[9].Red != 255 {
+		t.Errorf("Expected only the first segment to be halved, got %+v / %+v", leds[0], leds[9])
+	}
+
+	// Both segments at full white: each is limited to 104 mA, then all
+	// together to 158 mA.
+	leds = fullWhite(10)
+	if total := limiter.limit(leds); math.Abs(total-158) > 1e-9 {
+		t.Errorf("Expected the total budget of 158mA, got %v", total)
+	}
+	// Capped values are reduced as well: a blue of 1000 can't draw more
+	// than a blue of 510 with a colour correction of 0.5.
+	leds = []p.Led{{Blue: 1000}, {}, {}, {}, {}, {}, {}, {}, {}, {}}
+	limiter.segmentBudget = 4 + 10
+	limiter.limit(leds)
+	if math.Abs(leds[0].Blue-255) > 1e-9 {
+		t.Errorf("Expected blue to be capped and halved to 255, got %v", leds[0].Blue)
+	}
+}
@@ -0,0 +1,69 @@
+package platform
+
+import (
+	"log/slog"
+	"sync"
+
+	u "lautenbacher.net/goleds/util"
+)
+
+// RecordingPlatform wraps another Platform and writes every sensor
+// event the wrapped platform produces to a TriggerRecorder before
+// handing it on to the application. Everything else is delegated to
+// the wrapped platform unchanged.
+type RecordingPlatform struct {
+	Platform
+	recorder     *u.TriggerRecorder
+	sensorEvents chan *u.Trigger
+	stopChan     chan bool
+	wg           sync.WaitGroup
+}
+
+func NewRecordingPlatform(inner Platform, recorder *u.TriggerRecorder) *RecordingPlatform {
+	return &RecordingPlatform{
+		Platform:     inner,
+		recorder:     recorder,
+		sensorEvents: make(chan *u.Trigger),
+		stopChan:     make(chan bool),
+	}
+}
+
+func (s *RecordingPlatform) Start(pool *sync.Pool) error {
+	if err := s.Platform.Start(pool); err != nil {
+		return err
+	}
+	s.wg.Add(1)
+	go s.forward()
+	return nil
+}
+
+func (s *RecordingPlatform) Stop() {
+	close(s.stopChan)
+	s.wg.Wait()
+	s.Platform.Stop()
+}
+
+func (s *RecordingPlatform) GetSensorEvents() <-chan *u.Trigger {
+	return s.sensorEvents
+}
+
+// forward records each event of the wrapped platform and passes it on.
+func (s *RecordingPlatform) forward() {
+	defer s.wg.Done()
+	for {
+		select {
+		case <-s.stopChan:
+			slog.Info("Ending sensor recording go-routine")
+			return
+		case trigger := <-s.Platform.GetSensorEvents():
+			if err := s.recorder.Record(trigger); err != nil {
+				slog.Error("Failed to record sensor event", "uid", trigger.ID, "error", err)
+			}
+			select {
+			case s.sensorEvents <- trigger:
+			case <-s.stopChan:
+				return
+			}
+		}
+	}
+}
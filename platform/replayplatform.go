@@ -0,0 +1,131 @@
+package platform
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	u "lautenbacher.net/goleds/util"
+)
+
+// ReplayPlatform wraps another Platform and feeds a recorded sequence
+// of sensor events (see util.TriggerRecorder) to the application.
+// Events of the wrapped platform are still passed on, so e.g. the
+// keyboard of the TUI keeps working during a replay.
+//
+// The recording is played back with the given speed factor: 1 is real
+// time, 10 is ten times faster. A speed <= 0 sends all events without
+// any delay. To keep the recorded gaps between the events intact, the
+// playback only begins once Play() is called, i.e. when the application
+// is ready to consume the events.
+type ReplayPlatform struct {
+	Platform
+	records      []u.TriggerRecord
+	speed        float64
+	sensorEvents chan *u.Trigger
+	stopChan     chan bool
+	doneChan     chan bool
+	playChan     chan bool
+	playOnce     sync.Once
+	wg           sync.WaitGroup
+	clock        u.Clock
+}
+
+func NewReplayPlatform(inner Platform, records []u.TriggerRecord, speed float64) *ReplayPlatform {
+	return &ReplayPlatform{
+		Platform:     inner,
+		records:      records,
+		speed:        speed,
+		sensorEvents: make(chan *u.Trigger),
+		stopChan:     make(chan bool),
+		doneChan:     make(chan bool),
+		playChan:     make(chan bool),
+		clock:        u.RealClock{},
+	}
+}
+
+// SetClock sets the clock used to schedule the replayed events.
+func (s *ReplayPlatform) SetClock(clock u.Clock) {
+	s.clock = clock
+}
+
+func (s *ReplayPlatform) Start(pool *sync.Pool) error {
+	if err := s.Platform.Start(pool); err != nil {
+		return err
+	}
+	s.wg.Add(2)
+	go s.forward()
+	go s.replay()
+	return nil
+}
+
+func (s *ReplayPlatform) Stop() {
+	close(s.stopChan)
+	s.wg.Wait()
+	s.Platform.Stop()
+}
+
+func (s *ReplayPlatform) GetSensorEvents() <-chan *u.Trigger {
+	return s.sensorEvents
+}
+
+// Play starts the playback of the recording. It is safe to call it
+// more than once.
+func (s *ReplayPlatform) Play() {
+	s.playOnce.Do(func() { close(s.playChan) })
+}
+
+// Done returns a channel that is closed once the last recorded event
+// has been delivered (or the platform was stopped).
+func (s *ReplayPlatform) Done() <-chan bool {
+	return s.doneChan
+}
+
+// replay sends the recorded events at their (scaled) offsets.
+func (s *ReplayPlatform) replay() {
+	defer s.wg.Done()
+	defer close(s.doneChan)
+
+	select {
+	case <-s.playChan:
+	case <-s.stopChan:
+		return
+	}
+	slog.Info("Starting replay of recorded sensor events", "events", len(s.records), "speed", s.speed)
+	start := s.clock.Now()
+	for _, rec := range s.records {
+		if s.speed > 0 {
+			due := start.Add(time.Duration(float64(rec.Offset) / s.speed))
+			timer := s.clock.NewTimer(due.Sub(s.clock.Now()))
+			select {
+			case <-timer.C():
+			case <-s.stopChan:
+				timer.Stop()
+				return
+			}
+		}
+		select {
+		case s.sensorEvents <- u.NewTrigger(rec.ID, rec.Value, s.clock.Now()):
+		case <-s.stopChan:
+			return
+		}
+	}
+	slog.Info("Replay of recorded sensor events finished")
+}
+
+// forward passes on the events of the wrapped platform.
+func (s *ReplayPlatform) forward() {
+	defer s.wg.Done()
+	for {
+		select {
+		case <-s.stopChan:
+			return
+		case trigger := <-s.Platform.GetSensorEvents():
+			select {
+			case s.sensorEvents <- trigger:
+			case <-s.stopChan:
+				return
+			}
+		}
+	}
+}
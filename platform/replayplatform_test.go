@@ -0,0 +1,194 @@
+package platform
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+
+	p "lautenbacher.net/goleds/producer"
+	u "lautenbacher.net/goleds/util"
+)
+
+// stubPlatform is a minimal Platform used as the wrapped platform in
+// the decorator tests.
+type stubPlatform struct {
+	sensorEvents chan *u.Trigger
+	mu           sync.Mutex
+	setLedsCalls int
+	started      bool
+	stopped      bool
+}
+
+func newStubPlatform() *stubPlatform {
+	return &stubPlatform{sensorEvents: make(chan *u.Trigger)}
+}
+
+func (s *stubPlatform) Start(pool *sync.Pool) error {
+	s.started = true
+	return nil
+}
+
+func (s *stubPlatform) Stop() { s.stopped = true }
+
+func (s *stubPlatform) SetLeds(leds []p.Led) {
+	s.mu.Lock()
+	s.setLedsCalls++
+	s.mu.Unlock()
+}
+
+func (s *stubPlatform) GetSensorEvents() <-chan *u.Trigger  { return s.sensorEvents }
+func (s *stubPlatform) GetSensorLedIndices() map[string]int { return map[string]int{"S0": 0} }
+func (s *stubPlatform) GetLedsTotal() int                   { return 3 }
+func (s *stubPlatform) GetLayout() *p.Layout                { return nil }
+func (s *stubPlatform) GetPositions() *p.Positions          { return nil }
+func (s *stubPlatform) GetForceUpdateDelay() time.Duration  { return 0 }
+func (s *stubPlatform) Ready() <-chan bool {
+	c := make(chan bool)
+	close(c)
+	return c
+}
+
+func receiveTrigger(t *testing.T, events <-chan *u.Trigger) *u.Trigger {
+	t.Helper()
+	select {
+	case trigger := <-events:
+		return trigger
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for sensor event")
+		return nil
+	}
+}
+
+func TestReplayPlatform_ReplaysInOrder(t *testing.T) {
+	inner := newStubPlatform()
+	records := []u.TriggerRecord{
+		{Offset: 0, ID: "S0", Value: 100},
+		{Offset: 20 * time.Millisecond, ID: "S1", Value: 200},
+		{Offset: 40 * time.Millisecond, ID: "S0", Value: 300},
+	}
+	replay := NewReplayPlatform(inner, records, 2)
+	if err := replay.Start(nil); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer replay.Stop()
+	replay.Play()
+
+	start := time.Now()
+	for i, rec := range records {
+		trigger := receiveTrigger(t, replay.GetSensorEvents())
+		if trigger.ID != rec.ID || trigger.Value != rec.Value {
+			t.Errorf("Event %d: expected %s/%d, got %s/%d", i, rec.ID, rec.Value, trigger.ID, trigger.Value)
+		}
+	}
+	// With speed 2 the last event is due after 20ms.
+	if elapsed := time.Since(start); elapsed < 15*time.Millisecond {
+		t.Errorf("Replay was too fast: %v", elapsed)
+	}
+
+	select {
+	case <-replay.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Done channel was not closed after the last event")
+	}
+}
+
+// TestReplayPlatform_KeepsGapsUntilPlay checks that nothing is sent
+// before Play() and that the recorded gaps between the events are kept
+// no matter how long the application took to get ready.
+func TestReplayPlatform_KeepsGapsUntilPlay(t *testing.T) {
+	inner := newStubPlatform()
+	records := []u.TriggerRecord{
+		{Offset: 100 * time.Millisecond, ID: "S0", Value: 100},
+		{Offset: 130 * time.Millisecond, ID: "S1", Value: 200},
+		{Offset: 190 * time.Millisecond, ID: "S0", Value: 300},
+	}
+	clock := u.NewFakeClock(time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC))
+	replay := NewReplayPlatform(inner, records, 1)
+	replay.SetClock(clock)
+	if err := replay.Start(nil); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer replay.Stop()
+
+	// A slow startup of the application must not shift the replay.
+	clock.Advance(time.Hour)
+	select {
+	case trigger := <-replay.GetSensorEvents():
+		t.Fatalf("Event %s sent before Play()", trigger.ID)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	playStart := clock.Now()
+	replay.Play()
+	for i, rec := range records {
+		clock.BlockUntil(1)
+		clock.AdvanceTo(playStart.Add(rec.Offset))
+		trigger := receiveTrigger(t, replay.GetSensorEvents())
+		if offset := trigger.Timestamp.Sub(playStart); trigger.ID != rec.ID || offset != rec.Offset {
+			t.Errorf("Event %d: expected %s at %v, got %s at %v", i, rec.ID, rec.Offset, trigger.ID, offset)
+		}
+	}
+}
+
+func TestReplayPlatform_ForwardsInnerEvents(t *testing.T) {
+	inner := newStubPlatform()
+	replay := NewReplayPlatform(inner, nil, 0)
+	if err := replay.Start(nil); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	go func() { inner.sensorEvents <- u.NewTrigger("S0", 42, time.Now()) }()
+	if trigger := receiveTrigger(t, replay.GetSensorEvents()); trigger.Value != 42 {
+		t.Errorf("Expected forwarded trigger value 42, got %d", trigger.Value)
+	}
+
+	replay.Stop()
+	if !inner.stopped {
+		t.Error("Expected the wrapped platform to be stopped")
+	}
+}
+
+func TestCapturePlatform_CapturesFrames(t *testing.T) {
+	inner := newStubPlatform()
+	capture := NewCapturePlatform(inner)
+
+	frame := []p.Led{{Red: 1}, {Green: 2}, {Blue: 3}}
+	capture.SetLeds(frame)
+	frame[0].Red = 99 // must not change the captured copy
+
+	frames := capture.Frames()
+	if len(frames) != 1 {
+		t.Fatalf("Expected 1 captured frame, got %d", len(frames))
+	}
+	if frames[0].Leds[0].Red != 1 || frames[0].Leds[2].Blue != 3 {
+		t.Errorf("Captured frame has unexpected content: %+v", frames[0])
+	}
+	if inner.setLedsCalls != 1 {
+		t.Errorf("Expected SetLeds to be forwarded once, got %d", inner.setLedsCalls)
+	}
+}
+
+func TestRecordingPlatform_RecordsEvents(t *testing.T) {
+	inner := newStubPlatform()
+	var buf bytes.Buffer
+	start := time.Now()
+	rec := NewRecordingPlatform(inner, u.NewTriggerRecorder(&buf, start))
+	if err := rec.Start(nil); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	go func() { inner.sensorEvents <- u.NewTrigger("S2", 512, start.Add(time.Second)) }()
+	if trigger := receiveTrigger(t, rec.GetSensorEvents()); trigger.ID != "S2" {
+		t.Errorf("Expected forwarded trigger S2, got %s", trigger.ID)
+	}
+	rec.Stop()
+
+	records, err := u.ReadTriggerLog(&buf)
+	if err != nil {
+		t.Fatalf("Failed to read recording: %v", err)
+	}
+	if len(records) != 1 || records[0].ID != "S2" || records[0].Value != 512 || records[0].Offset != time.Second {
+		t.Errorf("Unexpected recording: %+v", records)
+	}
+}
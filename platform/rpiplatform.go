@@ -6,7 +6,6 @@ import (
 	"math"
 	"strings"
 	"sync"
-	"time"
 
 	"github.com/stianeikeland/go-rpio/v4"
 	"lautenbacher.net/goleds/config"
@@ -23,6 +22,7 @@ type RaspberryPiPlatform struct {
 	sensorWg        sync.WaitGroup
 	sensorStopChan  chan bool
 	readyChan       chan bool
+	luxSensor       *bh1750
 }
 
 type gpiocfg struct {
@@ -103,6 +103,12 @@ func (s *RaspberryPiPlatform) Start(pool *sync.Pool) error {
 		return fmt.Errorf("unknown LED type: %s", s.config.Hardware.LEDType)
 	}
 
+	// Started before the other go-routines, which would keep running
+	// if it fails.
+	if err := s.startAmbientSensor(); err != nil {
+		return err
+	}
+
 	if s.sensorViewer != nil {
 		go s.sensorViewer.Start()
 	}
@@ -129,6 +135,12 @@ func (s *RaspberryPiPlatform) Stop() {
 	// Wait for them to finish
 	s.displayWg.Wait()
 	s.sensorWg.Wait()
+	s.stopAmbientLight()
+	if s.luxSensor != nil {
+		if err := s.luxSensor.close(); err != nil {
+			slog.Error("Error closing ambient light sensor", "error", err)
+		}
+	}
 
 	// Now, safely close hardware
 	rpio.SpiEnd(rpio.Spi0)
@@ -260,7 +272,7 @@ func (d *apa102Driver) write(segment *segment, exchangeFunc func(string, []byte)
 
 func (s *RaspberryPiPlatform) sensorDriver() {
 	defer s.sensorWg.Done()
-	ticker := time.NewTicker(s.config.Hardware.Sensors.LoopDelay)
+	ticker := s.clock.NewTicker(s.config.Hardware.Sensors.LoopDelay)
 	defer ticker.Stop()
 
 	latestValues := make(map[string]int)
@@ -270,12 +282,12 @@ func (s *RaspberryPiPlatform) sensorDriver() {
 		case <-s.sensorStopChan:
 			slog.Info("Ending SensorDriver go-routine (RPi)")
 			return
-		case <-ticker.C:
+		case <-ticker.C():
 			for name, sensor := range s.sensors {
 				value := sensor.smoothedValue(s.readAdc(sensor.spimultiplex, sensor.adcChannel))
 				latestValues[name] = value
 				if value > sensor.triggerValue {
-					s.sensorEvents <- util.NewTrigger(name, value, time.Now())
+					s.sensorEvents <- util.NewTrigger(name, value, s.clock.Now())
 				}
 			}
 
@@ -291,3 +303,25 @@ func (s *RaspberryPiPlatform) readAdc(multiplex string, channel byte) int {
 	read := s.spiExchangeMultiplex(multiplex, write)
 	return ((int(read[1]) & 3) << 8) + int(read[2])
 }
+
+// startAmbientSensor starts reading the ambient light sensor, either
+// through the ADC or from a BH1750 on the I2C bus.
+func (s *RaspberryPiPlatform) startAmbientSensor() error {
+	cfg := s.config.Hardware.AmbientLight
+	if !cfg.Enabled {
+		return nil
+	}
+	if strings.EqualFold(cfg.Source, "BH1750") {
+		sensor, err := openBH1750(cfg.I2CBus, cfg.I2CAddress)
+		if err != nil {
+			return err
+		}
+		s.luxSensor = sensor
+		s.startAmbientLight(sensor.readLux)
+		return nil
+	}
+	s.startAmbientLight(func() (float64, error) {
+		return float64(s.readAdc(cfg.SpiMultiplex, cfg.AdcChannel)), nil
+	})
+	return nil
+}
@@ -5,10 +5,10 @@ import (
 	"log/slog"
 	"math"
 	"os"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
-	"time"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
@@ -32,6 +32,8 @@ type TUIPlatform struct {
 	tuiTriggerValue int
 	logFlushOnce    sync.Once
 	readyChan       chan bool
+	ambientMutex    sync.Mutex
+	ambientReading  float64 // simulated ambient light sensor reading
 }
 
 func NewTUIPlatform(conf *config.Config, ossignalchan chan os.Signal) *TUIPlatform {
@@ -64,6 +66,17 @@ func (s *TUIPlatform) Start(pool *sync.Pool) error {
 	s.displayWg.Add(1)
 	go s.displayDriver()
 
+	if s.ambient != nil {
+		// Start with the brightest end of the curve.
+		curve := s.config.Hardware.AmbientLight.Curve
+		s.ambientReading = curve[len(curve)-1][0]
+		s.startAmbientLight(func() (float64, error) {
+			s.ambientMutex.Lock()
+			defer s.ambientMutex.Unlock()
+			return s.ambientReading, nil
+		})
+	}
+
 	return nil
 }
 
@@ -74,6 +87,7 @@ func (s *TUIPlatform) Stop() {
 	close(s.displayStopChan)
 	// Wait for it to confirm it's done
 	s.displayWg.Wait()
+	s.stopAmbientLight()
 
 	if s.tviewapp != nil {
 		s.tviewapp.Stop()
@@ -88,6 +102,12 @@ func (s *TUIPlatform) tuiDisplayFunc(leds []producer.Led) {
 		}
 	}
 	// Queue the update to redraw the LED display pane
+	if s.layout != nil {
+		// The buffer goes back to the pool before the pane is drawn.
+		frame := slices.Clone(leds)
+		s.tviewapp.QueueUpdateDraw(func() { s.simulateMatrixDisplay(frame) })
+		return
+	}
 	s.tviewapp.QueueUpdateDraw(s.simulateLedDisplay)
 }
 
@@ -97,6 +117,13 @@ func (s *TUIPlatform) getIntroText(numSensors int) string {
 
 	line1 := fmt.Sprintf("Trigger value: [#ffff00]%-4d[white] | Hit [#ff0000]+[white]/[#ff0000]-[white] to change", triggerValue)
 	line2 := fmt.Sprintf("Hit [blue]1[-]...[blue]%d[-] to fire a sensor", numSensors)
+	if s.ambient != nil {
+		s.ambientMutex.Lock()
+		reading := s.ambientReading
+		s.ambientMutex.Unlock()
+		line2 += fmt.Sprintf(" | Ambient light: [#ffff00]%.0f[white] (brightness %.2f) | Hit [#ff0000]<[white]/[#ff0000]>[white] to change",
+			reading, curveValue(s.config.Hardware.AmbientLight.Curve, reading))
+	}
 	line3 := "Hit [#ff0000]q[-] to exit, [#ff0000]Up/Down[-] to scroll logs"
 
 	return fmt.Sprintf("%s\n%s\n%s", line1, line2, line3)
@@ -133,6 +160,9 @@ func (s *TUIPlatform) initSimulationTUI(ossignal chan os.Signal, numSensors int,
 
 	// --- Layout ---
 	stripeHeight := 1 + (3 * numSegmentGroups) + 2 // 1 for sensor line, 3 per group, 2 for border
+	if s.layout != nil {
+		stripeHeight = s.layout.Height() + 2 // 1 per row, 2 for border
+	}
 
 	layout := tview.NewFlex().SetDirection(tview.FlexRow).
 		AddItem(s.intro, 5, 0, false). // Increased height for 3 lines of text
@@ -162,7 +192,7 @@ func (s *TUIPlatform) initSimulationTUI(ossignal chan os.Signal, numSensors int,
 				minimum := s.sensors[senuid].triggerValue
 				if currentTriggerValue >= minimum {
 					slog.Debug("Triggering sensor", "uid", senuid, "value", currentTriggerValue)
-					s.sensorEvents <- util.NewTrigger(senuid, currentTriggerValue, time.Now())
+					s.sensorEvents <- util.NewTrigger(senuid, currentTriggerValue, s.clock.Now())
 				} else {
 					slog.Info("Sensor not triggered", "uid", senuid, "value", currentTriggerValue, "minimum", minimum)
 					return nil
@@ -182,6 +212,12 @@ func (s *TUIPlatform) initSimulationTUI(ossignal chan os.Signal, numSensors int,
 				s.tuiTriggerValue = max(s.tuiTriggerValue, 0)
 				s.intro.SetText(s.getIntroText(numSensors))
 				return nil
+			case "<", ">":
+				if s.ambient != nil {
+					s.changeAmbientReading(key == ">")
+					s.intro.SetText(s.getIntroText(numSensors))
+				}
+				return nil
 			}
 		case tcell.KeyUp:
 			row, col := s.logView.GetScrollOffset()
@@ -215,6 +251,20 @@ func (s *TUIPlatform) initSimulationTUI(ossignal chan os.Signal, numSensors int,
 	}()
 }
 
+// changeAmbientReading moves the simulated ambient light reading by a
+// tenth of the configured curve's range.
+func (s *TUIPlatform) changeAmbientReading(brighter bool) {
+	curve := s.config.Hardware.AmbientLight.Curve
+	low, high := curve[0][0], curve[len(curve)-1][0]
+	step := (high - low) / 10
+	if !brighter {
+		step = -step
+	}
+	s.ambientMutex.Lock()
+	s.ambientReading = math.Max(low, math.Min(high, s.ambientReading+step))
+	s.ambientMutex.Unlock()
+}
+
 // simulateLedDisplay redraws the entire LED display pane.
 // This function must be called on the main TUI thread via app.QueueUpdateDraw().
 func (s *TUIPlatform) simulateLedDisplay() {
@@ -244,6 +294,48 @@ func (s *TUIPlatform) simulateLedDisplay() {
 	s.ledDisplay.SetText(buf.String())
 }
 
+// simulateMatrixDisplay redraws the LED display pane as the grid of
+// the matrix layout, two characters per cell. Sensors are shown by
+// their key on dark cells. This function must be called on the main
+// TUI thread via app.QueueUpdateDraw().
+func (s *TUIPlatform) simulateMatrixDisplay(leds []producer.Led) {
+	sensorKeys := make(map[int]string, len(s.chartosensor))
+	for key, uid := range s.chartosensor {
+		sensorKeys[s.sensors[uid].LedIndex] = key
+	}
+	var buf strings.Builder
+	for y := 0; y < s.layout.Height(); y++ {
+		buf.WriteString(" ")
+		for x := 0; x < s.layout.Width(); x++ {
+			index := s.layout.Index(x, y)
+			switch {
+			case index < 0 || index >= len(leds):
+				buf.WriteString(" ·")
+			case leds[index].IsEmpty():
+				if key, ok := sensorKeys[index]; ok {
+					buf.WriteString("[blue]" + key + "[-] ")
+				} else {
+					buf.WriteString("  ")
+				}
+			default:
+				v := leds[index]
+				value := math.Max(v.Red, math.Max(v.Green, v.Blue))
+				char := "█"
+				if value <= 8 {
+					char = "░"
+				} else if value <= 32 {
+					char = "▒"
+				} else if value <= 80 {
+					char = "▓"
+				}
+				buf.WriteString(scaledColor(v) + char + char + "[-]")
+			}
+		}
+		buf.WriteString("\n")
+	}
+	s.ledDisplay.SetText(buf.String())
+}
+
 // simulateLedSegment generates the two-line representation for a single segment.
 func (s *TUIPlatform) simulateLedSegment(segment *segment) (string, string) {
 	if !segment.visible {
@@ -0,0 +1,133 @@
+package platform
+
+import (
+	"sync"
+	"time"
+
+	c "lautenbacher.net/goleds/config"
+	p "lautenbacher.net/goleds/producer"
+	u "lautenbacher.net/goleds/util"
+)
+
+// zoneEventBuffer is the number of sensor events a zone can fall
+// behind before further events are dropped.
+const zoneEventBuffer = 16
+
+// SharedStrip combines the frames of the zones into the frames of the
+// platform they share, so all zones are sent over the same SPI bus.
+type SharedStrip struct {
+	platform Platform
+	pool     *sync.Pool
+	mutex    sync.Mutex
+	frame    []p.Led
+}
+
+// NewSharedStrip creates a SharedStrip on platform, which must be
+// started with pool.
+func NewSharedStrip(platform Platform, pool *sync.Pool) *SharedStrip {
+	return &SharedStrip{
+		platform: platform,
+		pool:     pool,
+		frame:    make([]p.Led, platform.GetLedsTotal()),
+	}
+}
+
+// NewZone creates the platform of a zone with the configuration conf
+// (see config.Config.Zone) on the LEDs leds of the shared strip.
+func (s *SharedStrip) NewZone(conf *c.Config, leds []int) *ZonePlatform {
+	indices := make(map[string]int)
+	for uid, sensorCfg := range conf.Hardware.Sensors.SensorCfg {
+		indices[uid] = sensorCfg.LedIndex
+	}
+	ready := make(chan bool)
+	close(ready)
+	return &ZonePlatform{
+		strip:        s,
+		leds:         leds,
+		indices:      indices,
+		sensorEvents: make(chan *u.Trigger, zoneEventBuffer),
+		ready:        ready,
+		layout:       p.NewLayout(conf.Hardware.Display.Layout, len(leds)),
+		positions:    p.NewPositions(conf.Hardware.Display.Positions, len(leds)),
+	}
+}
+
+// set copies the zone frame leds onto the LEDs indices of the shared
+// frame and sends the result to the shared platform.
+func (s *SharedStrip) set(indices []int, leds []p.Led) {
+	s.mutex.Lock()
+	for k, i := range indices {
+		s.frame[i] = leds[k]
+	}
+	frame := s.pool.Get().([]p.Led)
+	copy(frame, s.frame)
+	s.mutex.Unlock()
+	s.platform.SetLeds(frame)
+}
+
+// ZonePlatform is the Platform of a zone, i.e. a part of a SharedStrip.
+// The sensor events of the zone are sent to it with SendSensorEvent,
+// the shared platform itself is started and stopped by its owner.
+type ZonePlatform struct {
+	strip        *SharedStrip
+	leds         []int // LED of the shared strip for every LED of the zone
+	indices      map[string]int
+	pool         *sync.Pool
+	sensorEvents chan *u.Trigger
+	ready        chan bool
+	layout       *p.Layout
+	positions    *p.Positions
+}
+
+func (s *ZonePlatform) Start(pool *sync.Pool) error {
+	s.pool = pool
+	return nil
+}
+
+func (s *ZonePlatform) Stop() {}
+
+func (s *ZonePlatform) SetLeds(leds []p.Led) {
+	s.strip.set(s.leds, leds)
+	s.pool.Put(leds)
+}
+
+// SendSensorEvent hands trigger to the zone without blocking, so a busy
+// zone doesn't hold up the others. It returns false if the zone is too
+// far behind and the event is dropped; a sensor keeps firing as long
+// as it is triggered.
+func (s *ZonePlatform) SendSensorEvent(trigger *u.Trigger) bool {
+	select {
+	case s.sensorEvents <- trigger:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *ZonePlatform) GetSensorEvents() <-chan *u.Trigger {
+	return s.sensorEvents
+}
+
+func (s *ZonePlatform) GetSensorLedIndices() map[string]int {
+	return s.indices
+}
+
+func (s *ZonePlatform) GetLedsTotal() int {
+	return len(s.leds)
+}
+
+func (s *ZonePlatform) GetLayout() *p.Layout {
+	return s.layout
+}
+
+func (s *ZonePlatform) GetPositions() *p.Positions {
+	return s.positions
+}
+
+func (s *ZonePlatform) GetForceUpdateDelay() time.Duration {
+	return s.strip.platform.GetForceUpdateDelay()
+}
+
+func (s *ZonePlatform) Ready() <-chan bool {
+	return s.ready
+}
@@ -0,0 +1,77 @@
+package platform
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	c "lautenbacher.net/goleds/config"
+	p "lautenbacher.net/goleds/producer"
+	u "lautenbacher.net/goleds/util"
+)
+
+// framePlatform is a stubPlatform with 6 LEDs keeping the frames sent
+// to it.
+type framePlatform struct {
+	*stubPlatform
+	frames [][]p.Led
+}
+
+func (s *framePlatform) GetLedsTotal() int { return 6 }
+
+func (s *framePlatform) SetLeds(leds []p.Led) {
+	s.frames = append(s.frames, leds)
+}
+
+func TestZonePlatform(t *testing.T) {
+	shared := &framePlatform{stubPlatform: newStubPlatform()}
+	sharedPool := &sync.Pool{New: func() any { return make([]p.Led, 6) }}
+	strip := NewSharedStrip(shared, sharedPool)
+
+	conf := &c.Config{}
+	conf.Hardware.Sensors.SensorCfg = map[string]c.SensorCfg{"S1": {LedIndex: 2}}
+	zoneA := strip.NewZone(&c.Config{}, []int{0, 1})
+	zoneB := strip.NewZone(conf, []int{5, 4, 3})
+	pool := &sync.Pool{New: func() any { return make([]p.Led, 3) }}
+	if err := zoneA.Start(pool); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if err := zoneB.Start(pool); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	<-zoneB.Ready()
+	if n := zoneB.GetLedsTotal(); n != 3 {
+		t.Errorf("Expected 3 LEDs in the zone, got %d", n)
+	}
+	if indices := zoneB.GetSensorLedIndices(); !reflect.DeepEqual(indices, map[string]int{"S1": 2}) {
+		t.Errorf("Expected S1 at LED 2 of the zone, got %v", indices)
+	}
+
+	red, green := p.Led{Red: 10}, p.Led{Green: 10}
+	zoneA.SetLeds([]p.Led{red, red})
+	zoneB.SetLeds([]p.Led{green, {}, green})
+	// Each frame has the latest LEDs of all zones, zone B reversed.
+	expected := [][]p.Led{{red, red, {}, {}, {}, {}}, {red, red, {}, green, {}, green}}
+	if !reflect.DeepEqual(shared.frames, expected) {
+		t.Errorf("Expected frames %v, got %v", expected, shared.frames)
+	}
+
+	// A busy zone doesn't block the sender, it drops the events it
+	// can't take any more.
+	trigger := u.NewTrigger("S1", 300, time.Now())
+	for i := range zoneEventBuffer {
+		if !zoneB.SendSensorEvent(trigger) {
+			t.Fatalf("Event %d was dropped, expected it to be buffered", i)
+		}
+	}
+	if zoneB.SendSensorEvent(trigger) {
+		t.Error("Expected an event beyond the buffer to be dropped")
+	}
+	if received := receiveTrigger(t, zoneB.GetSensorEvents()); received != trigger {
+		t.Errorf("Expected the sent trigger, got %v", received)
+	}
+	if !zoneA.SendSensorEvent(trigger) {
+		t.Error("Expected the other zone to take the event")
+	}
+}
@@ -25,6 +25,8 @@ type AbstractProducer struct {
 	triggerEvent *u.AtomicEvent[*u.Trigger]
 	endWg        *sync.WaitGroup
 	runfunc      func()
+	clock        u.Clock
+	brightness   float64
 }
 
 // Creates a new instance of AbstractProducer. The uid must be unique
@@ -37,10 +39,17 @@ func NewAbstractProducer(uid string, ledsChanged *u.AtomicMapEvent[LedProducer],
 		runfunc:      runfunc,
 		triggerEvent: u.NewAtomicEvent[*u.Trigger](),
 		endWg:        nil,
+		clock:        u.RealClock{},
+		brightness:   1,
 	}
 	return &inst
 }
 
+// SetClock sets the clock used by the producer's runner.
+func (s *AbstractProducer) SetClock(clock u.Clock) {
+	s.clock = clock
+}
+
 // Sets a single LED at index index to value
 func (s *AbstractProducer) setLed(index int, value Led) {
 	s.ledsMutex.Lock()
@@ -49,11 +58,26 @@ func (s *AbstractProducer) setLed(index int, value Led) {
 	s.ledsChanged.Send(s.GetUID(), s)
 }
 
-// GetLeds copies the current LED state into the provided buffer.
+// GetLeds copies the current LED state into the provided buffer,
+// scaled by the brightness factor.
 func (s *AbstractProducer) GetLeds(buffer []Led) {
 	s.ledsMutex.RLock()
 	defer s.ledsMutex.RUnlock()
 	copy(buffer, s.leds)
+	if s.brightness != 1 {
+		ScaleLeds(buffer, s.brightness)
+	}
+}
+
+// SetBrightness sets the factor (0..1) applied to the LEDs in GetLeds.
+func (s *AbstractProducer) SetBrightness(factor float64) {
+	s.ledsMutex.Lock()
+	changed := s.brightness != factor
+	s.brightness = factor
+	s.ledsMutex.Unlock()
+	if changed {
+		s.ledsChanged.Send(s.GetUID(), s)
+	}
 }
 
 // The UID of the controller. Must be globally unique
@@ -138,6 +162,8 @@ func (s *AbstractProducer) TryStop() (bool, error) {
 		return false, nil
 	}
 
+	// The timeout guards against a hanging producer and is therefore
+	// always measured in real time, not with the producer's clock.
 	select {
 	case s.stopchan <- true:
 		return true, nil
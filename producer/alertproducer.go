@@ -0,0 +1,253 @@
+// This producer shows alerts of external events (doorbell, washing
+// machine done, ...) above all other producers. Alerts wait in a queue
+// ordered by priority, an alert with a higher priority interrupts the
+// current one, which continues afterwards. The producer runs
+// independently of the state manager: it is started once and lights
+// LEDs only while an alert is shown, so the other producers come back
+// as soon as it is done.
+
+package producer
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	c "lautenbacher.net/goleds/config"
+	u "lautenbacher.net/goleds/util"
+)
+
+// ErrAlertQueueFull is returned by Enqueue if MaxQueue alerts wait.
+var ErrAlertQueueFull = errors.New("alert queue is full")
+
+// Alert is a notification shown by the AlertProducer. Zero values of
+// LedRGB, Repeat and Period are replaced by the defaults of the
+// AlertLED config.
+type Alert struct {
+	// Pattern is Blink (the default), Pulse, Chase or Solid.
+	Pattern string
+	LedRGB  []float64
+	// Repeat is the number of cycles of the pattern, each Period long.
+	Repeat int
+	Period time.Duration
+	// Alerts with a higher Priority are shown first.
+	Priority int
+}
+
+// alertPattern returns the brightness factor of LED index at phase
+// (0..1) of a cycle.
+type alertPattern func(phase float64, index, ledsTotal int) float64
+
+var alertPatterns = map[string]alertPattern{
+	"BLINK": func(phase float64, _, _ int) float64 {
+		if phase < 0.5 {
+			return 1
+		}
+		return 0
+	},
+	"PULSE": func(phase float64, _, _ int) float64 {
+		return 0.5 - 0.5*math.Cos(2*math.Pi*phase)
+	},
+	"CHASE": func(phase float64, index, ledsTotal int) float64 {
+		// A block of a fifth of the LEDs runs over the strip.
+		width := max(ledsTotal/5, 1)
+		head := phase * float64(ledsTotal+width)
+		if float64(index) <= head && float64(index) > head-float64(width) {
+			return 1
+		}
+		return 0
+	},
+	"SOLID": func(float64, int, int) float64 { return 1 },
+}
+
+type queuedAlert struct {
+	Alert
+	pattern alertPattern
+	seq     uint64
+	done    int // completed cycles before an interruption
+}
+
+type AlertProducer struct {
+	*AbstractProducer
+	defaults   c.AlertLEDConfig
+	queueMutex sync.Mutex
+	queue      []*queuedAlert // by priority, then in order of arrival
+	active     *queuedAlert
+	cleared    bool
+	seq        uint64
+	changed    *u.AtomicEvent[struct{}]
+}
+
+func NewAlertProducer(uid string, ledsChanged *u.AtomicMapEvent[LedProducer], ledsTotal int, cfg c.AlertLEDConfig) *AlertProducer {
+	inst := &AlertProducer{
+		defaults: cfg,
+		changed:  u.NewAtomicEvent[struct{}](),
+	}
+	inst.AbstractProducer = NewAbstractProducer(uid, ledsChanged, inst.runner, ledsTotal)
+	return inst
+}
+
+// Enqueue adds an alert to the queue after filling in the defaults.
+func (s *AlertProducer) Enqueue(alert Alert) error {
+	if alert.Pattern == "" {
+		alert.Pattern = "Blink"
+	}
+	pattern, ok := alertPatterns[strings.ToUpper(alert.Pattern)]
+	if !ok {
+		return fmt.Errorf("unknown Pattern %q, must be Blink, Pulse, Chase or Solid", alert.Pattern)
+	}
+	if alert.LedRGB == nil {
+		alert.LedRGB = s.defaults.LedRGB
+	}
+	if len(alert.LedRGB) != 3 {
+		return fmt.Errorf("LedRGB must have exactly 3 components")
+	}
+	for _, v := range alert.LedRGB {
+		if v < 0 || v > 255 {
+			return fmt.Errorf("LedRGB components must be between 0 and 255")
+		}
+	}
+	if alert.Repeat < 0 {
+		return fmt.Errorf("Repeat must not be negative")
+	}
+	if alert.Repeat == 0 {
+		alert.Repeat = s.defaults.Repeat
+	}
+	if alert.Period == 0 {
+		alert.Period = s.defaults.Period
+	}
+	if alert.Period < s.defaults.Delay {
+		return fmt.Errorf("Period must not be shorter than %s", s.defaults.Delay)
+	}
+
+	s.queueMutex.Lock()
+	if len(s.queue) >= s.defaults.MaxQueue {
+		s.queueMutex.Unlock()
+		return ErrAlertQueueFull
+	}
+	s.seq++
+	s.insertLocked(&queuedAlert{Alert: alert, pattern: pattern, seq: s.seq})
+	s.queueMutex.Unlock()
+	s.changed.Send(struct{}{})
+	return nil
+}
+
+// insertLocked adds alert to the queue behind all alerts of at least
+// its priority that arrived before it.
+func (s *AlertProducer) insertLocked(alert *queuedAlert) {
+	i, _ := slices.BinarySearchFunc(s.queue, alert, func(a, b *queuedAlert) int {
+		if a.Priority != b.Priority {
+			return b.Priority - a.Priority
+		}
+		return int(a.seq) - int(b.seq)
+	})
+	s.queue = slices.Insert(s.queue, i, alert)
+}
+
+// Pending returns the alert being shown and the waiting ones in the
+// order they will be shown.
+func (s *AlertProducer) Pending() []Alert {
+	s.queueMutex.Lock()
+	defer s.queueMutex.Unlock()
+	pending := make([]Alert, 0, len(s.queue)+1)
+	if s.active != nil {
+		pending = append(pending, s.active.Alert)
+	}
+	for _, alert := range s.queue {
+		pending = append(pending, alert.Alert)
+	}
+	return pending
+}
+
+// Clear removes all waiting alerts and ends the one being shown.
+func (s *AlertProducer) Clear() {
+	s.queueMutex.Lock()
+	s.queue = nil
+	s.cleared = true
+	s.queueMutex.Unlock()
+	s.changed.Send(struct{}{})
+}
+
+// advance decides which alert is shown at now: the current one ends
+// after its cycles or is put back into the queue if an alert of a
+// higher priority waits. started is when the returned alert (or its
+// continuation) began.
+func (s *AlertProducer) advance(current *queuedAlert, started, now time.Time) (*queuedAlert, time.Time) {
+	s.queueMutex.Lock()
+	defer s.queueMutex.Unlock()
+	if s.cleared {
+		current, s.cleared = nil, false
+	}
+	if current != nil {
+		cycles := int(now.Sub(started) / current.Period)
+		if current.done+cycles >= current.Repeat {
+			current = nil
+		} else if len(s.queue) > 0 && s.queue[0].Priority > current.Priority {
+			current.done += cycles
+			s.insertLocked(current)
+			current = nil
+		}
+	}
+	if current == nil && len(s.queue) > 0 {
+		current, s.queue = s.queue[0], s.queue[1:]
+		started = now
+	}
+	s.active = current
+	return current, started
+}
+
+// draw sets the LEDs to the pattern of alert after elapsed.
+func (s *AlertProducer) draw(alert *queuedAlert, elapsed time.Duration) {
+	phase := float64(elapsed%alert.Period) / float64(alert.Period)
+	color := Led{alert.LedRGB[0], alert.LedRGB[1], alert.LedRGB[2]}
+	s.ledsMutex.Lock()
+	defer s.ledsMutex.Unlock()
+	for i := range s.leds {
+		f := alert.pattern(phase, i, len(s.leds))
+		s.leds[i] = Led{color.Red * f, color.Green * f, color.Blue * f}
+	}
+}
+
+func (s *AlertProducer) runner() {
+	defer func() {
+		s.ledsMutex.Lock()
+		clear(s.leds) // Reset LEDs
+		s.ledsMutex.Unlock()
+		s.ledsChanged.Send(s.GetUID(), s)
+	}()
+
+	var current *queuedAlert
+	var started time.Time
+	// A nil channel blocks forever: no steps without an alert.
+	var step <-chan time.Time
+	for {
+		stepped := false
+		select {
+		case <-s.changed.Channel():
+			// A new alert may interrupt the current one.
+		case <-step:
+			stepped = true
+		case <-s.stopchan:
+			return
+		}
+		now := s.clock.Now()
+		previous := current
+		current, started = s.advance(current, started, now)
+		if current == nil {
+			s.ledsMutex.Lock()
+			clear(s.leds)
+			s.ledsMutex.Unlock()
+			step = nil
+		} else {
+			s.draw(current, now.Sub(started))
+			if stepped || current != previous {
+				step = s.clock.After(s.defaults.Delay)
+			}
+		}
+		s.ledsChanged.Send(s.GetUID(), s)
+	}
+}
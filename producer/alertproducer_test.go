@@ -0,0 +1,133 @@
+package producer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	c "lautenbacher.net/goleds/config"
+	u "lautenbacher.net/goleds/util"
+)
+
+var alertConfig = c.AlertLEDConfig{
+	Enabled:  true,
+	Delay:    100 * time.Millisecond,
+	Period:   time.Second,
+	Repeat:   2,
+	LedRGB:   []float64{255, 0, 0},
+	MaxQueue: 3,
+}
+
+func TestAlertProducer_Enqueue(t *testing.T) {
+	p := NewAlertProducer("alert", u.NewAtomicMapEvent[LedProducer](), 10, alertConfig)
+
+	require.NoError(t, p.Enqueue(Alert{}))
+	require.NoError(t, p.Enqueue(Alert{Pattern: "pulse", LedRGB: []float64{0, 0, 255}, Repeat: 5, Period: 2 * time.Second, Priority: 1}))
+	require.NoError(t, p.Enqueue(Alert{Pattern: "Solid", Priority: 1}))
+	assert.ErrorIs(t, p.Enqueue(Alert{}), ErrAlertQueueFull)
+
+	// Higher priorities first, the defaults are filled in.
+	assert.Equal(t, []Alert{
+		{Pattern: "pulse", LedRGB: []float64{0, 0, 255}, Repeat: 5, Period: 2 * time.Second, Priority: 1},
+		{Pattern: "Solid", LedRGB: []float64{255, 0, 0}, Repeat: 2, Period: time.Second, Priority: 1},
+		{Pattern: "Blink", LedRGB: []float64{255, 0, 0}, Repeat: 2, Period: time.Second},
+	}, p.Pending())
+
+	p.Clear()
+	assert.Empty(t, p.Pending())
+
+	tests := map[string]struct {
+		alert    Alert
+		expected string
+	}{
+		"unknown pattern": {Alert{Pattern: "Strobe"}, "unknown Pattern"},
+		"short colour":    {Alert{LedRGB: []float64{1, 2}}, "exactly 3 components"},
+		"invalid colour":  {Alert{LedRGB: []float64{1, 2, 300}}, "between 0 and 255"},
+		"negative repeat": {Alert{Repeat: -1}, "Repeat must not be negative"},
+		"short period":    {Alert{Period: time.Millisecond}, "Period must not be shorter than 100ms"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := p.Enqueue(tc.alert)
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.expected)
+			}
+		})
+	}
+}
+
+func TestAlertProducer_patterns(t *testing.T) {
+	assert.Equal(t, 1.0, alertPatterns["BLINK"](0.2, 0, 10))
+	assert.Equal(t, 0.0, alertPatterns["BLINK"](0.7, 0, 10))
+	assert.InDelta(t, 0.0, alertPatterns["PULSE"](0, 0, 10), 1e-9)
+	assert.InDelta(t, 1.0, alertPatterns["PULSE"](0.5, 0, 10), 1e-9)
+	assert.Equal(t, 1.0, alertPatterns["SOLID"](0.9, 3, 10))
+
+	// The chase block of 2 LEDs is at LEDs 5 and 6 halfway through.
+	var lit []int
+	for i := range 10 {
+		if alertPatterns["CHASE"](0.5, i, 10) > 0 {
+			lit = append(lit, i)
+		}
+	}
+	assert.Equal(t, []int{5, 6}, lit)
+}
+
+func TestAlertProducer_advance(t *testing.T) {
+	p := NewAlertProducer("alert", u.NewAtomicMapEvent[LedProducer](), 10, alertConfig)
+	start := time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC)
+
+	require.NoError(t, p.Enqueue(Alert{Pattern: "Solid", Repeat: 3}))
+	current, started := p.advance(nil, time.Time{}, start)
+	require.NotNil(t, current)
+	assert.Equal(t, "Solid", current.Pattern)
+
+	// A higher priority interrupts after one and a half cycles.
+	require.NoError(t, p.Enqueue(Alert{Pattern: "Blink", Repeat: 1, Priority: 5}))
+	now := start.Add(1500 * time.Millisecond)
+	current, started = p.advance(current, started, now)
+	assert.Equal(t, "Blink", current.Pattern)
+	assert.Equal(t, now, started)
+	assert.Equal(t, []Alert{current.Alert, {Pattern: "Solid", LedRGB: alertConfig.LedRGB, Repeat: 3, Period: time.Second}}, p.Pending())
+
+	// The interrupted alert continues with its remaining two cycles.
+	now = now.Add(time.Second)
+	current, started = p.advance(current, started, now)
+	assert.Equal(t, "Solid", current.Pattern)
+	current, _ = p.advance(current, started, now.Add(1900*time.Millisecond))
+	assert.NotNil(t, current)
+	current, _ = p.advance(current, started, now.Add(2*time.Second))
+	assert.Nil(t, current)
+	assert.Empty(t, p.Pending())
+}
+
+func TestAlertProducer_Runner(t *testing.T) {
+	clock := u.NewFakeClock(time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC))
+	p := NewAlertProducer("alert", u.NewAtomicMapEvent[LedProducer](), 4, alertConfig)
+	p.SetClock(clock)
+	p.Start()
+	defer p.Exit()
+
+	leds := make([]Led, 4)
+	firstLed := func() Led {
+		t.Helper()
+		clock.BlockUntil(1)
+		p.GetLeds(leds)
+		return leds[0]
+	}
+
+	require.NoError(t, p.Enqueue(Alert{Repeat: 1}))
+	assert.Equal(t, Led{Red: 255}, firstLed())
+	clock.Advance(500 * time.Millisecond)
+	assert.Equal(t, Led{}, firstLed(), "second half of the blink")
+	clock.Advance(400 * time.Millisecond)
+	assert.Equal(t, Led{}, firstLed())
+
+	// After the alert the producer waits without a timer.
+	clock.Advance(100 * time.Millisecond)
+	assert.Eventually(t, func() bool { return len(p.Pending()) == 0 }, time.Second, time.Millisecond)
+	assert.Equal(t, 0, clock.Waiters())
+	p.GetLeds(leds)
+	assert.Equal(t, make([]Led, 4), leds)
+}
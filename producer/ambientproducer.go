@@ -0,0 +1,170 @@
+// The ambient producers draw procedural effects (fire, candles,
+// twinkling stars, noise and breathing) on a range of LEDs. They share
+// the runner below and differ in their ambientEffect. They run
+// permanently or, with AfterSensor, for a while after SensorLED like
+// the CylonLED.
+
+package producer
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	c "lautenbacher.net/goleds/config"
+	u "lautenbacher.net/goleds/util"
+)
+
+// ambientEffect computes the frames of an ambient producer.
+type ambientEffect interface {
+	// step draws the next frame, frame[0] is the StartLed. elapsed is
+	// the time since the producer started and dt the time since the
+	// last step.
+	step(frame []Led, rng *rand.Rand, elapsed, dt time.Duration)
+}
+
+// layoutEffect is implemented by effects that draw in x/y coordinates
+// when the LEDs have a matrix layout.
+type layoutEffect interface {
+	// setCells gives the cell of every LED of the frame, inMatrix is
+	// false for LEDs outside the matrix.
+	setCells(cells []Cell, inMatrix []bool)
+}
+
+// pathEffect is implemented by effects that follow the physical
+// distance along the strip when the LEDs have positions.
+type pathEffect interface {
+	// setPath gives the distance of every LED of the frame along the
+	// strip in metres.
+	setPath(path []float64)
+}
+
+// statefulEffect is implemented by effects keeping state from frame to
+// frame, which is dropped before every run.
+type statefulEffect interface {
+	reset()
+}
+
+type AmbientProducer struct {
+	*AbstractProducer
+	cfg    c.AmbientConfig
+	effect ambientEffect
+	rng    *rand.Rand
+	frame  []Led
+}
+
+func newAmbientProducer(uid string, ledsChanged *u.AtomicMapEvent[LedProducer], ledsTotal int, cfg c.AmbientConfig, effect ambientEffect, endwg *sync.WaitGroup) *AmbientProducer {
+	length := cfg.EndLed - cfg.StartLed
+	inst := &AmbientProducer{
+		cfg:    cfg,
+		effect: effect,
+		frame:  make([]Led, max(length, -length)+1),
+	}
+	inst.reset()
+	inst.AbstractProducer = NewAbstractProducer(uid, ledsChanged, inst.runner, ledsTotal)
+	if endwg != nil {
+		inst.AbstractProducer.endWg = endwg
+	}
+	return inst
+}
+
+// SetLayout lets the effect draw in x/y coordinates of layout if it
+// supports it. layout may be nil. It must be called before Start().
+func (s *AmbientProducer) SetLayout(layout *Layout) {
+	effect, ok := s.effect.(layoutEffect)
+	if !ok || layout == nil {
+		return
+	}
+	direction := 1
+	if s.cfg.EndLed < s.cfg.StartLed {
+		direction = -1
+	}
+	cells := make([]Cell, len(s.frame))
+	inMatrix := make([]bool, len(s.frame))
+	for k := range s.frame {
+		cells[k], inMatrix[k] = layout.Position(s.cfg.StartLed + k*direction)
+	}
+	effect.setCells(cells, inMatrix)
+}
+
+// SetPositions lets the effect follow the physical distance along the
+// strip if it supports it. positions may be nil. It must be called
+// before Start().
+func (s *AmbientProducer) SetPositions(positions *Positions) {
+	effect, ok := s.effect.(pathEffect)
+	if !ok || positions == nil {
+		return
+	}
+	direction := 1
+	if s.cfg.EndLed < s.cfg.StartLed {
+		direction = -1
+	}
+	path := make([]float64, len(s.frame))
+	for k := range s.frame {
+		path[k] = positions.Path(s.cfg.StartLed + k*direction)
+	}
+	effect.setPath(path)
+}
+
+// reset starts the effect afresh, so with a Seed every run shows the
+// same frames.
+func (s *AmbientProducer) reset() {
+	seed := s.cfg.Seed
+	if seed == 0 {
+		seed = uint64(time.Now().UnixNano())
+	}
+	s.rng = rand.New(rand.NewPCG(seed, 0))
+	clear(s.frame)
+	if effect, ok := s.effect.(statefulEffect); ok {
+		effect.reset()
+	}
+}
+
+// draw copies the frame to the LEDs between StartLed and EndLed.
+func (s *AmbientProducer) draw() {
+	direction := 1
+	if s.cfg.EndLed < s.cfg.StartLed {
+		direction = -1
+	}
+	s.ledsMutex.Lock()
+	defer s.ledsMutex.Unlock()
+	for k, led := range s.frame {
+		s.leds[s.cfg.StartLed+k*direction] = led
+	}
+}
+
+func (s *AmbientProducer) runner() {
+	tick := s.clock.NewTicker(s.cfg.Delay)
+	// A nil channel blocks forever: permanent producers run until
+	// they are stopped.
+	var end <-chan time.Time
+	if s.cfg.AfterSensor {
+		end = s.clock.After(s.cfg.Duration)
+	}
+	defer func() {
+		s.ledsMutex.Lock()
+		clear(s.leds) // Reset LEDs
+		s.ledsMutex.Unlock()
+		s.ledsChanged.Send(s.GetUID(), s)
+		tick.Stop()
+	}()
+
+	s.reset()
+
+	start := s.clock.Now()
+	last := start
+	for {
+		select {
+		case <-end:
+			return
+		case <-s.stopchan:
+			return
+		case <-tick.C():
+			now := s.clock.Now()
+			s.effect.step(s.frame, s.rng, now.Sub(start), now.Sub(last))
+			last = now
+			s.draw()
+			s.ledsChanged.Send(s.GetUID(), s)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package producer
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	c "lautenbacher.net/goleds/config"
+	u "lautenbacher.net/goleds/util"
+)
+
+// stepAmbient runs n steps of Delay and returns the last frame.
+func stepAmbient(p *AmbientProducer, n int) []Led {
+	for range n {
+		p.effect.step(p.frame, p.rng, 0, p.cfg.Delay)
+	}
+	return p.frame
+}
+
+var fireConfig = c.FireLEDConfig{
+	AmbientConfig: c.AmbientConfig{StartLed: 0, EndLed: 19, Delay: 50 * time.Millisecond, Seed: 42},
+	Cooling:       0.1,
+	Sparking:      0.8,
+	Colors:        [][]float64{{0, 0, 0, 0}, {0.5, 255, 0, 0}, {1, 255, 255, 0}},
+}
+
+func TestAmbientProducer_Seed(t *testing.T) {
+	newFire := func(seed uint64) *AmbientProducer {
+		cfg := fireConfig
+		cfg.Seed = seed
+		return NewFireProducer("fire", u.NewAtomicMapEvent[LedProducer](), 20, cfg, nil)
+	}
+	// The same seed gives the same frames, another seed other ones.
+	frame := stepAmbient(newFire(42), 50)
+	assert.Equal(t, frame, stepAmbient(newFire(42), 50))
+	assert.NotEqual(t, frame, stepAmbient(newFire(7), 50))
+}
+
+func TestAmbientProducer_SeedEveryRun(t *testing.T) {
+	twinkle := c.TwinkleLEDConfig{
+		AmbientConfig: c.AmbientConfig{StartLed: 0, EndLed: 19, Delay: 50 * time.Millisecond, Seed: 3},
+		Colors:        [][]float64{{255, 255, 255}},
+		Density:       0.2,
+		Lifetime:      time.Second,
+	}
+	// Every run starts afresh, so AfterSensor runs repeat as well.
+	for name, p := range map[string]*AmbientProducer{
+		"fire":    NewFireProducer("fire", u.NewAtomicMapEvent[LedProducer](), 20, fireConfig, nil),
+		"twinkle": NewTwinkleProducer("twinkle", u.NewAtomicMapEvent[LedProducer](), 20, twinkle, nil),
+	} {
+		first := append([]Led(nil), stepAmbient(p, 50)...)
+		p.reset()
+		assert.Equal(t, first, stepAmbient(p, 50), name)
+	}
+}
+
+func TestAmbientProducer_Runner(t *testing.T) {
+	clock := u.NewFakeClock(time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC))
+	cfg := c.BreathingLEDConfig{
+		AmbientConfig: c.AmbientConfig{StartLed: 7, EndLed: 3, Delay: 100 * time.Millisecond,
+			AfterSensor: true, Duration: time.Second},
+		LedRGB: []float64{0, 0, 200},
+		Period: 2 * time.Second,
+	}
+	var wg sync.WaitGroup
+	p := NewBreathingProducer("breathing", u.NewAtomicMapEvent[LedProducer](), 10, cfg, &wg)
+	p.SetClock(clock)
+	p.Start()
+	defer p.Exit()
+
+	// The reversed range from LED 7 down to LED 3 breathes.
+	leds := make([]Led, 10)
+	clock.BlockUntil(2)
+	clock.Advance(500 * time.Millisecond)
+	assert.Eventually(t, func() bool {
+		p.GetLeds(leds)
+		return leds[3].Blue > 0
+	}, time.Second, time.Millisecond)
+	for i, led := range leds {
+		if i < 3 || i > 7 {
+			assert.Equal(t, Led{}, led, "LED %d", i)
+		} else {
+			assert.Equal(t, leds[3], led, "LED %d", i)
+		}
+	}
+
+	// With AfterSensor the producer ends after Duration.
+	clock.Advance(500 * time.Millisecond)
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("The producer didn't end after Duration")
+	}
+	p.GetLeds(leds)
+	assert.Equal(t, make([]Led, 10), leds)
+}
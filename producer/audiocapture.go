@@ -0,0 +1,217 @@
+package producer
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	c "lautenbacher.net/goleds/config"
+	u "lautenbacher.net/goleds/util"
+)
+
+// AudioFrame is one buffer read from the audio input.
+type AudioFrame struct {
+	Left  []float32
+	Right []float32
+	Time  time.Time
+}
+
+// BeatSource delivers the beats of the audio input. Subscribers only
+// see the latest beat.
+type BeatSource interface {
+	SubscribeBeats(uid string) *u.AtomicEvent[Beat]
+	Unsubscribe(uid string)
+}
+
+// AudioCapture owns the single stream of the audio source configured in
+// AudioLEDConfig. It is read while there are subscribers, the buffers
+// and the beats found in them are passed on to all subscribers. After
+// 10 seconds of silence the source is closed and only checked every 5
+// seconds.
+type AudioCapture struct {
+	sampleRate      int
+	framesPerBuffer int
+	beatCfg         c.BeatConfig
+	open            func() (AudioSource, error)
+	clock           u.Clock
+	runMutex        sync.Mutex // protects running, closed and stopChan
+	running         bool
+	closed          bool
+	stopChan        chan bool
+	wg              sync.WaitGroup
+	subMutex        sync.Mutex
+	frameSubs       map[string]*u.AtomicEvent[AudioFrame]
+	beatSubs        map[string]*u.AtomicEvent[Beat]
+}
+
+// NewAudioCapture creates the AudioCapture for cfg, the stream is only
+// opened with the first subscriber.
+func NewAudioCapture(cfg c.AudioLEDConfig) *AudioCapture {
+	inst := &AudioCapture{
+		sampleRate:      cfg.SampleRate,
+		framesPerBuffer: cfg.FramesPerBuffer,
+		beatCfg:         cfg.Beat,
+		clock:           u.RealClock{},
+		frameSubs:       make(map[string]*u.AtomicEvent[AudioFrame]),
+		beatSubs:        make(map[string]*u.AtomicEvent[Beat]),
+	}
+	inst.open = func() (AudioSource, error) {
+		return NewAudioSource(cfg, inst.clock)
+	}
+	return inst
+}
+
+// SetClock sets the clock used for the time stamps and the silence
+// detection.
+func (s *AudioCapture) SetClock(clock u.Clock) {
+	s.clock = clock
+}
+
+// SubscribeFrames returns the event receiving every buffer read.
+func (s *AudioCapture) SubscribeFrames(uid string) *u.AtomicEvent[AudioFrame] {
+	event := u.NewAtomicEvent[AudioFrame]()
+	s.subscribe(func() { s.frameSubs[uid] = event })
+	return event
+}
+
+// SubscribeBeats returns the event receiving the detected beats.
+func (s *AudioCapture) SubscribeBeats(uid string) *u.AtomicEvent[Beat] {
+	event := u.NewAtomicEvent[Beat]()
+	s.subscribe(func() { s.beatSubs[uid] = event })
+	return event
+}
+
+func (s *AudioCapture) subscribe(add func()) {
+	s.runMutex.Lock()
+	defer s.runMutex.Unlock()
+	s.subMutex.Lock()
+	add()
+	s.subMutex.Unlock()
+	if !s.running && !s.closed {
+		s.running = true
+		s.stopChan = make(chan bool)
+		s.wg.Add(1)
+		go s.run(s.stopChan)
+	}
+}
+
+// Unsubscribe removes all subscriptions of uid. The stream is closed
+// when the last one is gone.
+func (s *AudioCapture) Unsubscribe(uid string) {
+	s.runMutex.Lock()
+	defer s.runMutex.Unlock()
+	s.subMutex.Lock()
+	delete(s.frameSubs, uid)
+	delete(s.beatSubs, uid)
+	remaining := len(s.frameSubs) + len(s.beatSubs)
+	s.subMutex.Unlock()
+	if remaining == 0 {
+		s.stopLocked()
+	}
+}
+
+// Close stops reading and releases PortAudio. The AudioCapture can't be
+// used afterwards.
+func (s *AudioCapture) Close() {
+	s.runMutex.Lock()
+	defer s.runMutex.Unlock()
+	s.stopLocked()
+	s.closed = true
+	terminatePortAudio()
+}
+
+// stopLocked MUST be called with runMutex held.
+func (s *AudioCapture) stopLocked() {
+	if !s.running {
+		return
+	}
+	close(s.stopChan)
+	s.wg.Wait()
+	s.running = false
+}
+
+func (s *AudioCapture) run(stop chan bool) {
+	defer s.wg.Done()
+	var input AudioSource
+	defer func() {
+		if input != nil {
+			input.Close()
+		}
+	}()
+
+	var detector *beatDetector
+	if s.beatCfg.Enabled {
+		detector = newBeatDetector(s.beatCfg, s.sampleRate, s.framesPerBuffer)
+	}
+	var buffer []float32
+	var silenceStart time.Time
+	slowedDown := false
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		if input == nil {
+			if slowedDown {
+				select {
+				case <-stop:
+					return
+				case <-s.clock.After(5 * time.Second):
+				}
+			}
+			var err error
+			if input, err = s.open(); err != nil {
+				slog.Error("AudioCapture: failed to open audio source", "error", err)
+				return
+			}
+			buffer = make([]float32, s.framesPerBuffer*input.Channels())
+		}
+		if err := input.Read(buffer); errors.Is(err, io.EOF) {
+			slog.Error("AudioCapture: audio source ended")
+			return
+		} else if err != nil {
+			// This can happen, e.g., portaudio.InputOverflowed. We can log it but continue.
+			slog.Debug("AudioCapture: read error", "error", err)
+		}
+
+		now := s.clock.Now()
+		left, right := deInterleave(buffer, input.Channels())
+		if input.Channels() == 1 {
+			// the buffer is reused for the next read
+			left = append([]float32(nil), left...)
+			right = left
+		}
+		s.subMutex.Lock()
+		for _, sub := range s.frameSubs {
+			sub.Send(AudioFrame{Left: left, Right: right, Time: now})
+		}
+		if detector != nil {
+			if beat, ok := detector.process(left, right, now); ok {
+				for _, sub := range s.beatSubs {
+					sub.Send(beat)
+				}
+			}
+		}
+		s.subMutex.Unlock()
+
+		if calculateRMS(left) > 0 || calculateRMS(right) > 0 {
+			silenceStart = time.Time{}
+			if slowedDown {
+				slog.Info("AudioCapture: Audio input detected, back to full speed...")
+				slowedDown = false
+			}
+		} else if silenceStart.IsZero() {
+			silenceStart = now
+		} else if !slowedDown && now.Sub(silenceStart) > 10*time.Second {
+			slog.Info("AudioCapture: No audio input detected for 10 seconds, slowing down...")
+			slowedDown = true
+		}
+		if slowedDown {
+			input.Close()
+			input = nil
+		}
+	}
+}
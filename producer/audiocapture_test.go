@@ -0,0 +1,86 @@
+package producer
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	c "lautenbacher.net/goleds/config"
+)
+
+// fakeInput returns stereo buffers with the left channel at 0.5 and
+// the right one at -0.25, paced like a real input.
+type fakeInput struct {
+	mutex  sync.Mutex
+	closed bool
+}
+
+func (f *fakeInput) Channels() int { return 2 }
+
+func (f *fakeInput) Read(buffer []float32) error {
+	time.Sleep(time.Millisecond)
+	for i := 0; i < len(buffer); i += 2 {
+		buffer[i], buffer[i+1] = 0.5, -0.25
+	}
+	return nil
+}
+
+func (f *fakeInput) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.closed = true
+	return nil
+}
+
+func newFakeCapture() (*AudioCapture, *[]*fakeInput) {
+	capture := NewAudioCapture(c.AudioLEDConfig{SampleRate: 8000, FramesPerBuffer: 16})
+	var mutex sync.Mutex
+	inputs := []*fakeInput{}
+	capture.open = func() (AudioSource, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		input := &fakeInput{}
+		inputs = append(inputs, input)
+		return input, nil
+	}
+	return capture, &inputs
+}
+
+func TestAudioCapture_SharedStream(t *testing.T) {
+	capture, inputs := newFakeCapture()
+
+	framesA := capture.SubscribeFrames("a")
+	framesB := capture.SubscribeFrames("b")
+	for _, frames := range []interface{ Channel() <-chan struct{} }{framesA, framesB} {
+		select {
+		case <-frames.Channel():
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for a frame")
+		}
+	}
+	frame := framesA.Value()
+	assert.Len(t, frame.Left, 16)
+	assert.Equal(t, float32(0.5), frame.Left[0])
+	assert.Equal(t, float32(-0.25), frame.Right[15])
+
+	// Both subscribers share one stream, it is closed with the last one.
+	capture.Unsubscribe("a")
+	assert.Len(t, *inputs, 1)
+	assert.False(t, (*inputs)[0].closed)
+	capture.Unsubscribe("b")
+	assert.True(t, (*inputs)[0].closed)
+
+	// A new subscriber opens the stream again, but not after Close.
+	framesC := capture.SubscribeFrames("c")
+	select {
+	case <-framesC.Channel():
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for a frame")
+	}
+	capture.Close()
+	assert.Len(t, *inputs, 2)
+	assert.True(t, (*inputs)[1].closed)
+	capture.SubscribeFrames("d")
+	assert.Len(t, *inputs, 2)
+}
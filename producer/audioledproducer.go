@@ -1,133 +1,81 @@
 package producer
 
 import (
-	"fmt"
-	"log/slog"
 	"math"
 	"strings"
-	"sync"
 	"time"
 
-	"github.com/gordonklaus/portaudio"
 	c "lautenbacher.net/goleds/config"
 	u "lautenbacher.net/goleds/util"
 )
 
-var (
-	paMutex       sync.Mutex
-	paInitialized bool
-)
-
 // AudioLEDProducer implements a VU meter that reads from an audio input
-// and displays the volume on a segment of LEDs.
+// and displays the volume on a segment of LEDs. In spectrum mode it
+// shows the levels of frequency bands instead. Optionally the segments
+// flash on every beat.
 type AudioLEDProducer struct {
 	*AbstractProducer
-	ledsChanged   *u.AtomicMapEvent[LedProducer]
-	Device        string
+	capture       *AudioCapture
 	startLedLeft  int
 	endLedLeft    int
 	startLedRight int
 	endLedRight   int
-	colors        struct {
-		Green  Led
-		Yellow Led
-		Red    Led
-	}
-	sampleRate       int
-	framesPerBuffer  int
-	updateFreq       time.Duration
-	minDB            float64
-	maxDB            float64
-	silenceStartTime time.Time
-	silenceStart     bool
-	slowedDown       bool
+	meterLeft     *vuMeter
+	meterRight    *vuMeter
+	mono          bool
+	updateFreq    time.Duration
+	minDB         float64
+	maxDB         float64
+	spectrumLeft  *spectrumAnalyser
+	spectrumRight *spectrumAnalyser
+	flashColor    *Led
+	flashDuration time.Duration
+	lastBeat      time.Time
+	flashing      bool
 }
 
-// NewAudioLEDProducer creates a new AudioLEDProducer.
-func NewAudioLEDProducer(uid string, ledsChanged *u.AtomicMapEvent[LedProducer], ledsTotal int, cfg c.AudioLEDConfig) *AudioLEDProducer {
+// NewAudioLEDProducer creates a new AudioLEDProducer reading its input
+// from capture.
+func NewAudioLEDProducer(uid string, ledsChanged *u.AtomicMapEvent[LedProducer], ledsTotal int, cfg c.AudioLEDConfig, capture *AudioCapture) *AudioLEDProducer {
 	p := &AudioLEDProducer{
-		ledsChanged:   ledsChanged,
+		capture:       capture,
 		startLedLeft:  cfg.StartLedLeft,
 		endLedLeft:    cfg.EndLedLeft,
 		startLedRight: cfg.StartLedRight,
 		endLedRight:   cfg.EndLedRight,
-		Device:        cfg.Device,
 	}
-	p.colors.Green = Led{Red: cfg.LedGreen[0], Green: cfg.LedGreen[1], Blue: cfg.LedGreen[2]}
-	p.colors.Yellow = Led{Red: cfg.LedYellow[0], Green: cfg.LedYellow[1], Blue: cfg.LedYellow[2]}
-	p.colors.Red = Led{Red: cfg.LedRed[0], Green: cfg.LedRed[1], Blue: cfg.LedRed[2]}
-	p.sampleRate = cfg.SampleRate
-	p.framesPerBuffer = cfg.FramesPerBuffer
+	p.meterLeft = newVUMeter(cfg)
+	p.meterRight = newVUMeter(cfg)
+	p.mono = cfg.Mono
 	p.updateFreq = cfg.UpdateFreq
 	p.minDB = cfg.MinDB
 	p.maxDB = cfg.MaxDB
-	p.silenceStart = false
-	p.slowedDown = false
+	if strings.EqualFold(cfg.Mode, "Spectrum") {
+		p.spectrumLeft = newSpectrumAnalyser(cfg)
+		p.spectrumRight = newSpectrumAnalyser(cfg)
+	}
+	if cfg.Beat.Enabled && len(cfg.Beat.FlashLedRGB) > 0 {
+		rgb := cfg.Beat.FlashLedRGB
+		p.flashColor = &Led{Red: rgb[0], Green: rgb[1], Blue: rgb[2]}
+		p.flashDuration = cfg.Beat.FlashDuration
+	}
 	p.AbstractProducer = NewAbstractProducer(uid, ledsChanged, p.runner, ledsTotal)
 	return p
 }
 
-func (p *AudioLEDProducer) Exit() {
-	p.AbstractProducer.Exit()
-	paMutex.Lock()
-	defer paMutex.Unlock()
-	if paInitialized {
-		if err := portaudio.Terminate(); err != nil {
-			slog.Error("AudioLEDProducer: failed to terminate portaudio", "uid", p.uid, "error", err)
-		} else {
-			slog.Info("AudioLEDProducer: PortAudio terminated.")
-			paInitialized = false
-		}
-	}
-}
-
-// runner is the main processing loop for the producer.
+// runner is the main processing loop for the producer. The LEDs are
+// redrawn every updateFreq if a new buffer was read or a flash fades.
 func (p *AudioLEDProducer) runner() {
-	paMutex.Lock()
-	if !paInitialized {
-		if err := portaudio.Initialize(); err != nil {
-			slog.Error("AudioLEDProducer: failed to initialize portaudio", "uid", p.uid, "error", err)
-			paMutex.Unlock()
-			return
-		}
-		slog.Info("AudioLEDProducer: PortAudio initialized.")
+	frames := p.capture.SubscribeFrames(p.GetUID())
+	var beats *u.AtomicEvent[Beat]
+	var beatChan <-chan struct{}
+	if p.flashColor != nil {
+		beats = p.capture.SubscribeBeats(p.GetUID())
+		beatChan = beats.Channel()
 	}
-	paInitialized = true
-	paMutex.Unlock()
+	defer p.capture.Unsubscribe(p.GetUID())
 
-	inDevice, err := p.findDevice()
-	if err != nil {
-		slog.Error("AudioLEDProducer: no device", "uid", p.GetUID(), "error", err)
-		return
-	}
-
-	slog.Info("AudioLEDProducer", "uid", p.GetUID(), "device", inDevice.Name, "sampleRate", p.sampleRate, "framesPerBuffer", p.framesPerBuffer)
-
-	buffer := make([]float32, p.framesPerBuffer*inDevice.MaxInputChannels)
-	streamParams := portaudio.StreamParameters{
-		Input: portaudio.StreamDeviceParameters{
-			Device:   inDevice,
-			Channels: inDevice.MaxInputChannels,
-			Latency:  inDevice.DefaultLowInputLatency,
-		},
-		SampleRate:      float64(p.sampleRate),
-		FramesPerBuffer: p.framesPerBuffer,
-	}
-
-	stream, err := portaudio.OpenStream(streamParams, buffer)
-	if err != nil {
-		slog.Error("AudioLEDProducer: failed to open stream", "uid", p.uid, "error", err)
-		return
-	}
-	defer stream.Close()
-
-	if err := stream.Start(); err != nil {
-		slog.Error("AudioLEDProducer: failed to start stream", "uid", p.uid, "error", err)
-		return
-	}
-	defer stream.Stop()
-
-	ticker := time.NewTicker(p.updateFreq)
+	ticker := p.clock.NewTicker(p.updateFreq)
 	defer ticker.Stop()
 
 	// Clean up LEDs on exit
@@ -136,128 +84,84 @@ func (p *AudioLEDProducer) runner() {
 		p.ledsChanged.Send(p.GetUID(), p)
 	}()
 
-	p.slowedDown = false
-	p.silenceStart = false
 	for {
 		select {
 		case <-p.stopchan:
 			return
-		case <-ticker.C:
-			if p.slowedDown {
-				stream, err = portaudio.OpenStream(streamParams, buffer)
-				if err != nil {
-					slog.Error("AudioLEDProducer: failed to open stream", "uid", p.uid, "error", err)
-					return
-				}
-				if err = stream.Start(); err != nil {
-					slog.Error("AudioLEDProducer: failed to start stream", "uid", p.uid, "error", err)
-					return
-				}
+		case <-beatChan:
+			p.lastBeat = beats.Value().Time
+		case <-ticker.C():
+			newFrame := false
+			select {
+			case <-frames.Channel():
+				newFrame = true
+			default:
 			}
-			if err = stream.Read(); err != nil {
-				// This can happen, e.g., portaudio.InputOverflowed. We can log it but continue.
+			now := p.clock.Now()
+			flash := p.flashFactor(now)
+			if !newFrame && flash == 0 && !p.flashing {
+				continue
 			}
+			p.flashing = flash > 0
 
-			samplesL, samplesR := deInterleave(buffer, inDevice.MaxInputChannels)
-			rmsL := calculateRMS(samplesL)
-			rmsR := calculateRMS(samplesR)
-			p.checkSilence(rmsL, rmsR, ticker)
-			if p.slowedDown {
-				stream.Stop()
-				stream.Close()
+			frame := frames.Value()
+			if frame.Left == nil {
+				continue
+			}
+			if p.spectrumLeft != nil {
+				p.spectrumLeft.show(frame.Left, now, p.leds, p.startLedLeft, p.endLedLeft)
+				p.spectrumRight.show(frame.Right, now, p.leds, p.startLedRight, p.endLedRight)
+			} else {
+				p.updateLeds(calculateRMS(frame.Left), calculateRMS(frame.Right), now)
+			}
+			if flash > 0 {
+				p.applyFlash(flash, p.startLedLeft, p.endLedLeft)
+				p.applyFlash(flash, p.startLedRight, p.endLedRight)
 			}
-
-			dbL := rmsToDB(rmsL)
-			dbR := rmsToDB(rmsR)
-			p.updateLeds(dbL, p.startLedLeft, p.endLedLeft)
-			p.updateLeds(dbR, p.startLedRight, p.endLedRight)
 			p.ledsChanged.Send(p.GetUID(), p)
 		}
 	}
 }
 
-func (p *AudioLEDProducer) checkSilence(rmsL float64, rmsR float64, ticker *time.Ticker) {
-	if rmsL > 0 || rmsR > 0 {
-		if p.slowedDown {
-			slog.Info("AudioLEDProducer: Audio input detected, back to full loop speed...")
-			p.silenceStart = false
-			p.slowedDown = false
-			ticker.Reset(p.updateFreq)
-		} else if p.silenceStart {
-			// Reset silence start if we detect audio after a period of silence
-			p.silenceStart = false
-		}
-	} else {
-		if !p.silenceStart {
-			p.silenceStart = true
-			p.silenceStartTime = time.Now()
-		} else {
-			if !p.slowedDown && time.Since(p.silenceStartTime) > 10*time.Second {
-				slog.Info("AudioLEDProducer: No audio input detected for 10 seconds, slowing down loop...")
-				ticker.Reset(5 * time.Second)
-				p.slowedDown = true
-			}
-		}
+// flashFactor returns the brightness (1 at the beat, fading to 0 within
+// flashDuration) of the beat flash at now.
+func (p *AudioLEDProducer) flashFactor(now time.Time) float64 {
+	if p.flashColor == nil || p.lastBeat.IsZero() {
+		return 0
 	}
+	return max(1-float64(now.Sub(p.lastBeat))/float64(p.flashDuration), 0)
 }
 
-// updateLeds calculates and sets the LED colors based on the dB level.
-func (p *AudioLEDProducer) updateLeds(db float64, startLed int, endLed int) {
-	reverse := false
-	if startLed > endLed {
-		reverse = true
-		startLed, endLed = endLed, startLed
-	}
-	segmentLen := endLed - startLed + 1
-
-	// Clamp dB value to the expected range
-	db = min(db, p.maxDB)
-	db = max(db, p.minDB)
-
-	// Normalize level from 0.0 to 1.0
-	level := (db - p.minDB) / (p.maxDB - p.minDB)
-	ledsToLight := int(math.Ceil(level * float64(segmentLen)))
-
-	// Define color sections (e.g., 70% green, 20% yellow, 10% red)
-	greenEnd := int(float64(segmentLen) * 0.7)
-	yellowEnd := int(float64(segmentLen) * 0.9)
-
-	for i := range segmentLen {
-		stripIndex := startLed + i
-		if i < ledsToLight {
-			if i < greenEnd {
-				p.leds[stripIndex] = p.colors.Green
-			} else if i < yellowEnd {
-				p.leds[stripIndex] = p.colors.Yellow
-			} else {
-				p.leds[stripIndex] = p.colors.Red
-			}
-		} else {
-			p.leds[stripIndex] = Led{} // Off
-		}
-	}
-	if reverse {
-		for i := 0; i < segmentLen/2; i++ {
-			p.leds[startLed+i], p.leds[endLed-i] = p.leds[endLed-i], p.leds[startLed+i]
-		}
+// applyFlash blends the flash colour dimmed by factor into the LEDs
+// from startLed to endLed.
+func (p *AudioLEDProducer) applyFlash(factor float64, startLed, endLed int) {
+	flash := Led{p.flashColor.Red * factor, p.flashColor.Green * factor, p.flashColor.Blue * factor}
+	for i := min(startLed, endLed); i <= max(startLed, endLed); i++ {
+		p.leds[i] = p.leds[i].Max(flash)
 	}
 }
 
-func (p *AudioLEDProducer) findDevice() (*portaudio.DeviceInfo, error) {
-	devices, err := portaudio.Devices()
-	if err != nil {
-		return nil, fmt.Errorf("could not list audio devices: %w", err)
-	}
-
-	// Look for a squeezelite device
-	for _, device := range devices {
-		// log.Printf("AudioLEDProducer: found device: %s", device.Name)
-		if device.MaxInputChannels > 0 && strings.Contains(strings.ToLower(device.Name), p.Device) {
-			return device, nil
-		}
+// updateLeds shows the RMS values of both channels on the VU meters,
+// or their mean on one bar across both ranges in mono mode.
+func (p *AudioLEDProducer) updateLeds(rmsL, rmsR float64, now time.Time) {
+	left := ledRange(p.startLedLeft, p.endLedLeft)
+	right := ledRange(p.startLedRight, p.endLedRight)
+	if p.mono {
+		level, peak := p.meterLeft.update(p.dbLevel(rmsToDB((rmsL+rmsR)/2)), now)
+		p.meterLeft.draw(p.leds, append(left, right...), level, peak)
+		return
 	}
+	level, peak := p.meterLeft.update(p.dbLevel(rmsToDB(rmsL)), now)
+	p.meterLeft.draw(p.leds, left, level, peak)
+	level, peak = p.meterRight.update(p.dbLevel(rmsToDB(rmsR)), now)
+	p.meterRight.draw(p.leds, right, level, peak)
+}
 
-	return nil, fmt.Errorf("no suitable audio input device found")
+// dbLevel normalizes db to 0.0 to 1.0 between minDB and maxDB.
+func (p *AudioLEDProducer) dbLevel(db float64) float64 {
+	db = min(db, p.maxDB)
+	db = max(db, p.minDB)
+	return (db - p.minDB) / (p.maxDB - p.minDB)
 }
 
 // deInterleave converts a buffer of interleaved stereo samples to mono.
@@ -0,0 +1,48 @@
+package producer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	c "lautenbacher.net/goleds/config"
+	u "lautenbacher.net/goleds/util"
+)
+
+func TestAudioLEDProducer_VUFromCapture(t *testing.T) {
+	capture, _ := newFakeCapture()
+	defer capture.Close()
+	cfg := c.AudioLEDConfig{
+		StartLedLeft: 9, EndLedLeft: 0, StartLedRight: 10, EndLedRight: 19,
+		LedGreen: []float64{0, 10, 0}, LedYellow: []float64{10, 10, 0}, LedRed: []float64{10, 0, 0},
+		UpdateFreq: 5 * time.Millisecond, MinDB: -60, MaxDB: 0,
+		Beat: c.BeatConfig{Enabled: true, Sensitivity: 2, FlashLedRGB: []float64{0, 0, 50}, FlashDuration: time.Second},
+	}
+	ledsChanged := u.NewAtomicMapEvent[LedProducer]()
+	prod := NewAudioLEDProducer("audio", ledsChanged, 20, cfg, capture)
+
+	// A beat flashes both ranges, fading out.
+	now := time.Now()
+	prod.lastBeat = now.Add(-750 * time.Millisecond)
+	assert.InDelta(t, 0.25, prod.flashFactor(now), 0.01)
+
+	prod.lastBeat = time.Time{}
+	prod.Start()
+	defer prod.Exit()
+	leds := make([]Led, 20)
+	deadline := time.Now().Add(time.Second)
+	for leds[10].IsEmpty() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the VU meter")
+		}
+		<-ledsChanged.Channel()
+		prod.GetLeds(leds)
+	}
+	// Left at -6 dB lights 9 of 10 LEDs from LED 9 down, right at
+	// -12 dB lights 8 from LED 10 up.
+	assert.Equal(t, Led{}, leds[0])
+	assert.Equal(t, Led{Red: 10, Green: 10}, leds[1])
+	assert.Equal(t, Led{Green: 10}, leds[9])
+	assert.Equal(t, Led{Red: 10, Green: 10}, leds[17])
+	assert.Equal(t, Led{}, leds[18])
+}
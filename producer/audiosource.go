@@ -0,0 +1,231 @@
+package producer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	c "lautenbacher.net/goleds/config"
+	u "lautenbacher.net/goleds/util"
+)
+
+// AudioSource is a stream of interleaved samples between -1 and 1. Read
+// fills the whole buffer and blocks like a sound card does, io.EOF
+// means the source has ended.
+type AudioSource interface {
+	Channels() int
+	Read(buffer []float32) error
+	Close() error
+}
+
+// NewAudioSource opens the audio source selected in cfg. Files and the
+// test signal are paced by clock to play in real time.
+func NewAudioSource(cfg c.AudioLEDConfig, clock u.Clock) (AudioSource, error) {
+	switch strings.ToUpper(cfg.Source) {
+	case "FILE":
+		source, err := openFileSource(cfg.File, cfg.SampleRate, cfg.PCMFormat, cfg.Channels)
+		if err != nil {
+			return nil, err
+		}
+		return newPacedSource(source, cfg.SampleRate, clock), nil
+	case "STDIN":
+		// stdin stays open for the next time the source is opened
+		return newPCMSource(os.Stdin, func() error { return nil }, cfg.PCMFormat, cfg.Channels), nil
+	case "COMMAND":
+		return startCommandSource(cfg.Command, cfg.PCMFormat, cfg.Channels)
+	case "TESTSIGNAL":
+		return newPacedSource(newTestSignal(cfg.Signal, cfg.SampleRate), cfg.SampleRate, clock), nil
+	default:
+		return newPortAudioSource(cfg.Device, cfg.SampleRate, cfg.FramesPerBuffer)
+	}
+}
+
+// pcmSource decodes raw little endian PCM ("S16LE" or "F32LE") read
+// from reader.
+type pcmSource struct {
+	reader   io.Reader
+	closer   func() error
+	float    bool
+	channels int
+	data     []byte
+}
+
+func newPCMSource(reader io.Reader, closer func() error, format string, channels int) *pcmSource {
+	return &pcmSource{
+		reader:   reader,
+		closer:   closer,
+		float:    strings.EqualFold(format, "F32LE"),
+		channels: channels,
+	}
+}
+
+func (s *pcmSource) Channels() int {
+	return s.channels
+}
+
+func (s *pcmSource) Read(buffer []float32) error {
+	size := 2
+	if s.float {
+		size = 4
+	}
+	if len(s.data) != size*len(buffer) {
+		s.data = make([]byte, size*len(buffer))
+	}
+	if _, err := io.ReadFull(s.reader, s.data); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return io.EOF
+		}
+		return err
+	}
+	for i := range buffer {
+		if s.float {
+			buffer[i] = math.Float32frombits(binary.LittleEndian.Uint32(s.data[4*i:]))
+		} else {
+			buffer[i] = float32(int16(binary.LittleEndian.Uint16(s.data[2*i:]))) / 32768
+		}
+	}
+	return nil
+}
+
+func (s *pcmSource) Close() error {
+	return s.closer()
+}
+
+// loopReader reads data over and over again.
+type loopReader struct {
+	data *io.SectionReader
+}
+
+func (r *loopReader) Read(p []byte) (int, error) {
+	n, err := r.data.Read(p)
+	if err == io.EOF && r.data.Size() > 0 {
+		if _, err := r.data.Seek(0, io.SeekStart); err != nil {
+			return n, err
+		}
+		if n == 0 {
+			return r.data.Read(p)
+		}
+		err = nil
+	}
+	return n, err
+}
+
+// openFileSource opens a WAV or raw PCM file, which is played in a
+// loop. WAV files must have the configured sample rate.
+func openFileSource(path string, sampleRate int, format string, channels int) (*pcmSource, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audio file: %w", err)
+	}
+	header := make([]byte, 12)
+	n, _ := io.ReadFull(file, header)
+	info, err := os.Stat(path)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to open audio file: %w", err)
+	}
+	data := io.NewSectionReader(file, 0, info.Size())
+	if n == 12 && bytes.Equal(header[0:4], []byte("RIFF")) && bytes.Equal(header[8:12], []byte("WAVE")) {
+		wav, err := parseWAV(file)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if wav.sampleRate != sampleRate {
+			file.Close()
+			return nil, fmt.Errorf("%s: sample rate %d doesn't match the SampleRate %d", path, wav.sampleRate, sampleRate)
+		}
+		data = io.NewSectionReader(file, wav.dataOffset, wav.dataSize)
+		format, channels = wav.format, wav.channels
+	} else if channels != 1 && channels != 2 {
+		file.Close()
+		return nil, fmt.Errorf("%s: Channels must be 1 or 2 for raw PCM files", path)
+	}
+	return newPCMSource(&loopReader{data: data}, file.Close, format, channels), nil
+}
+
+// startCommandSource runs command and reads raw PCM from its stdout,
+// e.g. from parec or pw-record recording a monitor source.
+func startCommandSource(command []string, format string, channels int) (*pcmSource, error) {
+	cmd := exec.Command(command[0], command[1:]...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run %s: %w", command[0], err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to run %s: %w", command[0], err)
+	}
+	return newPCMSource(stdout, func() error {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return nil
+	}, format, channels), nil
+}
+
+// pacedSource delivers the samples of an AudioSource in real time.
+type pacedSource struct {
+	AudioSource
+	sampleRate int
+	clock      u.Clock
+	start      time.Time
+	frames     int64
+}
+
+func newPacedSource(source AudioSource, sampleRate int, clock u.Clock) *pacedSource {
+	return &pacedSource{AudioSource: source, sampleRate: sampleRate, clock: clock}
+}
+
+func (s *pacedSource) Read(buffer []float32) error {
+	if s.start.IsZero() {
+		s.start = s.clock.Now()
+	}
+	err := s.AudioSource.Read(buffer)
+	s.frames += int64(len(buffer) / s.Channels())
+	due := s.start.Add(time.Duration(s.frames) * time.Second / time.Duration(s.sampleRate))
+	if wait := due.Sub(s.clock.Now()); wait > 0 {
+		s.clock.Sleep(wait)
+	}
+	return err
+}
+
+// testSignal generates the test signal, see AudioSignalConfig.
+type testSignal struct {
+	cfg        c.AudioSignalConfig
+	sampleRate float64
+	n          int64
+}
+
+func newTestSignal(cfg c.AudioSignalConfig, sampleRate int) *testSignal {
+	return &testSignal{cfg: cfg, sampleRate: float64(sampleRate)}
+}
+
+func (s *testSignal) Channels() int {
+	return 2
+}
+
+func (s *testSignal) Read(buffer []float32) error {
+	for i := 0; i+1 < len(buffer); i += 2 {
+		t := float64(s.n) / s.sampleRate
+		tone := s.cfg.Amplitude * math.Sin(2*math.Pi*s.cfg.Frequency*t)
+		var kick float64
+		if s.cfg.BPM > 0 {
+			phase := math.Mod(t, 60/s.cfg.BPM)
+			kick = 0.8 * math.Exp(-30*phase) * math.Sin(2*math.Pi*60*phase)
+		}
+		buffer[i] = float32(max(min(tone+kick, 1), -1))
+		buffer[i+1] = float32(max(min(tone/2+kick, 1), -1))
+		s.n++
+	}
+	return nil
+}
+
+func (s *testSignal) Close() error {
+	return nil
+}
@@ -0,0 +1,143 @@
+package producer
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	c "lautenbacher.net/goleds/config"
+	u "lautenbacher.net/goleds/util"
+)
+
+// writeWAV writes a 16 bit stereo WAV file whose frame i holds the
+// samples i and -i (in 1/32768), with an odd sized chunk before the
+// data to skip.
+func writeWAV(t *testing.T, sampleRate, frames int) string {
+	data := make([]byte, 4*frames)
+	for i := range frames {
+		binary.LittleEndian.PutUint16(data[4*i:], uint16(int16(i)))
+		binary.LittleEndian.PutUint16(data[4*i+2:], uint16(int16(-i)))
+	}
+	fmtChunk := make([]byte, 16)
+	binary.LittleEndian.PutUint16(fmtChunk[0:], wavFormatPCM)
+	binary.LittleEndian.PutUint16(fmtChunk[2:], 2)
+	binary.LittleEndian.PutUint32(fmtChunk[4:], uint32(sampleRate))
+	binary.LittleEndian.PutUint32(fmtChunk[8:], uint32(4*sampleRate))
+	binary.LittleEndian.PutUint16(fmtChunk[12:], 4)
+	binary.LittleEndian.PutUint16(fmtChunk[14:], 16)
+
+	var wav []byte
+	chunk := func(id string, body []byte) {
+		wav = append(wav, id...)
+		wav = binary.LittleEndian.AppendUint32(wav, uint32(len(body)))
+		wav = append(wav, body...)
+		if len(body)%2 == 1 {
+			wav = append(wav, 0)
+		}
+	}
+	chunk("fmt ", fmtChunk)
+	chunk("LIST", []byte("odd"))
+	chunk("data", data)
+	wav = append([]byte("RIFF\x00\x00\x00\x00WAVE"), wav...)
+
+	path := filepath.Join(t.TempDir(), "test.wav")
+	require.NoError(t, os.WriteFile(path, wav, 0o644))
+	return path
+}
+
+func TestAudioSource_WAVFileLoops(t *testing.T) {
+	path := writeWAV(t, 8000, 10)
+	source, err := openFileSource(path, 8000, "", 0)
+	require.NoError(t, err)
+	defer source.Close()
+	assert.Equal(t, 2, source.Channels())
+
+	// 16 frames from a file of 10 frames wrap around.
+	buffer := make([]float32, 32)
+	require.NoError(t, source.Read(buffer))
+	assert.Equal(t, float32(9)/32768, buffer[18])
+	assert.Equal(t, float32(-9)/32768, buffer[19])
+	assert.Equal(t, float32(0), buffer[20])
+	assert.Equal(t, float32(5)/32768, buffer[30])
+
+	_, err = openFileSource(path, 44100, "", 0)
+	assert.ErrorContains(t, err, "doesn't match the SampleRate")
+}
+
+func TestAudioSource_RawFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.f32")
+	var data []byte
+	for _, v := range []float32{0.5, -0.25, 1} {
+		data = binary.LittleEndian.AppendUint32(data, math.Float32bits(v))
+	}
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+
+	_, err := openFileSource(path, 8000, "F32LE", 0)
+	assert.ErrorContains(t, err, "Channels must be 1 or 2")
+
+	source, err := openFileSource(path, 8000, "F32LE", 1)
+	require.NoError(t, err)
+	defer source.Close()
+	buffer := make([]float32, 4)
+	require.NoError(t, source.Read(buffer))
+	assert.Equal(t, []float32{0.5, -0.25, 1, 0.5}, buffer)
+}
+
+func TestAudioSource_Command(t *testing.T) {
+	cfg := c.AudioLEDConfig{Source: "Command", Command: []string{"printf", `\000\100\000\300`}, Channels: 2}
+	source, err := NewAudioSource(cfg, u.RealClock{})
+	require.NoError(t, err)
+	defer source.Close()
+
+	buffer := make([]float32, 2)
+	require.NoError(t, source.Read(buffer))
+	assert.Equal(t, []float32{0.5, -0.5}, buffer)
+	// The source ends with the command.
+	assert.ErrorIs(t, source.Read(buffer), io.EOF)
+}
+
+func TestAudioSource_TestSignalPaced(t *testing.T) {
+	clock := u.NewFakeClock(time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC))
+	cfg := c.AudioLEDConfig{Source: "TestSignal", SampleRate: 8000, Signal: c.AudioSignalConfig{Frequency: 1000, Amplitude: 0.5}}
+	source, err := NewAudioSource(cfg, clock)
+	require.NoError(t, err)
+
+	// 800 frames take 100ms, 100 periods of the tone.
+	buffer := make([]float32, 1600)
+	done := make(chan error)
+	go func() { done <- source.Read(buffer) }()
+	clock.BlockUntil(1)
+	clock.Advance(100 * time.Millisecond)
+	require.NoError(t, <-done)
+
+	left, right := deInterleave(buffer, source.Channels())
+	assert.InDelta(t, 0.5/math.Sqrt2, calculateRMS(left), 1e-6)
+	assert.InDelta(t, 0.25/math.Sqrt2, calculateRMS(right), 1e-6)
+	assert.InDelta(t, -9.03, rmsToDB(calculateRMS(left)), 0.01)
+	assert.InDelta(t, -80, rmsToDB(0), 1e-9)
+
+	mono, same := deInterleave(left, 1)
+	assert.Equal(t, &mono[0], &same[0])
+}
+
+func TestAudioSource_TestSignalKicks(t *testing.T) {
+	signal := newTestSignal(c.AudioSignalConfig{Frequency: 1000, BPM: 120}, 8000)
+	detector := newBeatDetector(c.BeatConfig{Enabled: true, Sensitivity: 1.5}, 8000, 400)
+	start := time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC)
+	buffer := make([]float32, 800)
+	var last Beat
+	for i := range 120 { // 6 seconds
+		require.NoError(t, signal.Read(buffer))
+		left, right := deInterleave(buffer, 2)
+		if beat, ok := detector.process(left, right, start.Add(time.Duration(i)*50*time.Millisecond)); ok {
+			last = beat
+		}
+	}
+	assert.InDelta(t, 120, last.BPM, 1e-9)
+}
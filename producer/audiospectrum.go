@@ -0,0 +1,154 @@
+package producer
+
+import (
+	"math"
+	"math/cmplx"
+	"time"
+
+	c "lautenbacher.net/goleds/config"
+	u "lautenbacher.net/goleds/util"
+)
+
+// spectrumAnalyser turns the samples of one audio channel into the
+// levels of frequency bands and shows them on a range of LEDs. Every
+// band gets an equal share of the LEDs, coloured from the palette and
+// dimmed by its level.
+type spectrumAnalyser struct {
+	bands      int
+	minFreq    float64
+	maxFreq    float64
+	logScale   bool
+	palette    []Led
+	sampleRate float64
+	minDB      float64
+	maxDB      float64
+	window     []float64
+	windowSum  float64
+	buf        []complex128
+	holds      []levelHold
+	levels     []float64
+}
+
+func newSpectrumAnalyser(cfg c.AudioLEDConfig) *spectrumAnalyser {
+	a := &spectrumAnalyser{
+		bands:      cfg.Spectrum.Bands,
+		minFreq:    cfg.Spectrum.MinFreq,
+		maxFreq:    cfg.Spectrum.MaxFreq,
+		logScale:   cfg.Spectrum.LogScale,
+		sampleRate: float64(cfg.SampleRate),
+		minDB:      cfg.MinDB,
+		maxDB:      cfg.MaxDB,
+		holds:      make([]levelHold, cfg.Spectrum.Bands),
+		levels:     make([]float64, cfg.Spectrum.Bands),
+	}
+	for i := range a.holds {
+		a.holds[i] = levelHold{hold: cfg.Spectrum.PeakHold, fallOff: cfg.Spectrum.FallOff}
+	}
+	for _, rgb := range cfg.Spectrum.Palette {
+		a.palette = append(a.palette, Led{Red: rgb[0], Green: rgb[1], Blue: rgb[2]})
+	}
+	return a
+}
+
+// bandEdge returns the lower frequency of band i, the upper frequency
+// of the last band for i == bands.
+func (a *spectrumAnalyser) bandEdge(i int) float64 {
+	t := float64(i) / float64(a.bands)
+	if a.logScale {
+		return a.minFreq * math.Pow(a.maxFreq/a.minFreq, t)
+	}
+	return a.minFreq + t*(a.maxFreq-a.minFreq)
+}
+
+// bandLevels returns the level (0..1 between MinDB and MaxDB) of every
+// band. The samples are Hann windowed and zero padded to a power of two.
+func (a *spectrumAnalyser) bandLevels(samples []float32) []float64 {
+	if len(a.window) != len(samples) {
+		a.window = make([]float64, len(samples))
+		a.windowSum = 0
+		for i := range a.window {
+			a.window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(max(len(samples)-1, 1)))
+			a.windowSum += a.window[i]
+		}
+		a.buf = make([]complex128, u.NextPowerOfTwo(len(samples)))
+	}
+	clear(a.buf)
+	for i, sample := range samples {
+		a.buf[i] = complex(float64(sample)*a.window[i], 0)
+	}
+	u.FFT(a.buf)
+
+	binWidth := a.sampleRate / float64(len(a.buf))
+	// a full scale sine has an amplitude of 1
+	amplitude := func(bin int) float64 {
+		return 2 * cmplx.Abs(a.buf[bin]) / max(a.windowSum, 1e-9)
+	}
+	levels := make([]float64, a.bands)
+	for i := range levels {
+		low, high := a.bandEdge(i), a.bandEdge(i+1)
+		first := int(math.Ceil(low / binWidth))
+		last := min(int(math.Ceil(high/binWidth))-1, len(a.buf)/2)
+		var amp float64
+		if first > last {
+			// band narrower than a bin: take the bin at its centre
+			amp = amplitude(min(int(math.Round((low+high)/2/binWidth)), len(a.buf)/2))
+		}
+		for bin := first; bin <= last; bin++ {
+			amp = max(amp, amplitude(bin))
+		}
+		levels[i] = (rmsToDB(amp) - a.minDB) / (a.maxDB - a.minDB)
+		levels[i] = max(min(levels[i], 1), 0)
+	}
+	return levels
+}
+
+// update applies peak hold and fall-off to the new levels and returns
+// the levels to show.
+func (a *spectrumAnalyser) update(levels []float64, now time.Time) []float64 {
+	for i, level := range levels {
+		a.levels[i] = a.holds[i].update(level, now)
+	}
+	return a.levels
+}
+
+// bandColor returns the palette colour of band i, interpolated between
+// the palette entries from the lowest to the highest band.
+func (a *spectrumAnalyser) bandColor(i int) Led {
+	if len(a.palette) == 1 || a.bands == 1 {
+		return a.palette[0]
+	}
+	pos := float64(i) / float64(a.bands-1) * float64(len(a.palette)-1)
+	idx := min(int(pos), len(a.palette)-2)
+	frac := pos - float64(idx)
+	from, to := a.palette[idx], a.palette[idx+1]
+	return Led{
+		Red:   from.Red + frac*(to.Red-from.Red),
+		Green: from.Green + frac*(to.Green-from.Green),
+		Blue:  from.Blue + frac*(to.Blue-from.Blue),
+	}
+}
+
+// show analyses samples and shows the bands on leds from startLed to
+// endLed.
+func (a *spectrumAnalyser) show(samples []float32, now time.Time, leds []Led, startLed, endLed int) {
+	a.render(a.update(a.bandLevels(samples), now), leds, startLed, endLed)
+}
+
+// render shows the band levels on leds from startLed to endLed, the
+// lowest band at startLed.
+func (a *spectrumAnalyser) render(levels []float64, leds []Led, startLed, endLed int) {
+	direction := 1
+	if startLed > endLed {
+		direction = -1
+	}
+	segmentLen := (endLed-startLed)*direction + 1
+	for i := range segmentLen {
+		band := i * a.bands / segmentLen
+		color := a.bandColor(band)
+		leds[startLed+i*direction] = Led{
+			Red:   color.Red * levels[band],
+			Green: color.Green * levels[band],
+			Blue:  color.Blue * levels[band],
+		}
+	}
+}
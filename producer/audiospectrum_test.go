@@ -0,0 +1,92 @@
+package producer
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	c "lautenbacher.net/goleds/config"
+)
+
+func spectrumConfig() c.AudioLEDConfig {
+	return c.AudioLEDConfig{
+		SampleRate: 16000,
+		MinDB:      -60,
+		MaxDB:      0,
+		Mode:       "Spectrum",
+		Spectrum: c.AudioSpectrumConfig{
+			Bands:    4,
+			MinFreq:  100,
+			MaxFreq:  8000,
+			LogScale: true,
+			PeakHold: 100 * time.Millisecond,
+			FallOff:  2,
+			Palette:  [][]float64{{100, 0, 0}, {0, 0, 100}},
+		},
+	}
+}
+
+func sine(freq, amplitude float64, sampleRate, n int) []float32 {
+	samples := make([]float32, n)
+	for i := range samples {
+		samples[i] = float32(amplitude * math.Sin(2*math.Pi*freq*float64(i)/float64(sampleRate)))
+	}
+	return samples
+}
+
+func TestSpectrumAnalyser_bandLevels(t *testing.T) {
+	a := newSpectrumAnalyser(spectrumConfig())
+	// Log scaled bands: 100, 299, 894, 2675, 8000 Hz
+	assert.InDelta(t, 894.4, a.bandEdge(2), 0.1)
+
+	// A 1 kHz sine at half scale (-6 dB) only shows up in the third band.
+	levels := a.bandLevels(sine(1000, 0.5, 16000, 1024))
+	assert.InDelta(t, (20*math.Log10(0.5)+60)/60, levels[2], 0.01)
+	for _, band := range []int{0, 1, 3} {
+		assert.Less(t, levels[band], 0.05, "band %d", band)
+	}
+
+	// Buffer sizes that aren't a power of two are zero padded.
+	levels = a.bandLevels(sine(200, 1, 16000, 1000))
+	assert.Greater(t, levels[0], 0.9)
+}
+
+func TestSpectrumAnalyser_update(t *testing.T) {
+	a := newSpectrumAnalyser(spectrumConfig())
+	start := time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, []float64{1, 0.5, 0, 0}, a.update([]float64{1, 0.5, 0, 0}, start))
+	// Within the hold time the peaks stay, higher levels replace them.
+	assert.Equal(t, []float64{1, 0.8, 0, 0}, a.update([]float64{0, 0.8, 0, 0}, start.Add(50*time.Millisecond)))
+	// 50ms after the hold time of the first band it has fallen by 0.1.
+	levels := a.update([]float64{0, 0, 0, 0}, start.Add(150*time.Millisecond))
+	assert.InDelta(t, 0.9, levels[0], 1e-9)
+	assert.InDelta(t, 0.8, levels[1], 1e-9)
+	// The fall-off continues from the last update, but not below the level.
+	levels = a.update([]float64{0.5, 0, 0, 0}, start.Add(350*time.Millisecond))
+	assert.InDelta(t, 0.5, levels[0], 1e-9)
+	assert.InDelta(t, 0.8-2*0.2, levels[1], 1e-9)
+
+	// Without a fall-off the level drops at once after the hold time.
+	cfg := spectrumConfig()
+	cfg.Spectrum.FallOff = 0
+	a = newSpectrumAnalyser(cfg)
+	a.update([]float64{1, 1, 1, 1}, start)
+	assert.Equal(t, []float64{0, 0, 0, 0}, a.update([]float64{0, 0, 0, 0}, start.Add(time.Second)))
+}
+
+func TestSpectrumAnalyser_render(t *testing.T) {
+	a := newSpectrumAnalyser(spectrumConfig())
+	leds := make([]Led, 10)
+
+	// Reversed range: the lowest band starts at LED 9, two LEDs per band.
+	a.render([]float64{1, 1, 0.5, 0}, leds, 9, 2)
+	assert.Equal(t, Led{Red: 100}, leds[9])
+	assert.Equal(t, Led{Red: 100}, leds[8])
+	assert.InDelta(t, 100*2.0/3, leds[7].Red, 1e-9)
+	assert.InDelta(t, 100*1.0/3, leds[7].Blue, 1e-9)
+	assert.InDelta(t, 100*1.0/3/2, leds[4].Red, 1e-9)
+	assert.Equal(t, Led{}, leds[2])
+	assert.Equal(t, Led{}, leds[0])
+}
@@ -0,0 +1,111 @@
+package producer
+
+import (
+	"math"
+	"slices"
+	"time"
+
+	c "lautenbacher.net/goleds/config"
+)
+
+const (
+	beatLowpassHz   = 150.0
+	beatMinInterval = 250 * time.Millisecond // 240 BPM
+	beatMaxInterval = 2 * time.Second        // 30 BPM
+	beatIntervals   = 8
+)
+
+// Beat is an onset detected in the audio input.
+type Beat struct {
+	Time time.Time
+	// BPM is the estimated tempo, 0 until enough beats were seen.
+	BPM float64
+}
+
+// beatDetector finds beats in the bass of the audio input: a buffer
+// whose low-pass filtered energy exceeds the average of the last second
+// by sensitivity is a beat. The tempo is estimated from the median of
+// the recent beat intervals.
+type beatDetector struct {
+	sensitivity float64
+	minInterval time.Duration
+	alpha       float64
+	lowpass     float64
+	history     []float64
+	pos         int
+	filled      bool
+	lastBeat    time.Time
+	intervals   []time.Duration
+}
+
+func newBeatDetector(cfg c.BeatConfig, sampleRate, framesPerBuffer int) *beatDetector {
+	return &beatDetector{
+		sensitivity: cfg.Sensitivity,
+		minInterval: cfg.MinInterval,
+		alpha:       1 - math.Exp(-2*math.Pi*beatLowpassHz/float64(sampleRate)),
+		history:     make([]float64, max(sampleRate/framesPerBuffer, 8)),
+	}
+}
+
+// process analyses the next buffer read at now and returns the beat,
+// if it is one.
+func (d *beatDetector) process(left, right []float32, now time.Time) (Beat, bool) {
+	var energy float64
+	for i := range left {
+		d.lowpass += d.alpha * ((float64(left[i])+float64(right[i]))/2 - d.lowpass)
+		energy += d.lowpass * d.lowpass
+	}
+	energy /= float64(max(len(left), 1))
+
+	var average float64
+	for _, e := range d.history {
+		average += e
+	}
+	average /= float64(len(d.history))
+
+	d.history[d.pos] = energy
+	d.pos = (d.pos + 1) % len(d.history)
+	if d.pos == 0 {
+		d.filled = true
+	}
+
+	if !d.filled || energy <= d.sensitivity*average || energy < 1e-6 {
+		return Beat{}, false
+	}
+	if !d.lastBeat.IsZero() {
+		interval := now.Sub(d.lastBeat)
+		if interval < d.minInterval {
+			return Beat{}, false
+		}
+		switch {
+		case interval > beatMaxInterval:
+			// a break, the tempo may have changed
+			d.intervals = d.intervals[:0]
+		case interval >= beatMinInterval:
+			d.intervals = append(d.intervals, interval)
+			if len(d.intervals) > beatIntervals {
+				d.intervals = d.intervals[1:]
+			}
+		}
+	}
+	d.lastBeat = now
+	return Beat{Time: now, BPM: d.bpm()}, true
+}
+
+// bpm returns the tempo of the recent beats folded into 60..180 BPM, or
+// 0 if there are too few of them.
+func (d *beatDetector) bpm() float64 {
+	if len(d.intervals) < beatIntervals/2 {
+		return 0
+	}
+	sorted := slices.Clone(d.intervals)
+	slices.Sort(sorted)
+	bpm := 60 / sorted[len(sorted)/2].Seconds()
+	for bpm < 60 {
+		bpm *= 2
+	}
+	for bpm > 180 {
+		bpm /= 2
+	}
+	return bpm
+}
@@ -0,0 +1,74 @@
+package producer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	c "lautenbacher.net/goleds/config"
+)
+
+// kickBuffer returns a buffer of a 60 Hz tone, loud for a kick drum and
+// quiet otherwise.
+func kickBuffer(kick bool, sampleRate, n int) []float32 {
+	amplitude := 0.05
+	if kick {
+		amplitude = 0.8
+	}
+	return sine(60, amplitude, sampleRate, n)
+}
+
+func TestBeatDetector(t *testing.T) {
+	const sampleRate, n = 8000, 400 // 50ms per buffer
+	d := newBeatDetector(c.BeatConfig{Enabled: true, Sensitivity: 1.5, MinInterval: 100 * time.Millisecond}, sampleRate, n)
+	start := time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC)
+
+	// A kick every 500ms (120 BPM), starting after the first second.
+	var beats []Beat
+	for i := range 100 {
+		now := start.Add(time.Duration(i) * 50 * time.Millisecond)
+		buffer := kickBuffer(i >= 20 && i%10 == 0, sampleRate, n)
+		if beat, ok := d.process(buffer, buffer, now); ok {
+			assert.Equal(t, now, beat.Time)
+			beats = append(beats, beat)
+		}
+	}
+	if assert.Len(t, beats, 8) {
+		assert.Equal(t, 0.0, beats[0].BPM, "the tempo is unknown at first")
+		assert.InDelta(t, 120, beats[7].BPM, 1e-9)
+	}
+}
+
+func TestBeatDetector_MinIntervalAndSilence(t *testing.T) {
+	const sampleRate, n = 8000, 400
+	d := newBeatDetector(c.BeatConfig{Enabled: true, Sensitivity: 1.5, MinInterval: 300 * time.Millisecond}, sampleRate, n)
+	start := time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC)
+
+	var beats []int
+	for i := range 40 {
+		// the second kick follows after 200ms only
+		buffer := kickBuffer(i == 30 || i == 34, sampleRate, n)
+		if _, ok := d.process(buffer, buffer, start.Add(time.Duration(i)*50*time.Millisecond)); ok {
+			beats = append(beats, i)
+		}
+	}
+	assert.Equal(t, []int{30}, beats)
+
+	// Silence is never a beat.
+	silence := make([]float32, n)
+	for i := range 40 {
+		_, ok := d.process(silence, silence, start.Add(time.Duration(40+i)*50*time.Millisecond))
+		assert.False(t, ok)
+	}
+}
+
+func TestBeatDetector_bpmFolding(t *testing.T) {
+	d := &beatDetector{}
+	for range 4 {
+		d.intervals = append(d.intervals, 1500*time.Millisecond) // 40 BPM
+	}
+	assert.InDelta(t, 80, d.bpm(), 1e-9)
+	d.intervals = []time.Duration{300 * time.Millisecond, 300 * time.Millisecond, 300 * time.Millisecond, 2 * time.Second}
+	assert.InDelta(t, 100, d.bpm(), 1e-9)
+	assert.Equal(t, 0.0, (&beatDetector{intervals: d.intervals[:3]}).bpm())
+}
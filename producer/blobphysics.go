@@ -0,0 +1,138 @@
+package producer
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"time"
+)
+
+// The physics of the MultiBlobProducer: the blobs keep their momentum,
+// slow down by friction, get random kicks and bounce off each other
+// and the ends of the strip in elastic collisions. Blobs colliding
+// slowly merge and split again later. Speeds are in LEDs per step.
+
+// velocity returns the signed speed of the blob.
+func (s *Blob) velocity() float64 {
+	return s.delta * s.dir
+}
+
+func (s *Blob) setVelocity(v float64) {
+	s.delta = math.Abs(v)
+	s.dir = 1
+	if v < 0 {
+		s.dir = -1
+	}
+}
+
+// radius is the distance from the centre at which the blob is at 1/e
+// of its brightness, blobs touch at the sum of their radii.
+func (s *Blob) radius() float64 {
+	return math.Sqrt(s.width)
+}
+
+// stepPhysics moves the blobs on by one step at now.
+func (s *MultiBlobProducer) stepPhysics(now time.Time) {
+	dt := s.delay.Seconds()
+	last := float64(len(s.leds) - 1)
+	for _, blob := range s.allblobs {
+		v := blob.velocity()
+		if s.physics.Impulse > 0 {
+			v += (2*s.rng.Float64() - 1) * s.physics.Impulse * dt
+		}
+		if s.physics.Friction > 0 {
+			v *= math.Pow(1-s.physics.Friction, dt)
+		}
+		blob.x += v
+		// The ends of the strip reflect the blobs.
+		if blob.x < 0 {
+			blob.x, v = -blob.x, math.Abs(v)
+		} else if blob.x > last {
+			blob.x, v = 2*last-blob.x, -math.Abs(v)
+		}
+		blob.setVelocity(v)
+	}
+	s.collideBlobs(now)
+	if s.physics.SplitAfter > 0 {
+		for uid, blob := range s.allblobs {
+			if len(blob.parts) == 2 && now.Sub(blob.mergedAt) >= s.physics.SplitAfter {
+				delete(s.allblobs, uid)
+				for _, part := range splitBlob(blob, s.physics.MergeSpeed) {
+					s.allblobs[part.uid] = part
+				}
+			}
+		}
+	}
+}
+
+// collideBlobs handles the collisions of neighbouring blobs that
+// touch or passed each other during the last step while approaching.
+func (s *MultiBlobProducer) collideBlobs(now time.Time) {
+	blobs := make([]*Blob, 0, len(s.allblobs))
+	for _, blob := range s.allblobs {
+		blobs = append(blobs, blob)
+	}
+	// Ordered by the positions before the step, a changed order means
+	// the blobs passed each other.
+	slices.SortFunc(blobs, func(a, b *Blob) int {
+		return cmp.Or(cmp.Compare(a.last_x, b.last_x), cmp.Compare(a.uid, b.uid))
+	})
+	for i := 0; i+1 < len(blobs); i++ {
+		left, right := blobs[i], blobs[i+1]
+		approaching := left.velocity() - right.velocity()
+		if right.x-left.x >= left.radius()+right.radius() || approaching <= 0 {
+			continue
+		}
+		if approaching < s.physics.MergeSpeed {
+			merged := mergeBlobs(left, right, now)
+			delete(s.allblobs, left.uid)
+			delete(s.allblobs, right.uid)
+			s.allblobs[merged.uid] = merged
+			// The merged blob may hit the next one.
+			blobs[i+1] = merged
+			continue
+		}
+		// An elastic collision exchanges momentum, the blobs go back
+		// to where they were before they passed each other.
+		m1, m2 := left.mass, right.mass
+		v1, v2 := left.velocity(), right.velocity()
+		left.setVelocity(((m1-m2)*v1 + 2*m2*v2) / (m1 + m2))
+		right.setVelocity(((m2-m1)*v2 + 2*m1*v1) / (m1 + m2))
+		if right.x < left.x {
+			left.x, right.x = left.last_x, right.last_x
+		}
+	}
+}
+
+// mergeBlobs combines two blobs into one with their mass and momentum
+// at their centre of mass, coloured by the mix of both.
+func mergeBlobs(a, b *Blob, now time.Time) *Blob {
+	m := a.mass + b.mass
+	x := (a.x*a.mass + b.x*b.mass) / m
+	merged := &Blob{
+		uid:      a.uid + "+" + b.uid,
+		led:      InterpolateOKLab(a.led, b.led, b.mass/m),
+		last_x:   x,
+		x:        x,
+		width:    a.width + b.width,
+		mass:     m,
+		parts:    []*Blob{a, b},
+		mergedAt: now,
+	}
+	merged.setVelocity((a.velocity()*a.mass + b.velocity()*b.mass) / m)
+	return merged
+}
+
+// splitBlob returns the parts of a merged blob, separating with speed
+// around the centre of mass. Momentum is conserved.
+func splitBlob(merged *Blob, speed float64) []*Blob {
+	a, b := merged.parts[0], merged.parts[1]
+	m := a.mass + b.mass
+	d := a.radius() + b.radius()
+	v := merged.velocity()
+	a.x, b.x = merged.x-d*b.mass/m, merged.x+d*a.mass/m
+	a.last_x, b.last_x = a.x, b.x
+	a.setVelocity(v - speed*b.mass/m)
+	b.setVelocity(v + speed*a.mass/m)
+	return []*Blob{a, b}
+}
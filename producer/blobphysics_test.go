@@ -0,0 +1,149 @@
+package producer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	c "lautenbacher.net/goleds/config"
+	u "lautenbacher.net/goleds/util"
+)
+
+// newPhysicsProducer creates a MultiBlobProducer on 50 LEDs with a step
+// of 100ms.
+func newPhysicsProducer(physics c.MultiBlobPhysicsConfig, blobCfg ...c.BlobCfg) *MultiBlobProducer {
+	physics.Enabled = true
+	p := NewMultiBlobProducer("multi", u.NewAtomicMapEvent[LedProducer](), 50, time.Minute, 100*time.Millisecond, blobCfg, nil)
+	p.SetPhysics(physics)
+	p.resetBlobs()
+	return p
+}
+
+// totalMomentum sums up mass times velocity of all blobs.
+func totalMomentum(p *MultiBlobProducer) float64 {
+	momentum := 0.0
+	for _, blob := range p.allblobs {
+		momentum += blob.mass * blob.velocity()
+	}
+	return momentum
+}
+
+// step moves the blobs on like the runner.
+func step(p *MultiBlobProducer, now time.Time) {
+	p.stepPhysics(now)
+	for _, blob := range p.allblobs {
+		blob.last_x = blob.x
+	}
+}
+
+func TestBlobPhysics_ElasticCollision(t *testing.T) {
+	now := time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC)
+	// Equal masses exchange their velocities.
+	p := newPhysicsProducer(c.MultiBlobPhysicsConfig{},
+		c.BlobCfg{X: 20, Width: 1, DeltaX: 0.5, LedRGB: []float64{255, 0, 0}},
+		c.BlobCfg{X: 22, Width: 1, DeltaX: -0.5, LedRGB: []float64{0, 0, 255}})
+	for range 3 {
+		step(p, now)
+	}
+	assert.Equal(t, -0.5, p.allblobs["Blob_0"].velocity())
+	assert.Equal(t, 0.5, p.allblobs["Blob_1"].velocity())
+
+	// A heavy blob pushes a light one away, the momentum is conserved.
+	p = newPhysicsProducer(c.MultiBlobPhysicsConfig{},
+		c.BlobCfg{X: 20, Width: 1, Mass: 3, DeltaX: 0.5, LedRGB: []float64{255, 0, 0}},
+		c.BlobCfg{X: 22, Width: 1, Mass: 1, DeltaX: 0, LedRGB: []float64{0, 0, 255}})
+	momentum := totalMomentum(p)
+	for range 3 {
+		step(p, now)
+	}
+	assert.InDelta(t, 0.25, p.allblobs["Blob_0"].velocity(), 1e-9)
+	assert.InDelta(t, 0.75, p.allblobs["Blob_1"].velocity(), 1e-9)
+	assert.InDelta(t, momentum, totalMomentum(p), 1e-9)
+}
+
+func TestBlobPhysics_WallsAndFriction(t *testing.T) {
+	now := time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC)
+	p := newPhysicsProducer(c.MultiBlobPhysicsConfig{},
+		c.BlobCfg{X: 48, Width: 1, DeltaX: 1.5, LedRGB: []float64{255, 0, 0}})
+	step(p, now)
+	blob := p.allblobs["Blob_0"]
+	assert.Equal(t, 48.5, blob.x, "reflected at LED 49")
+	assert.Equal(t, -1.5, blob.velocity())
+
+	// Friction 0.5 halves the speed in one second (10 steps).
+	p = newPhysicsProducer(c.MultiBlobPhysicsConfig{Friction: 0.5},
+		c.BlobCfg{X: 10, Width: 1, DeltaX: 0.4, LedRGB: []float64{255, 0, 0}})
+	for range 10 {
+		step(p, now)
+	}
+	assert.InDelta(t, 0.2, p.allblobs["Blob_0"].velocity(), 1e-9)
+
+	// Random impulses change the speed by at most Impulse per second.
+	p = newPhysicsProducer(c.MultiBlobPhysicsConfig{Impulse: 1},
+		c.BlobCfg{X: 10, Width: 1, DeltaX: 0.4, LedRGB: []float64{255, 0, 0}})
+	step(p, now)
+	assert.NotEqual(t, 0.4, p.allblobs["Blob_0"].velocity())
+	assert.InDelta(t, 0.4, p.allblobs["Blob_0"].velocity(), 0.1)
+}
+
+func TestBlobPhysics_MergeAndSplit(t *testing.T) {
+	now := time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC)
+	p := newPhysicsProducer(c.MultiBlobPhysicsConfig{MergeSpeed: 0.5, SplitAfter: time.Second},
+		c.BlobCfg{X: 20, Width: 1, DeltaX: 0.3, LedRGB: []float64{255, 0, 0}},
+		c.BlobCfg{X: 22, Width: 1, DeltaX: 0.1, LedRGB: []float64{0, 0, 255}})
+	momentum := totalMomentum(p)
+	for range 10 {
+		step(p, now)
+		if len(p.allblobs) == 1 {
+			break
+		}
+	}
+	require.Len(t, p.allblobs, 1)
+	merged := p.allblobs["Blob_0+Blob_1"]
+	require.NotNil(t, merged)
+	assert.Equal(t, 2.0, merged.mass)
+	assert.InDelta(t, momentum, totalMomentum(p), 1e-9)
+	assert.Greater(t, merged.led.Red, 0.0, "the colours are mixed")
+	assert.Greater(t, merged.led.Blue, 0.0, "the colours are mixed")
+
+	// The merged blob splits into the original blobs after SplitAfter.
+	step(p, now.Add(time.Second))
+	require.Len(t, p.allblobs, 2)
+	left, right := p.allblobs["Blob_0"], p.allblobs["Blob_1"]
+	assert.Equal(t, Led{255, 0, 0}, left.led)
+	assert.Less(t, left.x, right.x)
+	assert.InDelta(t, 0.5, right.velocity()-left.velocity(), 1e-9, "they separate at MergeSpeed")
+	assert.InDelta(t, momentum, totalMomentum(p), 1e-9)
+}
+
+func TestBlobPhysics_SpawnAtTrigger(t *testing.T) {
+	p := newPhysicsProducer(c.MultiBlobPhysicsConfig{SpawnAtTrigger: true},
+		c.BlobCfg{X: 10, Width: 1, DeltaX: 0.5, LedRGB: []float64{255, 0, 0}},
+		c.BlobCfg{X: 40, Width: 1, DeltaX: -0.5, LedRGB: []float64{0, 0, 255}})
+	// Without a trigger the blobs start at X.
+	assert.Equal(t, 10.0, p.allblobs["Blob_0"].x)
+
+	var spawnable Spawnable = p
+	spawnable.SetSpawnLed(25)
+	p.resetBlobs()
+	assert.Equal(t, 25.0, p.allblobs["Blob_0"].x)
+	assert.Equal(t, 25.0, p.allblobs["Blob_1"].x)
+}
+
+func TestBlobPhysics_Seed(t *testing.T) {
+	now := time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC)
+	run := func(p *MultiBlobProducer) float64 {
+		p.resetBlobs()
+		for i := range 20 {
+			step(p, now.Add(time.Duration(i)*100*time.Millisecond))
+		}
+		return p.allblobs["Blob_0"].x
+	}
+	blob := c.BlobCfg{X: 25, Width: 1, DeltaX: 0.1, LedRGB: []float64{255, 0, 0}}
+	p := newPhysicsProducer(c.MultiBlobPhysicsConfig{Impulse: 2, Seed: 42}, blob)
+	// Every run with the same seed takes the same random impulses.
+	x := run(p)
+	assert.Equal(t, x, run(p))
+	assert.NotEqual(t, x, run(newPhysicsProducer(c.MultiBlobPhysicsConfig{Impulse: 2, Seed: 7}, blob)))
+}
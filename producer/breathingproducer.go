@@ -0,0 +1,40 @@
+package producer
+
+import (
+	"math"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	c "lautenbacher.net/goleds/config"
+	u "lautenbacher.net/goleds/util"
+)
+
+// breathingEffect dims all LEDs up and down. The exponential of a sine
+// rests longer at the dim end and looks more natural than a plain sine.
+type breathingEffect struct {
+	cfg   c.BreathingLEDConfig
+	color Led
+}
+
+// NewBreathingProducer creates the BreathingLED producer. endwg is only
+// used with AfterSensor.
+func NewBreathingProducer(uid string, ledsChanged *u.AtomicMapEvent[LedProducer], ledsTotal int, cfg c.BreathingLEDConfig, endwg *sync.WaitGroup) *AmbientProducer {
+	effect := &breathingEffect{cfg: cfg, color: Led{cfg.LedRGB[0], cfg.LedRGB[1], cfg.LedRGB[2]}}
+	return newAmbientProducer(uid, ledsChanged, ledsTotal, cfg.AmbientConfig, effect, endwg)
+}
+
+// brightness returns the brightness (MinBrightness..1) at elapsed, a
+// breath starts and ends at MinBrightness.
+func (s *breathingEffect) brightness(elapsed time.Duration) float64 {
+	phase := 2 * math.Pi * float64(elapsed%s.cfg.Period) / float64(s.cfg.Period)
+	f := (math.Exp(-math.Cos(phase)) - 1/math.E) / (math.E - 1/math.E)
+	return s.cfg.MinBrightness + (1-s.cfg.MinBrightness)*f
+}
+
+func (s *breathingEffect) step(frame []Led, rng *rand.Rand, elapsed, dt time.Duration) {
+	b := s.brightness(elapsed)
+	for k := range frame {
+		frame[k] = Led{s.color.Red * b, s.color.Green * b, s.color.Blue * b}
+	}
+}
@@ -0,0 +1,33 @@
+package producer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	c "lautenbacher.net/goleds/config"
+	u "lautenbacher.net/goleds/util"
+)
+
+func TestBreathingProducer_brightness(t *testing.T) {
+	cfg := c.BreathingLEDConfig{
+		AmbientConfig: c.AmbientConfig{StartLed: 0, EndLed: 4, Delay: 50 * time.Millisecond},
+		LedRGB:        []float64{100, 0, 0},
+		Period:        4 * time.Second,
+		MinBrightness: 0.2,
+	}
+	p := NewBreathingProducer("breathing", u.NewAtomicMapEvent[LedProducer](), 5, cfg, nil)
+	effect := p.effect.(*breathingEffect)
+
+	assert.InDelta(t, 0.2, effect.brightness(0), 1e-9)
+	assert.InDelta(t, 1, effect.brightness(2*time.Second), 1e-9)
+	assert.InDelta(t, 0.2, effect.brightness(4*time.Second), 1e-9)
+	// It rests longer at the dim end.
+	assert.Less(t, effect.brightness(time.Second), 0.6)
+	assert.InDelta(t, effect.brightness(time.Second), effect.brightness(3*time.Second), 1e-9)
+
+	p.effect.step(p.frame, p.rng, 2*time.Second, cfg.Delay)
+	for _, led := range p.frame {
+		assert.InDelta(t, 100, led.Red, 1e-9)
+	}
+}
@@ -0,0 +1,63 @@
+package producer
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	c "lautenbacher.net/goleds/config"
+	u "lautenbacher.net/goleds/util"
+)
+
+const (
+	// candleCalm is the probability per second that a flickering
+	// candle calms down again.
+	candleCalm = 2.0
+	// candleFollow is the share of the way to its target brightness
+	// a candle goes per step.
+	candleFollow = 0.3
+)
+
+// candleEffect lets every LED flicker on its own: the brightness
+// follows a target that jumps to random values now and then.
+type candleEffect struct {
+	cfg        c.CandleLEDConfig
+	color      Led
+	brightness []float64
+	target     []float64
+}
+
+// NewCandleProducer creates the CandleLED producer. endwg is only used
+// with AfterSensor.
+func NewCandleProducer(uid string, ledsChanged *u.AtomicMapEvent[LedProducer], ledsTotal int, cfg c.CandleLEDConfig, endwg *sync.WaitGroup) *AmbientProducer {
+	effect := &candleEffect{cfg: cfg, color: Led{cfg.LedRGB[0], cfg.LedRGB[1], cfg.LedRGB[2]}}
+	return newAmbientProducer(uid, ledsChanged, ledsTotal, cfg.AmbientConfig, effect, endwg)
+}
+
+func (s *candleEffect) reset() {
+	s.brightness, s.target = nil, nil
+}
+
+func (s *candleEffect) step(frame []Led, rng *rand.Rand, elapsed, dt time.Duration) {
+	if len(s.brightness) != len(frame) {
+		s.brightness = make([]float64, len(frame))
+		s.target = make([]float64, len(frame))
+		for k := range frame {
+			s.brightness[k], s.target[k] = 1, 1
+		}
+	}
+	calm := candleCalm * dt.Seconds()
+	for k := range frame {
+		switch r := rng.Float64(); {
+		case r < calm:
+			s.target[k] = 1
+		case r < 2*calm:
+			// Small flickers are more frequent than deep ones.
+			f := rng.Float64()
+			s.target[k] = 1 - s.cfg.Flicker*f*f
+		}
+		s.brightness[k] += (s.target[k] - s.brightness[k]) * candleFollow
+		b := s.brightness[k]
+		frame[k] = Led{s.color.Red * b, s.color.Green * b, s.color.Blue * b}
+	}
+}
@@ -0,0 +1,33 @@
+package producer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	c "lautenbacher.net/goleds/config"
+	u "lautenbacher.net/goleds/util"
+)
+
+func TestCandleProducer_step(t *testing.T) {
+	cfg := c.CandleLEDConfig{
+		AmbientConfig: c.AmbientConfig{StartLed: 0, EndLed: 9, Delay: 50 * time.Millisecond, Seed: 1},
+		LedRGB:        []float64{200, 100, 0},
+		Flicker:       0.5,
+	}
+	p := NewCandleProducer("candle", u.NewAtomicMapEvent[LedProducer](), 10, cfg, nil)
+	lowest, distinct := 200.0, false
+	for range 200 {
+		frame := stepAmbient(p, 1)
+		for _, led := range frame {
+			// The colour stays, only the brightness flickers.
+			assert.InDelta(t, led.Red/2, led.Green, 1e-9)
+			assert.LessOrEqual(t, led.Red, 200.0)
+			assert.GreaterOrEqual(t, led.Red, 100.0)
+			lowest = min(lowest, led.Red)
+			distinct = distinct || led != frame[0]
+		}
+	}
+	assert.Less(t, lowest, 150.0, "the candles flicker")
+	assert.True(t, distinct, "every candle flickers on its own")
+}
@@ -51,7 +51,7 @@ func NewClockProducer(uid string, ledsChanged *u.AtomicMapEvent[LedProducer], le
 
 func (s *ClockProducer) setTime() {
 	clear(s.leds)
-	now := time.Now()
+	now := s.clock.Now()
 	hour := now.Hour() % 12
 	minute := now.Minute()
 	s.setLed(s.hour_start+int(math.Round(float64(hour*60+minute)*s.hour_dist)), s.hour)
@@ -67,10 +67,11 @@ func (s *ClockProducer) runner() {
 	s.setTime()
 	s.ledsChanged.Send(s.GetUID(), s)
 
-	ticker := time.NewTicker(1 * time.Minute)
+	ticker := s.clock.NewTicker(1 * time.Minute)
+	defer ticker.Stop()
 	for {
 		select {
-		case <-ticker.C:
+		case <-ticker.C():
 			s.setTime()
 			s.ledsChanged.Send(s.GetUID(), s)
 		case <-s.stopchan:
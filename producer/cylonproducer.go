@@ -2,21 +2,49 @@ package producer
 
 import (
 	"math"
+	"strings"
 	"sync"
 	"time"
 
 	u "lautenbacher.net/goleds/util"
 )
 
+// cylonTrailFade is the brightness left at the end of the trail.
+const cylonTrailFade = 0.05
+
+// cylonEasings map the linear position (0..1) between the ends of the
+// strip to the eased one. Linear needs no easing.
+var cylonEasings = map[string]func(float64) float64{
+	// Sine slows down towards the ends like a pendulum.
+	"SINE": func(p float64) float64 { return 0.5 - 0.5*math.Cos(math.Pi*p) },
+	// Cubic stays longer at the ends and moves faster in the middle.
+	"CUBIC": func(p float64) float64 {
+		if p < 0.5 {
+			return 4 * p * p * p
+		}
+		return 1 - 4*(1-p)*(1-p)*(1-p)
+	},
+}
+
 type CylonProducer struct {
 	*AbstractProducer
+	// distance is the way travelled by the first eye, the eyes bounce
+	// at the ends of the strip.
+	distance float64
+	// x and direction move the single eye of the default settings.
 	x         float64
+	direction int
 	step      float64
 	radius    int
-	direction int
 	color     Led
+	defColor  Led
 	duration  time.Duration
 	delay     time.Duration
+	beats     BeatSource
+	eyes      int
+	hueShift  float64
+	trail     float64
+	easing    func(float64) float64 // nil for linear
 }
 
 func NewCylonProducer(uid string, ledsChanged *u.AtomicMapEvent[LedProducer], ledsTotal int, duration time.Duration, delay time.Duration, step float64, width int, ledRGB []float64, endwg *sync.WaitGroup) *CylonProducer {
@@ -27,12 +55,13 @@ func NewCylonProducer(uid string, ledsChanged *u.AtomicMapEvent[LedProducer], le
 			Blue:  ledRGB[2],
 		},
 		step:      step,
-		x:         0,
-		direction: 1,
 		duration:  duration,
 		delay:     delay,
+		eyes:      1,
+		direction: 1,
 	}
 	inst.radius = width / 2
+	inst.defColor = inst.color
 	inst.AbstractProducer = NewAbstractProducer(uid, ledsChanged, inst.runner, ledsTotal)
 	if endwg != nil {
 		inst.AbstractProducer.endWg = endwg
@@ -41,11 +70,144 @@ func NewCylonProducer(uid string, ledsChanged *u.AtomicMapEvent[LedProducer], le
 	return inst
 }
 
+// SetColor implements Recolorable, it takes effect with the next step.
+func (s *CylonProducer) SetColor(rgb []float64) {
+	s.ledsMutex.Lock()
+	defer s.ledsMutex.Unlock()
+	s.color = s.defColor
+	if rgb != nil {
+		s.color = Led{Red: rgb[0], Green: rgb[1], Blue: rgb[2]}
+	}
+}
+
+// SetBeatSource makes the speed follow the tempo of the beats, scaled
+// by BPM/120. It must be called before Start().
+func (s *CylonProducer) SetBeatSource(beats BeatSource) {
+	s.beats = beats
+}
+
+// SetEyes sets the number of eyes, they are spread evenly over the way
+// forth and back. It must be called before Start().
+func (s *CylonProducer) SetEyes(eyes int) {
+	s.eyes = max(eyes, 1)
+}
+
+// SetHueShift turns the hue of an eye by degrees at every bounce. It
+// must be called before Start().
+func (s *CylonProducer) SetHueShift(degrees float64) {
+	s.hueShift = degrees
+}
+
+// SetTrail lets the eyes leave a trail fading over length LEDs at full
+// speed, 0 means no trail. It must be called before Start().
+func (s *CylonProducer) SetTrail(length float64) {
+	s.trail = length
+}
+
+// SetEasing selects how the eyes move between the ends: Linear (the
+// default), Sine or Cubic. It must be called before Start().
+func (s *CylonProducer) SetEasing(easing string) {
+	s.easing = cylonEasings[strings.ToUpper(easing)]
+}
+
+// classic reports whether the producer has the default settings: a
+// single eye moving linearly without hue shift and trail.
+func (s *CylonProducer) classic() bool {
+	return s.eyes == 1 && s.hueShift == 0 && s.trail == 0 && s.easing == nil
+}
+
+// move advances the eyes by one step of speed.
+func (s *CylonProducer) move(speed float64) {
+	if s.classic() {
+		// The eye overshoots an end by up to one step before it turns.
+		if s.x < 0 || s.x > float64(len(s.leds)-1) {
+			s.direction = -s.direction
+		}
+		s.x += float64(s.direction) * s.step * speed
+		return
+	}
+	s.distance += s.step * speed
+}
+
+// eyePosition returns the centre of an eye that has travelled distance
+// and the number of times it bounced.
+func (s *CylonProducer) eyePosition(distance float64) (float64, int) {
+	span := float64(len(s.leds) - 1)
+	if span <= 0 {
+		return 0, 0
+	}
+	bounces := int(math.Floor(distance / span))
+	p := distance/span - float64(bounces)
+	if bounces%2 == 1 {
+		p = 1 - p
+	}
+	if s.easing != nil {
+		p = s.easing(p)
+	}
+	return span * p, bounces
+}
+
+// render draws the eyes after the first one travelled s.distance, or
+// the single eye at s.x with the default settings. The trail fades by
+// the LEDs moved in one step of speed.
+func (s *CylonProducer) render(speed float64) {
+	s.ledsMutex.Lock()
+	defer s.ledsMutex.Unlock()
+	if s.trail > 0 {
+		ScaleLeds(s.leds, math.Pow(cylonTrailFade, s.step*speed/s.trail))
+	} else {
+		clear(s.leds)
+	}
+	if s.classic() {
+		s.drawEye(s.x, s.color)
+		return
+	}
+	span := float64(len(s.leds) - 1)
+	for k := range s.eyes {
+		x, bounces := s.eyePosition(s.distance + 2*span*float64(k)/float64(s.eyes))
+		color := s.color
+		if s.hueShift != 0 {
+			color = color.RotateHue(s.hueShift * float64(bounces))
+		}
+		s.drawEye(x, color)
+	}
+}
+
+// drawEye draws an eye at x above the LEDs, the LEDs at its edges are
+// dimmed by the part of them it covers.
+func (s *CylonProducer) drawEye(x float64, color Led) {
+	left := x - float64(s.radius)
+	right := x + float64(s.radius)
+	for i := range s.leds {
+		if i < int(left) || i > int(right+1) {
+			continue
+		}
+		f := 1.0
+		if i == int(math.Floor(left)) {
+			f = 1 - (left - float64(i))
+		} else if i == int(math.Floor(right+1)) {
+			f = 1 - (float64(i) - right)
+		}
+		led := Led{color.Red * f, color.Green * f, color.Blue * f}
+		s.leds[i] = led.Max(s.leds[i])
+	}
+}
+
 func (s *CylonProducer) runner() {
-	triggerduration := time.NewTicker(s.duration)
-	tick := time.NewTicker(s.delay)
+	triggerduration := s.clock.NewTicker(s.duration)
+	tick := s.clock.NewTicker(s.delay)
+	speed := 1.0
+	var beats *u.AtomicEvent[Beat]
+	var beatChan <-chan struct{}
+	if s.beats != nil {
+		beats = s.beats.SubscribeBeats(s.GetUID())
+		beatChan = beats.Channel()
+		defer s.beats.Unsubscribe(s.GetUID())
+	}
 	defer func() {
-		s.leds = make([]Led, len(s.leds)) // Reset LEDs
+		s.ledsMutex.Lock()
+		clear(s.leds) // Reset LEDs
+		s.ledsMutex.Unlock()
 		s.ledsChanged.Send(s.GetUID(), s)
 		tick.Stop()
 		triggerduration.Stop()
@@ -53,35 +215,17 @@ func (s *CylonProducer) runner() {
 
 	for {
 		select {
-		case <-triggerduration.C:
+		case <-triggerduration.C():
 			return
 		case <-s.stopchan:
 			return
-		case <-tick.C:
-			if s.x < 0 || s.x > float64(len(s.leds)-1) {
-				s.direction = -s.direction
-			}
-			s.x += float64(s.direction) * s.step
-			left := s.x - float64(s.radius)
-			right := s.x + float64(s.radius)
-			// log.Printf("x: %f, left: %f, right: %f\n", s.x, left, right)
-			s.ledsMutex.Lock()
-			for i := range s.leds {
-				if i < int(left) || i > int(right+1) {
-					s.leds[i] = Led{}
-				} else {
-					if i == int(math.Floor(left)) {
-						f := 1 - (left - float64(i))
-						s.leds[i] = Led{s.color.Red * f, s.color.Green * f, s.color.Blue * f}
-					} else if i == int(math.Floor(right+1)) {
-						f := 1 - (float64(i) - right)
-						s.leds[i] = Led{s.color.Red * f, s.color.Green * f, s.color.Blue * f}
-					} else {
-						s.leds[i] = s.color
-					}
-				}
+		case <-beatChan:
+			if bpm := beats.Value().BPM; bpm > 0 {
+				speed = bpm / 120
 			}
-			s.ledsMutex.Unlock()
+		case <-tick.C():
+			s.move(speed)
+			s.render(speed)
 			s.ledsChanged.Send(s.GetUID(), s)
 		}
 	}
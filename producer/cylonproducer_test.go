@@ -74,3 +74,88 @@ func TestCylonProducer_Stop(t *testing.T) {
 		assert.True(t, led.IsEmpty())
 	}
 }
+
+func TestCylonProducer_eyePosition(t *testing.T) {
+	p := NewCylonProducer("test", u.NewAtomicMapEvent[LedProducer](), 21, time.Second, 10*time.Millisecond, 1, 4, []float64{255, 0, 0}, nil)
+	tests := []struct {
+		distance float64
+		x        float64
+		bounces  int
+	}{
+		{0, 0, 0},
+		{5, 5, 0},
+		{20, 20, 1},
+		{25, 15, 1},
+		{40, 0, 2},
+		{43, 3, 2},
+	}
+	for _, tc := range tests {
+		x, bounces := p.eyePosition(tc.distance)
+		assert.InDelta(t, tc.x, x, 1e-9, "distance %v", tc.distance)
+		assert.Equal(t, tc.bounces, bounces, "distance %v", tc.distance)
+	}
+
+	// Sine easing slows down at the ends, the middle stays the same.
+	p.SetEasing("sine")
+	x, _ := p.eyePosition(2)
+	assert.Less(t, x, 1.0)
+	x, _ = p.eyePosition(10)
+	assert.InDelta(t, 10, x, 1e-9)
+	x, _ = p.eyePosition(38)
+	assert.Less(t, x, 2.0, "the eye slows down on its way back, too")
+}
+
+func TestCylonProducer_render(t *testing.T) {
+	p := NewCylonProducer("test", u.NewAtomicMapEvent[LedProducer](), 21, time.Second, 10*time.Millisecond, 1, 2, []float64{255, 0, 0}, nil)
+	leds := make([]Led, 21)
+
+	// Two eyes start at opposite ends.
+	p.SetEyes(2)
+	p.distance = 5
+	p.render(1)
+	p.GetLeds(leds)
+	assert.Equal(t, Led{255, 0, 0}, leds[5])
+	assert.Equal(t, Led{255, 0, 0}, leds[15])
+	assert.True(t, leds[10].IsEmpty())
+
+	// The hue turns at every bounce: the second eye has bounced once.
+	p.SetHueShift(120)
+	p.render(1)
+	p.GetLeds(leds)
+	assert.Equal(t, Led{255, 0, 0}, leds[5])
+	assertLed(t, Led{0, 255, 0}, leds[15], 1e-9)
+
+	// The trail fades to cylonTrailFade after trail LEDs.
+	p.SetEyes(1)
+	p.SetHueShift(0)
+	p.SetTrail(4)
+	for range 8 {
+		p.distance++
+		p.render(1)
+	}
+	p.GetLeds(leds)
+	assert.Equal(t, Led{255, 0, 0}, leds[13])
+	// LED 8 was last covered fully by the eye at 9, 4 steps ago.
+	assert.InDelta(t, 255*cylonTrailFade, leds[8].Red, 1e-9)
+	assert.Greater(t, leds[9].Red, leds[8].Red)
+	assert.True(t, leds[0].Red < 1)
+}
+
+func TestCylonProducer_classicMotion(t *testing.T) {
+	// With the default settings, the eye overshoots the end by up to one
+	// step before it turns.
+	p := NewCylonProducer("test", u.NewAtomicMapEvent[LedProducer](), 21, time.Second, 10*time.Millisecond, 3, 2, []float64{255, 0, 0}, nil)
+	for range 7 {
+		p.move(1)
+	}
+	assert.Equal(t, 21.0, p.x)
+	p.move(1)
+	assert.Equal(t, 18.0, p.x)
+	assert.Zero(t, p.distance)
+
+	// Other settings bounce exactly at the ends.
+	p.SetEasing("sine")
+	p.move(1)
+	assert.Equal(t, 18.0, p.x)
+	assert.Equal(t, 3.0, p.distance)
+}
@@ -0,0 +1,196 @@
+package producer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	c "lautenbacher.net/goleds/config"
+	u "lautenbacher.net/goleds/util"
+)
+
+const (
+	// maxDocumentSize limits the size of a document read from a file
+	// or URL.
+	maxDocumentSize = 1 << 20
+	// mqttRetryDelay is the time between two connection attempts to
+	// the MQTT broker.
+	mqttRetryDelay = 30 * time.Second
+	mqttKeepAlive  = 60 * time.Second
+)
+
+// DataSource delivers the values a producer shows. Watch sends every
+// new value to updates until stop is closed.
+type DataSource[T any] interface {
+	Watch(stop <-chan struct{}, updates *u.AtomicEvent[T])
+}
+
+// NewDataSource creates the source selected in cfg, parse converts the
+// documents read into values. Polling sources wait on clock between
+// two readings. name is used in log messages.
+func NewDataSource[T any](name string, cfg c.DataSourceConfig, parse func([]byte) (T, error), clock u.Clock) DataSource[T] {
+	switch strings.ToUpper(cfg.Source) {
+	case "HTTP":
+		client := &http.Client{Timeout: 10 * time.Second}
+		return &pollingSource[T]{
+			name:     name,
+			fetch:    func() ([]byte, error) { return fetchURL(client, cfg.URL) },
+			interval: cfg.PollInterval,
+			parse:    parse,
+			clock:    clock,
+		}
+	case "MQTT":
+		m := cfg.MQTT
+		return &mqttSource[T]{
+			name: name,
+			opts: u.MQTTOptions{Broker: m.Broker, ClientID: m.ClientID, Username: m.Username,
+				Password: m.Password, Topic: m.Topic, KeepAlive: mqttKeepAlive},
+			parse: parse,
+			clock: clock,
+		}
+	default:
+		return &pollingSource[T]{
+			name:     name,
+			fetch:    func() ([]byte, error) { return os.ReadFile(cfg.File) },
+			interval: cfg.PollInterval,
+			parse:    parse,
+			clock:    clock,
+		}
+	}
+}
+
+// pollingSource reads a document every interval.
+type pollingSource[T any] struct {
+	name     string
+	fetch    func() ([]byte, error)
+	interval time.Duration
+	parse    func([]byte) (T, error)
+	clock    u.Clock
+}
+
+func (s *pollingSource[T]) Watch(stop <-chan struct{}, updates *u.AtomicEvent[T]) {
+	for {
+		data, err := s.fetch()
+		if err == nil {
+			var value T
+			if value, err = s.parse(data); err == nil {
+				updates.Send(value)
+			}
+		}
+		if err != nil {
+			slog.Error(s.name+": failed to read the data source", "error", err)
+		}
+		select {
+		case <-stop:
+			return
+		case <-s.clock.After(s.interval):
+		}
+	}
+}
+
+func fetchURL(client *http.Client, url string) ([]byte, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxDocumentSize))
+}
+
+// mqttSource parses the messages published on a topic and reconnects
+// to the broker after errors.
+type mqttSource[T any] struct {
+	name  string
+	opts  u.MQTTOptions
+	parse func([]byte) (T, error)
+	clock u.Clock
+}
+
+func (s *mqttSource[T]) Watch(stop <-chan struct{}, updates *u.AtomicEvent[T]) {
+	for {
+		sub, err := u.DialMQTT(s.opts)
+		if err != nil {
+			slog.Error(s.name+": failed to subscribe", "broker", s.opts.Broker, "topic", s.opts.Topic, "error", err)
+		} else {
+			done := make(chan struct{})
+			go func() {
+				select {
+				case <-stop:
+					sub.Close()
+				case <-done:
+				}
+			}()
+			s.receive(sub, updates)
+			close(done)
+			sub.Close()
+		}
+		select {
+		case <-stop:
+			return
+		case <-s.clock.After(mqttRetryDelay):
+		}
+	}
+}
+
+// receive parses the messages until the connection fails or is closed.
+func (s *mqttSource[T]) receive(sub *u.MQTTSubscriber, updates *u.AtomicEvent[T]) {
+	for {
+		topic, payload, err := sub.Next()
+		if err != nil {
+			slog.Warn(s.name+": MQTT connection lost", "error", err)
+			return
+		}
+		value, err := s.parse(payload)
+		if err != nil {
+			slog.Error(s.name+": invalid message", "topic", topic, "error", err)
+			continue
+		}
+		updates.Send(value)
+	}
+}
+
+// parseJSON decodes a JSON document for jsonNumber.
+func parseJSON(data []byte) (any, error) {
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid JSON document: %w", err)
+	}
+	return doc, nil
+}
+
+// jsonNumber follows a dotted path of object keys and array indices
+// through doc and returns the number at its end.
+func jsonNumber(doc any, path string) (float64, error) {
+	for _, key := range strings.Split(path, ".") {
+		switch node := doc.(type) {
+		case map[string]any:
+			value, ok := node[key]
+			if !ok {
+				return 0, fmt.Errorf("%s: no field %q", path, key)
+			}
+			doc = value
+		case []any:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(node) {
+				return 0, fmt.Errorf("%s: invalid index %q", path, key)
+			}
+			doc = node[index]
+		default:
+			return 0, fmt.Errorf("%s: %q is not an object or array", path, key)
+		}
+	}
+	number, ok := doc.(float64)
+	if !ok {
+		return 0, fmt.Errorf("%s is not a number", path)
+	}
+	return number, nil
+}
@@ -0,0 +1,32 @@
+package producer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONNumber(t *testing.T) {
+	doc, err := parseJSON([]byte(`{"job": {"progress": [{"completion": 42.5}]}}`))
+	require.NoError(t, err)
+	value, err := jsonNumber(doc, "job.progress.0.completion")
+	require.NoError(t, err)
+	assert.Equal(t, 42.5, value)
+
+	_, err = jsonNumber(doc, "job.state")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `no field "state"`)
+	}
+}
+
+func TestFetchURL_Status(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	_, err := fetchURL(server.Client(), server.URL)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "404")
+	}
+}
@@ -0,0 +1,52 @@
+package producer
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	c "lautenbacher.net/goleds/config"
+	u "lautenbacher.net/goleds/util"
+)
+
+// fireSparkZone is the number of LEDs at the bottom of the fire where
+// new sparks appear.
+const fireSparkZone = 7
+
+// fireEffect simulates the heat of the flames: every LED cools down,
+// the heat rises and diffuses, and new sparks ignite at the bottom.
+type fireEffect struct {
+	cfg  c.FireLEDConfig
+	heat []float64
+}
+
+// NewFireProducer creates the FireLED producer. endwg is only used
+// with AfterSensor.
+func NewFireProducer(uid string, ledsChanged *u.AtomicMapEvent[LedProducer], ledsTotal int, cfg c.FireLEDConfig, endwg *sync.WaitGroup) *AmbientProducer {
+	return newAmbientProducer(uid, ledsChanged, ledsTotal, cfg.AmbientConfig, &fireEffect{cfg: cfg}, endwg)
+}
+
+func (s *fireEffect) reset() {
+	s.heat = nil
+}
+
+func (s *fireEffect) step(frame []Led, rng *rand.Rand, elapsed, dt time.Duration) {
+	if len(s.heat) != len(frame) {
+		s.heat = make([]float64, len(frame))
+	}
+	heat := s.heat
+	for k := range heat {
+		heat[k] = max(heat[k]-rng.Float64()*s.cfg.Cooling, 0)
+	}
+	// The heat rises from every LED and diffuses a little.
+	for k := len(heat) - 1; k >= 2; k-- {
+		heat[k] = (heat[k-1] + 2*heat[k-2]) / 3
+	}
+	if rng.Float64() < s.cfg.Sparking {
+		k := rng.IntN(min(fireSparkZone, len(heat)))
+		heat[k] = min(heat[k]+0.6+0.4*rng.Float64(), 1)
+	}
+	for k, h := range heat {
+		frame[k] = gradientColor(s.cfg.Colors, h)
+	}
+}
@@ -0,0 +1,32 @@
+package producer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	u "lautenbacher.net/goleds/util"
+)
+
+func TestFireProducer_step(t *testing.T) {
+	cfg := fireConfig
+	cfg.Cooling = 0
+	cfg.Sparking = 1
+	p := NewFireProducer("fire", u.NewAtomicMapEvent[LedProducer](), 20, cfg, nil)
+	effect := p.effect.(*fireEffect)
+
+	// Without cooling the sparks heat up the bottom and the heat rises.
+	frame := stepAmbient(p, 5)
+	assert.Greater(t, effect.heat[0]+effect.heat[1], 0.5)
+	stepAmbient(p, 30)
+	assert.Greater(t, effect.heat[15], 0.0, "the heat rises")
+	for k, heat := range effect.heat {
+		assert.LessOrEqual(t, heat, 1.0)
+		assert.Equal(t, gradientColor(cfg.Colors, heat), frame[k], "LED %d", k)
+	}
+
+	// Without sparks the fire goes out.
+	effect.cfg.Cooling = 1
+	effect.cfg.Sparking = 0
+	frame = stepAmbient(p, 100)
+	assert.Equal(t, make([]Led, 20), frame)
+}
@@ -0,0 +1,188 @@
+// This producer shows a value between a minimum and a maximum as a bar
+// (build progress, 3D printer completion, a sensor reading, ...). The
+// values come from POST /api/gauge or a DataSource. The bar is coloured
+// by a gradient along its length or, once the value reaches a
+// threshold, in the threshold's colour. New values are approached in a
+// smooth transition.
+
+package producer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	c "lautenbacher.net/goleds/config"
+	u "lautenbacher.net/goleds/util"
+)
+
+// gaugeBlinkPeriod is the period of blinking thresholds.
+const gaugeBlinkPeriod = time.Second
+
+type GaugeProducer struct {
+	*AbstractProducer
+	cfg    c.GaugeLEDConfig
+	source DataSource[float64]
+	values *u.AtomicEvent[float64]
+	// The transition from from to target began at changed. The values
+	// are kept while the producer is stopped during sensor events.
+	from    float64
+	target  float64
+	changed time.Time
+	known   bool
+}
+
+func NewGaugeProducer(uid string, ledsChanged *u.AtomicMapEvent[LedProducer], ledsTotal int, cfg c.GaugeLEDConfig) *GaugeProducer {
+	inst := &GaugeProducer{
+		cfg:    cfg,
+		values: u.NewAtomicEvent[float64](),
+	}
+	inst.AbstractProducer = NewAbstractProducer(uid, ledsChanged, inst.runner, ledsTotal)
+	return inst
+}
+
+// SetValue shows a new value, also while the producer is stopped.
+func (s *GaugeProducer) SetValue(value float64) {
+	s.values.Send(value)
+}
+
+// Value returns the last value set or read from the source.
+func (s *GaugeProducer) Value() float64 {
+	return s.values.Value()
+}
+
+// SetSource replaces the source selected in the config. It must be
+// called before Start().
+func (s *GaugeProducer) SetSource(source DataSource[float64]) {
+	s.source = source
+}
+
+// parseGaugeValue reads the number at the dotted path field of a JSON
+// document, or the whole document as a number if field is empty.
+func parseGaugeValue(data []byte, field string) (float64, error) {
+	if field == "" {
+		value, err := strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid gauge value: %w", err)
+		}
+		return value, nil
+	}
+	doc, err := parseJSON(data)
+	if err != nil {
+		return 0, err
+	}
+	return jsonNumber(doc, field)
+}
+
+// current returns the value shown at now and whether the transition
+// to the target is still running. The transition eases in and out.
+func (s *GaugeProducer) current(now time.Time) (float64, bool) {
+	if s.cfg.Transition <= 0 {
+		return s.target, false
+	}
+	f := float64(now.Sub(s.changed)) / float64(s.cfg.Transition)
+	if f >= 1 {
+		return s.target, false
+	}
+	f = f * f * (3 - 2*f)
+	return s.from + f*(s.target-s.from), true
+}
+
+// threshold returns the highest threshold value reaches, nil if none.
+func (s *GaugeProducer) threshold(value float64) *c.GaugeThreshold {
+	var reached *c.GaugeThreshold
+	for i := range s.cfg.Thresholds {
+		if value >= s.cfg.Thresholds[i].Value {
+			reached = &s.cfg.Thresholds[i]
+		}
+	}
+	return reached
+}
+
+// render draws the bar for value. The last lit LED is dimmed by the
+// fraction of it that is filled.
+func (s *GaugeProducer) render(value float64, now time.Time) {
+	cfg := s.cfg
+	direction := 1
+	if cfg.EndLed < cfg.StartLed {
+		direction = -1
+	}
+	length := (cfg.EndLed-cfg.StartLed)*direction + 1
+	fill := (min(max(value, cfg.Min), cfg.Max) - cfg.Min) / (cfg.Max - cfg.Min) * float64(length)
+	threshold := s.threshold(value)
+	if threshold != nil && threshold.Blink && int64(now.Sub(s.changed)/(gaugeBlinkPeriod/2))%2 == 1 {
+		fill = 0
+	}
+
+	s.ledsMutex.Lock()
+	defer s.ledsMutex.Unlock()
+	for k := range length {
+		var color Led
+		if threshold != nil {
+			color = Led{threshold.LedRGB[0], threshold.LedRGB[1], threshold.LedRGB[2]}
+		} else {
+			// The colour of the value at the centre of the LED
+			color = gradientColor(cfg.Colors, cfg.Min+(float64(k)+0.5)/float64(length)*(cfg.Max-cfg.Min))
+		}
+		f := min(max(fill-float64(k), 0), 1)
+		s.leds[cfg.StartLed+k*direction] = Led{color.Red * f, color.Green * f, color.Blue * f}
+	}
+}
+
+func (s *GaugeProducer) runner() {
+	source := s.source
+	if source == nil && !strings.EqualFold(s.cfg.Source, "API") {
+		parse := func(data []byte) (float64, error) { return parseGaugeValue(data, s.cfg.Field) }
+		source = NewDataSource("GaugeLED", s.cfg.DataSourceConfig, parse, s.clock)
+	}
+	stop := make(chan struct{})
+	if source != nil {
+		go source.Watch(stop, s.values)
+	}
+	defer func() {
+		close(stop)
+		s.ledsMutex.Lock()
+		clear(s.leds) // Reset LEDs
+		s.ledsMutex.Unlock()
+		s.ledsChanged.Send(s.GetUID(), s)
+	}()
+
+	// A nil channel blocks forever: no steps while the bar stands still.
+	var step <-chan time.Time
+	update := func(stepped bool) {
+		now := s.clock.Now()
+		value, moving := s.current(now)
+		s.render(value, now)
+		s.ledsChanged.Send(s.GetUID(), s)
+		threshold := s.threshold(value)
+		if !moving && (threshold == nil || !threshold.Blink) {
+			step = nil
+		} else if stepped || step == nil {
+			step = s.clock.After(s.cfg.Delay)
+		}
+	}
+	// The last value is shown right away after a restart.
+	if s.known {
+		update(false)
+	}
+	for {
+		select {
+		case <-s.values.Channel():
+			now := s.clock.Now()
+			if s.known {
+				s.from, _ = s.current(now)
+			} else {
+				s.from = s.values.Value()
+			}
+			s.target = s.values.Value()
+			s.changed = now
+			s.known = true
+			update(false)
+		case <-step:
+			update(true)
+		case <-s.stopchan:
+			return
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package producer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	c "lautenbacher.net/goleds/config"
+	u "lautenbacher.net/goleds/util"
+)
+
+var gaugeConfig = c.GaugeLEDConfig{
+	DataSourceConfig: c.DataSourceConfig{Source: "API"},
+	Min:              0,
+	Max:              100,
+	StartLed:         10,
+	EndLed:           19,
+	Colors: [][]float64{
+		{0, 0, 200, 0},
+		{100, 200, 0, 0},
+	},
+	Delay: 50 * time.Millisecond,
+}
+
+func newTestGaugeProducer(cfg c.GaugeLEDConfig) *GaugeProducer {
+	return NewGaugeProducer("gauge", u.NewAtomicMapEvent[LedProducer](), 30, cfg)
+}
+
+func TestParseGaugeValue(t *testing.T) {
+	value, err := parseGaugeValue([]byte(" 42.5\n"), "")
+	require.NoError(t, err)
+	assert.Equal(t, 42.5, value)
+
+	value, err = parseGaugeValue([]byte(`{"progress": {"completion": 73}}`), "progress.completion")
+	require.NoError(t, err)
+	assert.Equal(t, 73.0, value)
+
+	_, err = parseGaugeValue([]byte("done"), "")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "invalid gauge value")
+	}
+}
+
+func TestGaugeProducer_render(t *testing.T) {
+	p := newTestGaugeProducer(gaugeConfig)
+	leds := make([]Led, 30)
+
+	// 45% of 10 LEDs: 4 full LEDs and half of the fifth one.
+	p.render(45, time.Time{})
+	p.GetLeds(leds)
+	assert.Equal(t, Led{10, 190, 0}, leds[10], "colour of the first LED")
+	assert.Equal(t, Led{70, 130, 0}, leds[13])
+	assert.Equal(t, Led{45, 55, 0}, leds[14], "half of the fifth LED")
+	for i, led := range leds {
+		if i < 10 || i > 14 {
+			assert.Equal(t, Led{}, led, "LED %d", i)
+		}
+	}
+
+	// Values are clamped to Min and Max.
+	p.render(150, time.Time{})
+	p.GetLeds(leds)
+	assert.Equal(t, Led{190, 10, 0}, leds[19])
+	p.render(-10, time.Time{})
+	p.GetLeds(leds)
+	assert.Equal(t, make([]Led, 30), leds)
+
+	// A reversed range fills from StartLed downwards.
+	cfg := gaugeConfig
+	cfg.StartLed, cfg.EndLed = 19, 10
+	p = newTestGaugeProducer(cfg)
+	p.render(20, time.Time{})
+	p.GetLeds(leds)
+	assert.Equal(t, Led{10, 190, 0}, leds[19])
+	assert.Equal(t, Led{30, 170, 0}, leds[18])
+	assert.Equal(t, Led{}, leds[17])
+}
+
+func TestGaugeProducer_renderThresholds(t *testing.T) {
+	cfg := gaugeConfig
+	cfg.Thresholds = []c.GaugeThreshold{
+		{Value: 50, LedRGB: []float64{255, 128, 0}},
+		{Value: 90, LedRGB: []float64{255, 0, 0}, Blink: true},
+	}
+	p := newTestGaugeProducer(cfg)
+	leds := make([]Led, 30)
+
+	p.render(60, time.Time{})
+	p.GetLeds(leds)
+	assert.Equal(t, Led{255, 128, 0}, leds[10])
+	assert.Equal(t, Led{255, 128, 0}, leds[15])
+
+	// The highest threshold reached wins and blinks.
+	p.render(100, time.Time{})
+	p.GetLeds(leds)
+	assert.Equal(t, Led{255, 0, 0}, leds[19])
+	p.render(100, time.Time{}.Add(gaugeBlinkPeriod/2))
+	p.GetLeds(leds)
+	assert.Equal(t, Led{}, leds[19])
+}
+
+func TestGaugeProducer_current(t *testing.T) {
+	cfg := gaugeConfig
+	cfg.Transition = time.Second
+	p := newTestGaugeProducer(cfg)
+	start := time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC)
+	p.from, p.target, p.changed = 20, 60, start
+
+	value, moving := p.current(start)
+	assert.Equal(t, 20.0, value)
+	assert.True(t, moving)
+	value, _ = p.current(start.Add(500 * time.Millisecond))
+	assert.InDelta(t, 40, value, 1e-9)
+	value, _ = p.current(start.Add(250 * time.Millisecond))
+	assert.Less(t, value, 30.0, "the transition eases in")
+	value, moving = p.current(start.Add(time.Second))
+	assert.Equal(t, 60.0, value)
+	assert.False(t, moving)
+}
+
+func TestGaugeProducer_Runner(t *testing.T) {
+	clock := u.NewFakeClock(time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC))
+	cfg := gaugeConfig
+	cfg.Transition = 200 * time.Millisecond
+	p := newTestGaugeProducer(cfg)
+	p.SetClock(clock)
+	p.Start()
+	defer p.Exit()
+	leds := make([]Led, 30)
+
+	// The first value is shown without a transition.
+	p.SetValue(100)
+	assert.Eventually(t, func() bool {
+		p.GetLeds(leds)
+		return !leds[19].IsEmpty()
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, 100.0, p.Value())
+
+	// The bar shrinks to the next value during the transition.
+	p.SetValue(50)
+	clock.BlockUntil(1)
+	p.GetLeds(leds)
+	assert.False(t, leds[19].IsEmpty())
+	assert.Eventually(t, func() bool {
+		if clock.Waiters() > 0 {
+			clock.Advance(cfg.Delay)
+		}
+		p.GetLeds(leds)
+		return leds[15].IsEmpty() && !leds[14].IsEmpty()
+	}, time.Second, time.Millisecond)
+
+	// After a restart the last value is shown right away.
+	stopped, err := p.TryStop()
+	assert.True(t, stopped)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		p.GetLeds(leds)
+		return leds[10].IsEmpty()
+	}, time.Second, time.Millisecond)
+	p.Start()
+	assert.Eventually(t, func() bool {
+		p.GetLeds(leds)
+		return !leds[14].IsEmpty() && leds[15].IsEmpty()
+	}, time.Second, time.Millisecond)
+}
@@ -0,0 +1,171 @@
+// This producer plays back an image authored in any image editor:
+// every row of a PNG or GIF is a frame, the columns are mapped to the
+// LEDs of the range. The frames of an animated GIF are drawn over each
+// other and their rows follow each other.
+
+package producer
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	c "lautenbacher.net/goleds/config"
+	u "lautenbacher.net/goleds/util"
+)
+
+// imageEffect shows the row of the image at the elapsed time.
+type imageEffect struct {
+	cfg  c.ImageLEDConfig
+	rows [][]Led
+}
+
+// NewImageProducer creates the ImageLED producer from the image in
+// cfg.File. endwg is only used with AfterSensor.
+func NewImageProducer(uid string, ledsChanged *u.AtomicMapEvent[LedProducer], ledsTotal int, cfg c.ImageLEDConfig, endwg *sync.WaitGroup) (*AmbientProducer, error) {
+	rows, err := loadImageRows(cfg.File)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		ScaleLeds(row, cfg.Brightness)
+	}
+	effect := &imageEffect{cfg: cfg, rows: rows}
+	return newAmbientProducer(uid, ledsChanged, ledsTotal, cfg.AmbientConfig, effect, endwg), nil
+}
+
+// loadImageRows reads the rows of a PNG or GIF file.
+func loadImageRows(file string) ([][]Led, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var frames []image.Image
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".gif":
+		anim, err := gif.DecodeAll(f)
+		if err != nil {
+			return nil, fmt.Errorf("invalid GIF %s: %w", file, err)
+		}
+		// Every frame is drawn over the previous ones, which its disposal
+		// method may clear or restore afterwards.
+		bounds := image.Rect(0, 0, anim.Config.Width, anim.Config.Height)
+		canvas := image.NewRGBA(bounds)
+		for i, frame := range anim.Image {
+			var disposal byte
+			if i < len(anim.Disposal) {
+				disposal = anim.Disposal[i]
+			}
+			var previous *image.RGBA
+			if disposal == gif.DisposalPrevious {
+				previous = copyRGBA(canvas)
+			}
+			draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+			frames = append(frames, image.Image(copyRGBA(canvas)))
+			switch disposal {
+			case gif.DisposalBackground:
+				// Like browsers, we use a transparent background.
+				draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+			case gif.DisposalPrevious:
+				canvas = previous
+			}
+		}
+	case ".png":
+		img, err := png.Decode(f)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PNG %s: %w", file, err)
+		}
+		frames = append(frames, img)
+	default:
+		return nil, fmt.Errorf("%s is neither a PNG nor a GIF file", file)
+	}
+
+	var rows [][]Led
+	for _, img := range frames {
+		bounds := img.Bounds()
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			row := make([]Led, bounds.Dx())
+			for x := range row {
+				// Transparent pixels are dark, RGBA is premultiplied.
+				r, g, b, _ := img.At(bounds.Min.X+x, y).RGBA()
+				row[x] = Led{float64(r >> 8), float64(g >> 8), float64(b >> 8)}
+			}
+			rows = append(rows, row)
+		}
+	}
+	if len(rows) == 0 || len(rows[0]) == 0 {
+		return nil, fmt.Errorf("%s is empty", file)
+	}
+	return rows, nil
+}
+
+func copyRGBA(img *image.RGBA) *image.RGBA {
+	dup := image.NewRGBA(img.Bounds())
+	copy(dup.Pix, img.Pix)
+	return dup
+}
+
+// row returns the index of the row shown at elapsed, -1 once a single
+// playback is over.
+func (s *imageEffect) row(elapsed time.Duration) int {
+	n := len(s.rows)
+	i := int(elapsed.Seconds() * s.cfg.FrameRate)
+	switch strings.ToUpper(s.cfg.Loop) {
+	case "ONCE":
+		if i >= n {
+			return -1
+		}
+		return i
+	case "PINGPONG":
+		if n == 1 {
+			return 0
+		}
+		// The first and the last row are shown once per cycle.
+		i %= 2*n - 2
+		if i >= n {
+			i = 2*n - 2 - i
+		}
+		return i
+	default:
+		return i % n
+	}
+}
+
+// scale maps the pixels of row to frame.
+func (s *imageEffect) scale(row, frame []Led) {
+	switch mode := strings.ToUpper(s.cfg.Scaling); {
+	case mode == "NONE":
+		clear(frame)
+		copy(frame, row)
+	case mode == "LINEAR" && len(row) > 1 && len(frame) > 1:
+		// The first and the last LED show the first and the last
+		// pixel, the others are interpolated in between.
+		for k := range frame {
+			x := float64(k) * float64(len(row)-1) / float64(len(frame)-1)
+			i := min(int(x), len(row)-2)
+			frame[k] = InterpolateRGB(row[i], row[i+1], x-float64(i))
+		}
+	default:
+		for k := range frame {
+			frame[k] = row[k*len(row)/len(frame)]
+		}
+	}
+}
+
+func (s *imageEffect) step(frame []Led, rng *rand.Rand, elapsed, dt time.Duration) {
+	i := s.row(elapsed)
+	if i < 0 {
+		clear(frame)
+		return
+	}
+	s.scale(s.rows[i], frame)
+}
@@ -0,0 +1,162 @@
+package producer
+
+import (
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	c "lautenbacher.net/goleds/config"
+	u "lautenbacher.net/goleds/util"
+)
+
+// writePNG writes a PNG with one row per frame and returns its path.
+func writePNG(t *testing.T, rows [][]color.RGBA) string {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, len(rows[0]), len(rows)))
+	for y, row := range rows {
+		for x, pixel := range row {
+			img.SetRGBA(x, y, pixel)
+		}
+	}
+	file := filepath.Join(t.TempDir(), "animation.png")
+	f, err := os.Create(file)
+	require.NoError(t, err)
+	defer f.Close()
+	require.NoError(t, png.Encode(f, img))
+	return file
+}
+
+// writeGIF writes a GIF of the frames with the disposal methods and
+// returns its path.
+func writeGIF(t *testing.T, width, height int, frames []*image.Paletted, disposal []byte) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "animation.gif")
+	f, err := os.Create(file)
+	require.NoError(t, err)
+	defer f.Close()
+	palette := frames[0].Palette
+	require.NoError(t, gif.EncodeAll(f, &gif.GIF{Image: frames, Delay: make([]int, len(frames)), Disposal: disposal,
+		Config: image.Config{ColorModel: palette, Width: width, Height: height}}))
+	return file
+}
+
+var (
+	imgRed   = color.RGBA{255, 0, 0, 255}
+	imgGreen = color.RGBA{0, 255, 0, 255}
+	imgBlue  = color.RGBA{0, 0, 255, 255}
+	imgClear = color.RGBA{}
+)
+
+func imageConfig(file string) c.ImageLEDConfig {
+	return c.ImageLEDConfig{
+		AmbientConfig: c.AmbientConfig{StartLed: 0, EndLed: 3, Delay: 20 * time.Millisecond},
+		File:          file,
+		FrameRate:     10,
+		Loop:          "Loop",
+		Scaling:       "None",
+		Brightness:    1,
+	}
+}
+
+func TestImageProducer_row(t *testing.T) {
+	file := writePNG(t, [][]color.RGBA{{imgRed}, {imgGreen}, {imgBlue}})
+	tests := map[string][]int{
+		"Loop":     {0, 1, 2, 0, 1, 2, 0},
+		"PingPong": {0, 1, 2, 1, 0, 1, 2},
+		"Once":     {0, 1, 2, -1, -1, -1, -1},
+	}
+	for loop, expected := range tests {
+		t.Run(loop, func(t *testing.T) {
+			cfg := imageConfig(file)
+			cfg.Loop = loop
+			p, err := NewImageProducer("image", u.NewAtomicMapEvent[LedProducer](), 10, cfg, nil)
+			require.NoError(t, err)
+			effect := p.effect.(*imageEffect)
+			for i, row := range expected {
+				// 10 rows per second
+				assert.Equal(t, row, effect.row(time.Duration(i)*100*time.Millisecond+50*time.Millisecond), "frame %d", i)
+			}
+		})
+	}
+}
+
+func TestImageProducer_scale(t *testing.T) {
+	file := writePNG(t, [][]color.RGBA{{imgRed, imgBlue}})
+	tests := map[string][]Led{
+		"None":    {{255, 0, 0}, {0, 0, 255}, {}, {}},
+		"Nearest": {{255, 0, 0}, {255, 0, 0}, {0, 0, 255}, {0, 0, 255}},
+		"Linear":  {{255, 0, 0}, {170, 0, 85}, {85, 0, 170}, {0, 0, 255}},
+	}
+	for scaling, expected := range tests {
+		t.Run(scaling, func(t *testing.T) {
+			cfg := imageConfig(file)
+			cfg.Scaling = scaling
+			p, err := NewImageProducer("image", u.NewAtomicMapEvent[LedProducer](), 10, cfg, nil)
+			require.NoError(t, err)
+			p.effect.step(p.frame, p.rng, 0, cfg.Delay)
+			for k, led := range expected {
+				assert.InDelta(t, led.Red, p.frame[k].Red, 1e-9, "LED %d", k)
+				assert.InDelta(t, led.Blue, p.frame[k].Blue, 1e-9, "LED %d", k)
+			}
+		})
+	}
+}
+
+func TestImageProducer_load(t *testing.T) {
+	// Transparent pixels are dark, Brightness scales the colours.
+	cfg := imageConfig(writePNG(t, [][]color.RGBA{{imgRed, imgClear}}))
+	cfg.Brightness = 0.2
+	p, err := NewImageProducer("image", u.NewAtomicMapEvent[LedProducer](), 10, cfg, nil)
+	require.NoError(t, err)
+	assert.Equal(t, [][]Led{{{51, 0, 0}, {}}}, p.effect.(*imageEffect).rows)
+
+	// The frames of a GIF are drawn over each other, their rows follow
+	// each other.
+	palette := color.Palette{imgClear, imgRed, imgGreen}
+	first := image.NewPaletted(image.Rect(0, 0, 2, 2), palette)
+	first.SetColorIndex(0, 0, 1)
+	first.SetColorIndex(1, 1, 1)
+	second := image.NewPaletted(image.Rect(1, 0, 2, 1), palette)
+	second.SetColorIndex(1, 0, 2)
+	rows, err := loadImageRows(writeGIF(t, 2, 2, []*image.Paletted{first, second}, nil))
+	require.NoError(t, err)
+	assert.Equal(t, [][]Led{
+		{{255, 0, 0}, {}}, {{}, {255, 0, 0}},
+		{{255, 0, 0}, {0, 255, 0}}, {{}, {255, 0, 0}},
+	}, rows)
+
+	// The disposal methods restore the previous canvas or clear the
+	// frame before the next one is drawn.
+	palette = color.Palette{imgClear, imgRed, imgGreen, imgBlue}
+	pixel := func(x, index int) *image.Paletted {
+		frame := image.NewPaletted(image.Rect(x, 0, x+1, 1), palette)
+		frame.SetColorIndex(x, 0, uint8(index))
+		return frame
+	}
+	full := image.NewPaletted(image.Rect(0, 0, 2, 1), palette)
+	full.SetColorIndex(0, 0, 1)
+	full.SetColorIndex(1, 0, 1)
+	rows, err = loadImageRows(writeGIF(t, 2, 1, []*image.Paletted{full, pixel(1, 2), pixel(0, 3), pixel(1, 0)},
+		[]byte{gif.DisposalNone, gif.DisposalPrevious, gif.DisposalBackground, gif.DisposalNone}))
+	require.NoError(t, err)
+	assert.Equal(t, [][]Led{
+		{{255, 0, 0}, {255, 0, 0}},
+		{{255, 0, 0}, {0, 255, 0}},
+		{{0, 0, 255}, {255, 0, 0}},
+		{{}, {255, 0, 0}},
+	}, rows)
+
+	_, err = loadImageRows(filepath.Join(t.TempDir(), "missing.png"))
+	assert.Error(t, err)
+	text := filepath.Join(t.TempDir(), "animation.txt")
+	require.NoError(t, os.WriteFile(text, []byte("frames"), 0o644))
+	_, err = loadImageRows(text)
+	assert.ErrorContains(t, err, "neither a PNG nor a GIF")
+}
@@ -0,0 +1,81 @@
+package producer
+
+import (
+	c "lautenbacher.net/goleds/config"
+)
+
+// Cell is a position in the matrix of a Layout.
+type Cell struct {
+	X, Y int
+}
+
+// Layout maps the cells of a matrix onto the virtual strip, so
+// producers can draw in x/y coordinates. Cells without an LED and LEDs
+// outside the matrix are allowed.
+type Layout struct {
+	width, height int
+	indices       []int  // LED index of every cell (y*width+x), -1 for none
+	cells         []Cell // cell of every LED
+	inMatrix      []bool // whether the LED is part of the matrix
+}
+
+// NewLayout creates the layout described by cfg, nil if it isn't
+// enabled. cfg must be valid for ledsTotal.
+func NewLayout(cfg c.LayoutConfig, ledsTotal int) *Layout {
+	if !cfg.Enabled {
+		return nil
+	}
+	inst := &Layout{
+		width:    cfg.Width,
+		height:   cfg.Height,
+		indices:  make([]int, cfg.Width*cfg.Height),
+		cells:    make([]Cell, ledsTotal),
+		inMatrix: make([]bool, ledsTotal),
+	}
+	for i := range inst.indices {
+		inst.indices[i] = -1
+	}
+	for index := range ledsTotal {
+		if x, y, ok := cfg.Cell(index); ok {
+			inst.indices[y*inst.width+x] = index
+			inst.cells[index] = Cell{X: x, Y: y}
+			inst.inMatrix[index] = true
+		}
+	}
+	return inst
+}
+
+// Width returns the number of columns of the matrix.
+func (l *Layout) Width() int {
+	return l.width
+}
+
+// Height returns the number of rows of the matrix.
+func (l *Layout) Height() int {
+	return l.height
+}
+
+// Index returns the LED index of the cell x/y, -1 if the cell is
+// outside the matrix or has no LED.
+func (l *Layout) Index(x, y int) int {
+	if x < 0 || y < 0 || x >= l.width || y >= l.height {
+		return -1
+	}
+	return l.indices[y*l.width+x]
+}
+
+// Position returns the cell of the LED index, false if the LED isn't
+// part of the matrix.
+func (l *Layout) Position(index int) (Cell, bool) {
+	if index < 0 || index >= len(l.cells) || !l.inMatrix[index] {
+		return Cell{}, false
+	}
+	return l.cells[index], true
+}
+
+// Set sets the LED of the cell x/y, cells without an LED are ignored.
+func (l *Layout) Set(leds []Led, x, y int, led Led) {
+	if index := l.Index(x, y); index >= 0 && index < len(leds) {
+		leds[index] = led
+	}
+}
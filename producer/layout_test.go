@@ -0,0 +1,52 @@
+package producer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	c "lautenbacher.net/goleds/config"
+)
+
+func TestNewLayout(t *testing.T) {
+	assert.Nil(t, NewLayout(c.LayoutConfig{Width: 3, Height: 2}, 6))
+
+	// A serpentine 3x2 matrix: the second row runs backwards.
+	layout := NewLayout(c.LayoutConfig{Enabled: true, Width: 3, Height: 2, Serpentine: true}, 8)
+	assert.Equal(t, 3, layout.Width())
+	assert.Equal(t, 2, layout.Height())
+	assert.Equal(t, []int{0, 1, 2, 5, 4, 3}, []int{
+		layout.Index(0, 0), layout.Index(1, 0), layout.Index(2, 0),
+		layout.Index(0, 1), layout.Index(1, 1), layout.Index(2, 1),
+	})
+	assert.Equal(t, -1, layout.Index(3, 0))
+	assert.Equal(t, -1, layout.Index(0, -1))
+
+	cell, ok := layout.Position(3)
+	assert.True(t, ok)
+	assert.Equal(t, Cell{X: 2, Y: 1}, cell)
+	// LEDs 6 and 7 are outside the matrix.
+	_, ok = layout.Position(6)
+	assert.False(t, ok)
+	_, ok = layout.Position(8)
+	assert.False(t, ok)
+}
+
+func TestNewLayout_Segments(t *testing.T) {
+	// Two 2x2 panels next to each other, the right one wired first,
+	// and a cell row below them without LEDs.
+	cfg := c.LayoutConfig{Enabled: true, Width: 4, Height: 3, Segments: []c.LayoutSegmentConfig{
+		{FirstLed: 4, X: 0, Y: 0, Width: 2, Height: 2},
+		{FirstLed: 0, X: 2, Y: 0, Width: 2, Height: 2},
+	}}
+	layout := NewLayout(cfg, 8)
+	assert.Equal(t, 4, layout.Index(0, 0))
+	assert.Equal(t, 7, layout.Index(1, 1))
+	assert.Equal(t, 0, layout.Index(2, 0))
+	assert.Equal(t, 3, layout.Index(3, 1))
+	assert.Equal(t, -1, layout.Index(0, 2))
+
+	leds := make([]Led, 8)
+	layout.Set(leds, 2, 1, Led{Red: 10})
+	layout.Set(leds, 0, 2, Led{Red: 20}) // no LED, ignored
+	assert.Equal(t, []Led{{}, {}, {Red: 10}, {}, {}, {}, {}, {}}, leds)
+}
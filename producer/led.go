@@ -38,3 +38,37 @@ func CombineLeds(allLedRanges map[string][]Led, target []Led) {
 		}
 	}
 }
+
+// OverlayLeds draws overlay above target: every lit LED of overlay
+// replaces the LED in target, dark ones leave it unchanged.
+func OverlayLeds(overlay []Led, target []Led) {
+	for i, led := range overlay {
+		if !led.IsEmpty() {
+			target[i] = led
+		}
+	}
+}
+
+// gradientColor interpolates the colour of value between points given
+// as [value, red, green, blue] with increasing values. Beyond the
+// first and last point their colour is used.
+func gradientColor(points [][]float64, value float64) Led {
+	toLed := func(point []float64) Led { return Led{point[1], point[2], point[3]} }
+	if value <= points[0][0] {
+		return toLed(points[0])
+	}
+	for i := 1; i < len(points); i++ {
+		from, to := points[i-1], points[i]
+		if value <= to[0] {
+			return InterpolateRGB(toLed(from), toLed(to), (value-from[0])/(to[0]-from[0]))
+		}
+	}
+	return toLed(points[len(points)-1])
+}
+
+// Multiply all components of the leds by factor
+func ScaleLeds(leds []Led, factor float64) {
+	for i := range leds {
+		leds[i] = Led{leds[i].Red * factor, leds[i].Green * factor, leds[i].Blue * factor}
+	}
+}
@@ -57,3 +57,18 @@ func TestCombineLeds(t *testing.T) {
 	assert.True(t, combinedLeds[3].IsEmpty())
 	assert.True(t, combinedLeds[4].IsEmpty())
 }
+
+func TestOverlayLeds(t *testing.T) {
+	target := []Led{{Red: 100}, {Green: 100}, {Blue: 100}}
+	OverlayLeds([]Led{{}, {Red: 10}, {}}, target)
+	assert.Equal(t, []Led{{Red: 100}, {Red: 10}, {Blue: 100}}, target)
+}
+
+func TestGradientColor(t *testing.T) {
+	points := [][]float64{{-10, 0, 0, 200}, {10, 0, 200, 0}, {30, 200, 0, 0}}
+	assert.Equal(t, Led{0, 0, 200}, gradientColor(points, -20))
+	assert.Equal(t, Led{0, 100, 100}, gradientColor(points, 0))
+	assert.Equal(t, Led{0, 200, 0}, gradientColor(points, 10))
+	assert.Equal(t, Led{50, 150, 0}, gradientColor(points, 15))
+	assert.Equal(t, Led{200, 0, 0}, gradientColor(points, 40))
+}
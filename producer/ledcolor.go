@@ -0,0 +1,113 @@
+package producer
+
+import (
+	"math"
+
+	u "lautenbacher.net/goleds/util"
+)
+
+// LedFromHSV returns the Led of hue (degrees), saturation and value
+// (0..1).
+func LedFromHSV(h, sat, v float64) Led {
+	return ledOf(u.HSVToRGB(h, sat, v))
+}
+
+// LedFromHSL returns the Led of hue (degrees), saturation and
+// lightness (0..1).
+func LedFromHSL(h, sat, l float64) Led {
+	return ledOf(u.HSLToRGB(h, sat, l))
+}
+
+// LedFromOKLab returns the Led of an OKLab colour, clipped to the
+// sRGB gamut.
+func LedFromOKLab(l, a, b float64) Led {
+	return ledOf(u.OKLabToRGB(l, a, b))
+}
+
+// LedFromKelvin returns the white of the colour temperature kelvin,
+// dimmed to brightness (0..1).
+func LedFromKelvin(kelvin, brightness float64) Led {
+	led := ledOf(u.KelvinToRGB(kelvin))
+	return Led{led.Red * brightness, led.Green * brightness, led.Blue * brightness}
+}
+
+func ledOf(rgb [3]float64) Led {
+	return Led{rgb[0], rgb[1], rgb[2]}
+}
+
+func (s *Led) rgb() [3]float64 {
+	return [3]float64{s.Red, s.Green, s.Blue}
+}
+
+// HSV returns hue (degrees), saturation and value (0..1) of the Led.
+func (s *Led) HSV() (h, sat, v float64) {
+	return u.RGBToHSV(s.rgb())
+}
+
+// HSL returns hue (degrees), saturation and lightness (0..1) of the
+// Led.
+func (s *Led) HSL() (h, sat, l float64) {
+	return u.RGBToHSL(s.rgb())
+}
+
+// OKLab returns the Led in the perceptual OKLab space.
+func (s *Led) OKLab() (l, a, b float64) {
+	return u.RGBToOKLab(s.rgb())
+}
+
+// RotateHue returns the Led with its hue turned by degrees, saturation
+// and value stay the same.
+func (s *Led) RotateHue(degrees float64) Led {
+	h, sat, v := s.HSV()
+	return LedFromHSV(h+degrees, sat, v)
+}
+
+// InterpolateRGB returns the colour at f (0..1) between from and to,
+// interpolated per component.
+func InterpolateRGB(from, to Led, f float64) Led {
+	return Led{
+		Red:   from.Red + f*(to.Red-from.Red),
+		Green: from.Green + f*(to.Green-from.Green),
+		Blue:  from.Blue + f*(to.Blue-from.Blue),
+	}
+}
+
+// InterpolateHSV returns the colour at f (0..1) between from and to,
+// the hue takes the shorter way around the colour wheel. Greys keep
+// the hue of the other colour, so a fade from black doesn't pass red,
+// and black also its saturation.
+func InterpolateHSV(from, to Led, f float64) Led {
+	h1, s1, v1 := from.HSV()
+	h2, s2, v2 := to.HSV()
+	if s1 == 0 {
+		h1 = h2
+	}
+	if s2 == 0 {
+		h2 = h1
+	}
+	if v1 == 0 {
+		s1 = s2
+	}
+	if v2 == 0 {
+		s2 = s1
+	}
+	dh := math.Mod(h2-h1+540, 360) - 180
+	return LedFromHSV(h1+f*dh, s1+f*(s2-s1), v1+f*(v2-v1))
+}
+
+// InterpolateOKLab returns the colour at f (0..1) between from and to
+// in the OKLab space, which looks evenly spaced to the eye.
+func InterpolateOKLab(from, to Led, f float64) Led {
+	l1, a1, b1 := from.OKLab()
+	l2, a2, b2 := to.OKLab()
+	return LedFromOKLab(l1+f*(l2-l1), a1+f*(a2-a1), b1+f*(b2-b1))
+}
+
+// InterpolateKelvin returns the white at f (0..1) between the colour
+// temperatures from and to, dimmed to brightness. The temperatures are
+// interpolated in mireds (1e6 / kelvin), which change evenly to the
+// eye.
+func InterpolateKelvin(from, to, f, brightness float64) Led {
+	mired := 1e6/from + f*(1e6/to-1e6/from)
+	return LedFromKelvin(1e6/mired, brightness)
+}
@@ -0,0 +1,64 @@
+package producer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// assertLed compares the components of two Leds within delta.
+func assertLed(t *testing.T, expected, actual Led, delta float64, msgAndArgs ...any) {
+	t.Helper()
+	assert.InDeltaSlice(t, []float64{expected.Red, expected.Green, expected.Blue},
+		[]float64{actual.Red, actual.Green, actual.Blue}, delta, msgAndArgs...)
+}
+
+var testLeds = []Led{{0, 0, 0}, {255, 255, 255}, {255, 0, 0}, {255, 128, 0}, {12, 200, 180}, {90, 30, 200}, {1, 2, 3}}
+
+func TestLed_RoundTrip(t *testing.T) {
+	for _, led := range testLeds {
+		assertLed(t, led, LedFromHSV(led.HSV()), 1e-9, "HSV %v", led)
+		assertLed(t, led, LedFromHSL(led.HSL()), 1e-9, "HSL %v", led)
+		assertLed(t, led, LedFromOKLab(led.OKLab()), 1e-3, "OKLab %v", led)
+	}
+}
+
+func TestLed_RotateHue(t *testing.T) {
+	red := Led{200, 0, 0}
+	assertLed(t, Led{0, 200, 0}, red.RotateHue(120), 1e-9)
+	assertLed(t, Led{0, 0, 200}, red.RotateHue(-120), 1e-9)
+	assertLed(t, red, red.RotateHue(720), 1e-9)
+}
+
+func TestLedFromKelvin(t *testing.T) {
+	warm := LedFromKelvin(2700, 1)
+	assert.Equal(t, 255.0, warm.Red)
+	assert.Less(t, warm.Blue, warm.Green)
+	dimmed := LedFromKelvin(2700, 0.5)
+	assertLed(t, Led{warm.Red / 2, warm.Green / 2, warm.Blue / 2}, dimmed, 1e-9)
+}
+
+func TestInterpolate(t *testing.T) {
+	red, blue := Led{255, 0, 0}, Led{0, 0, 255}
+	assertLed(t, Led{127.5, 0, 127.5}, InterpolateRGB(red, blue, 0.5), 1e-9)
+
+	// The hue takes the shorter way from red (0°) to blue (240°) over
+	// magenta (300°), not over green.
+	assertLed(t, Led{255, 0, 255}, InterpolateHSV(red, blue, 0.5), 1e-9)
+	// A fade from black keeps the hue.
+	assertLed(t, Led{0, 0, 127.5}, InterpolateHSV(Led{}, blue, 0.5), 1e-9)
+
+	for _, f := range []float64{0, 1} {
+		assertLed(t, InterpolateRGB(red, blue, f), InterpolateOKLab(red, blue, f), 1e-3, "f=%v", f)
+	}
+	// The middle of black and white in OKLab is lighter than the
+	// arithmetic mean, it looks like a middle grey.
+	grey := InterpolateOKLab(Led{}, Led{255, 255, 255}, 0.5)
+	assert.InDelta(t, grey.Red, grey.Blue, 1e-3)
+	assert.Greater(t, grey.Red, 60.0)
+	assert.Less(t, grey.Red, 127.5)
+
+	// 2000 K to 6500 K meet at about 3100 K in mireds.
+	assertLed(t, LedFromKelvin(1e6/((1e6/2000+1e6/6500)/2), 1), InterpolateKelvin(2000, 6500, 0.5, 1), 1e-9)
+	assertLed(t, LedFromKelvin(6500, 0.3), InterpolateKelvin(2000, 6500, 1, 0.3), 1e-9)
+}
@@ -11,3 +11,27 @@ type LedProducer interface {
 	TryStop() (bool, error)
 	Exit()
 }
+
+// Dimmable is implemented by all producers embedding AbstractProducer.
+// The brightness factor (0..1) is applied to the LEDs in GetLeds.
+type Dimmable interface {
+	SetBrightness(factor float64)
+}
+
+// Recolorable is implemented by producers whose main colour can be
+// swapped at runtime. A nil colour restores the configured one.
+type Recolorable interface {
+	SetColor(rgb []float64)
+}
+
+// Spawnable is implemented by producers that can start at the LED of
+// the sensor triggered last.
+type Spawnable interface {
+	SetSpawnLed(index int)
+}
+
+// Messageable is implemented by producers showing a text that can be
+// replaced at runtime. An empty text restores the configured one.
+type Messageable interface {
+	SetText(text string)
+}
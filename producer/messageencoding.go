@@ -0,0 +1,161 @@
+package producer
+
+import (
+	"strings"
+
+	c "lautenbacher.net/goleds/config"
+)
+
+// morseCode maps the characters to dots and dashes.
+var morseCode = map[rune]string{
+	'A': ".-", 'B': "-...", 'C': "-.-.", 'D': "-..", 'E': ".", 'F': "..-.",
+	'G': "--.", 'H': "....", 'I': "..", 'J': ".---", 'K': "-.-", 'L': ".-..",
+	'M': "--", 'N': "-.", 'O': "---", 'P': ".--.", 'Q': "--.-", 'R': ".-.",
+	'S': "...", 'T': "-", 'U': "..-", 'V': "...-", 'W': ".--", 'X': "-..-",
+	'Y': "-.--", 'Z': "--..",
+	'0': "-----", '1': ".----", '2': "..---", '3': "...--", '4': "....-",
+	'5': ".....", '6': "-....", '7': "--...", '8': "---..", '9': "----.",
+	'.': ".-.-.-", ',': "--..--", '?': "..--..", '!': "-.-.--", '-': "-....-",
+	'/': "-..-.", ':': "---...", '+': ".-.-.", '=': "-...-", '@': ".--.-.",
+}
+
+// bitmapFont is a font 5 LEDs high, '#' is lit. The glyphs are 3 LEDs
+// wide except for a few that need more.
+var bitmapFont = map[rune][c.MessageRows]string{
+	'0': {"###", "#.#", "#.#", "#.#", "###"},
+	'1': {".#.", "##.", ".#.", ".#.", "###"},
+	'2': {"###", "..#", "###", "#..", "###"},
+	'3': {"###", "..#", ".##", "..#", "###"},
+	'4': {"#.#", "#.#", "###", "..#", "..#"},
+	'5': {"###", "#..", "###", "..#", "###"},
+	'6': {"###", "#..", "###", "#.#", "###"},
+	'7': {"###", "..#", "..#", "..#", "..#"},
+	'8': {"###", "#.#", "###", "#.#", "###"},
+	'9': {"###", "#.#", "###", "..#", "###"},
+	'A': {".#.", "#.#", "###", "#.#", "#.#"},
+	'B': {"##.", "#.#", "##.", "#.#", "##."},
+	'C': {".##", "#..", "#..", "#..", ".##"},
+	'D': {"##.", "#.#", "#.#", "#.#", "##."},
+	'E': {"###", "#..", "##.", "#..", "###"},
+	'F': {"###", "#..", "##.", "#..", "#.."},
+	'G': {".##", "#..", "#.#", "#.#", ".##"},
+	'H': {"#.#", "#.#", "###", "#.#", "#.#"},
+	'I': {"###", ".#.", ".#.", ".#.", "###"},
+	'J': {"..#", "..#", "..#", "#.#", ".#."},
+	'K': {"#.#", "#.#", "##.", "#.#", "#.#"},
+	'L': {"#..", "#..", "#..", "#..", "###"},
+	'M': {"#...#", "##.##", "#.#.#", "#...#", "#...#"},
+	'N': {"#..#", "##.#", "#.##", "#..#", "#..#"},
+	'O': {".#.", "#.#", "#.#", "#.#", ".#."},
+	'P': {"##.", "#.#", "##.", "#..", "#.."},
+	'Q': {".#.", "#.#", "#.#", "##.", ".##"},
+	'R': {"##.", "#.#", "##.", "#.#", "#.#"},
+	'S': {".##", "#..", ".#.", "..#", "##."},
+	'T': {"###", ".#.", ".#.", ".#.", ".#."},
+	'U': {"#.#", "#.#", "#.#", "#.#", "###"},
+	'V': {"#.#", "#.#", "#.#", "#.#", ".#."},
+	'W': {"#...#", "#...#", "#.#.#", "##.##", "#...#"},
+	'X': {"#.#", "#.#", ".#.", "#.#", "#.#"},
+	'Y': {"#.#", "#.#", ".#.", ".#.", ".#."},
+	'Z': {"###", "..#", ".#.", "#..", "###"},
+	' ': {"..", "..", "..", "..", ".."},
+	'.': {".", ".", ".", ".", "#"},
+	',': {".", ".", ".", "#", "#"},
+	'!': {"#", "#", "#", ".", "#"},
+	':': {".", "#", ".", "#", "."},
+	'?': {"###", "..#", ".#.", "...", ".#."},
+	'-': {"...", "...", "###", "...", "..."},
+	'+': {"...", ".#.", "###", ".#.", "..."},
+	'/': {"..#", "..#", ".#.", "#..", "#.."},
+	'%': {"#.#", "..#", ".#.", "#..", "#.#"},
+}
+
+// encodeMorse encodes text as columns of one LED: a dot is one lit
+// LED, a dash three, with one dark LED between them, three between
+// letters and seven between words. Unknown characters are skipped.
+func encodeMorse(text string, color Led) [][]Led {
+	var columns [][]Led
+	gap := func(n int) {
+		for range n {
+			columns = append(columns, []Led{{}})
+		}
+	}
+	for i, word := range strings.Fields(strings.ToUpper(text)) {
+		if i > 0 {
+			gap(7)
+		}
+		letters := 0
+		for _, r := range word {
+			code, ok := morseCode[r]
+			if !ok {
+				continue
+			}
+			if letters > 0 {
+				gap(3)
+			}
+			letters++
+			for j, symbol := range code {
+				if j > 0 {
+					gap(1)
+				}
+				length := 1
+				if symbol == '-' {
+					length = 3
+				}
+				for range length {
+					columns = append(columns, []Led{color})
+				}
+			}
+		}
+	}
+	return columns
+}
+
+// encodeDigits encodes the digits of text as one LED each in the
+// colour of the digit with a dark LED in between. Other characters
+// separate the numbers by two more dark LEDs.
+func encodeDigits(text string, colors []Led) [][]Led {
+	var columns [][]Led
+	separated := false
+	for _, r := range text {
+		if r < '0' || r > '9' {
+			separated = len(columns) > 0
+			continue
+		}
+		if len(columns) > 0 {
+			columns = append(columns, []Led{{}})
+		}
+		if separated {
+			columns = append(columns, []Led{{}}, []Led{{}})
+			separated = false
+		}
+		columns = append(columns, []Led{colors[r-'0']})
+	}
+	return columns
+}
+
+// encodeBitmap encodes text in the bitmap font as columns of
+// c.MessageRows LEDs, from top to bottom, with a dark column between
+// the glyphs. Unknown characters are shown as '?'.
+func encodeBitmap(text string, color Led) [][]Led {
+	var columns [][]Led
+	for i, r := range strings.ToUpper(text) {
+		glyph, ok := bitmapFont[r]
+		if !ok {
+			glyph = bitmapFont['?']
+		}
+		if i > 0 {
+			columns = append(columns, make([]Led, c.MessageRows))
+		}
+		for x := range len(glyph[0]) {
+			column := make([]Led, c.MessageRows)
+			for y, row := range glyph {
+				if row[x] == '#' {
+					column[y] = color
+				}
+			}
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
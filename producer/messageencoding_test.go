@@ -0,0 +1,76 @@
+package producer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	c "lautenbacher.net/goleds/config"
+)
+
+// lit shows single-row columns as '#' (lit) and '.' (dark).
+func lit(columns [][]Led) string {
+	var b strings.Builder
+	for _, column := range columns {
+		if column[0].IsEmpty() {
+			b.WriteByte('.')
+		} else {
+			b.WriteByte('#')
+		}
+	}
+	return b.String()
+}
+
+func TestEncodeMorse(t *testing.T) {
+	white := Led{100, 100, 100}
+	// S = ..., O = ---, unknown characters are skipped.
+	assert.Equal(t, "#.#.#...###.###.###...#.#.#", lit(encodeMorse("s*os", white)))
+	assert.Equal(t, "#.......###", lit(encodeMorse(" E  T ", white)))
+	assert.Empty(t, encodeMorse("", white))
+	assert.Equal(t, white, encodeMorse("e", white)[0][0])
+}
+
+func TestEncodeDigits(t *testing.T) {
+	colors := make([]Led, 10)
+	for i := range colors {
+		colors[i] = Led{float64(i + 1), 0, 0}
+	}
+	columns := encodeDigits("a12:5", colors)
+	assert.Equal(t, "#.#...#", lit(columns))
+	assert.Equal(t, colors[1], columns[0][0])
+	assert.Equal(t, colors[2], columns[2][0])
+	assert.Equal(t, colors[5], columns[6][0])
+}
+
+func TestEncodeBitmap(t *testing.T) {
+	red := Led{255, 0, 0}
+	columns := encodeBitmap("Hi~", red)
+	// H and I are 3 columns wide, unknown characters become '?'.
+	assert.Len(t, columns, 3+1+3+1+3)
+	rows := make([]string, c.MessageRows)
+	for y := range rows {
+		var b strings.Builder
+		for _, column := range columns {
+			if column[y] == red {
+				b.WriteByte('#')
+			} else {
+				b.WriteByte('.')
+			}
+		}
+		rows[y] = b.String()
+	}
+	assert.Equal(t, []string{
+		"#.#.###.###",
+		"#.#..#....#",
+		"###..#...#.",
+		"#.#..#.....",
+		"#.#.###..#.",
+	}, rows)
+
+	// All glyphs have rows of the same width.
+	for r, glyph := range bitmapFont {
+		for _, row := range glyph {
+			assert.Len(t, row, len(glyph[0]), "glyph %q", r)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+// This producer scrolls short messages, e.g. on a status strip in the
+// hallway. The messages are encoded in Morse, as colour-coded digits or
+// in a bitmap font on the rows of a matrix and move from the end of
+// the range towards its start. A message sent to /api/message replaces
+// the one set by the schedule, which replaces the configured one.
+
+package producer
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	c "lautenbacher.net/goleds/config"
+	u "lautenbacher.net/goleds/util"
+)
+
+// messageRow is a row of LEDs the message moves along, from the LED
+// start in direction.
+type messageRow struct {
+	start, direction, width int
+}
+
+type MessageProducer struct {
+	*AbstractProducer
+	cfg c.MessageLEDConfig
+	// rows are the rows of the matrix for Bitmap, the only row of the
+	// other encodings runs from StartLed to EndLed.
+	rows      []messageRow
+	textMutex sync.Mutex
+	text      string // sent to the API, "" for none
+	repeat    int    // passes of text left, 0 means until it's replaced
+	scheduled string
+	changed   *u.AtomicEvent[struct{}]
+}
+
+// NewMessageProducer creates the MessageLED producer, rows are the
+// segments of the MatrixGroup for the Bitmap encoding.
+func NewMessageProducer(uid string, ledsChanged *u.AtomicMapEvent[LedProducer], ledsTotal int, cfg c.MessageLEDConfig, rows []c.LedSegmentConfig) *MessageProducer {
+	inst := &MessageProducer{
+		cfg:     cfg,
+		changed: u.NewAtomicEvent[struct{}](),
+	}
+	if strings.EqualFold(cfg.Encoding, "Bitmap") {
+		for _, row := range rows {
+			inst.rows = append(inst.rows, messageRow{row.FirstLed, 1, row.LastLed - row.FirstLed + 1})
+		}
+	} else if cfg.EndLed < cfg.StartLed {
+		inst.rows = []messageRow{{cfg.StartLed, -1, cfg.StartLed - cfg.EndLed + 1}}
+	} else {
+		inst.rows = []messageRow{{cfg.StartLed, 1, cfg.EndLed - cfg.StartLed + 1}}
+	}
+	inst.AbstractProducer = NewAbstractProducer(uid, ledsChanged, inst.runner, ledsTotal)
+	return inst
+}
+
+// ShowMessage shows text for repeat passes, 0 means until it is
+// replaced or cleared.
+func (s *MessageProducer) ShowMessage(text string, repeat int) error {
+	if repeat < 0 {
+		return fmt.Errorf("Repeat must not be negative")
+	}
+	s.textMutex.Lock()
+	s.text, s.repeat = text, repeat
+	s.textMutex.Unlock()
+	s.changed.Send(struct{}{})
+	return nil
+}
+
+// ClearMessage removes the message shown by ShowMessage.
+func (s *MessageProducer) ClearMessage() {
+	s.ShowMessage("", 0)
+}
+
+// SetText implements Messageable, the text replaces the configured
+// message.
+func (s *MessageProducer) SetText(text string) {
+	s.textMutex.Lock()
+	changed := s.scheduled != text
+	s.scheduled = text
+	s.textMutex.Unlock()
+	if changed {
+		s.changed.Send(struct{}{})
+	}
+}
+
+// Message returns the text shown and the passes left of a message
+// sent by ShowMessage.
+func (s *MessageProducer) Message() (string, int) {
+	s.textMutex.Lock()
+	defer s.textMutex.Unlock()
+	return s.currentLocked()
+}
+
+func (s *MessageProducer) currentLocked() (string, int) {
+	switch {
+	case s.text != "":
+		return s.text, s.repeat
+	case s.scheduled != "":
+		return s.scheduled, 0
+	default:
+		return s.cfg.Message, 0
+	}
+}
+
+// passDone counts a finished pass of the message sent by ShowMessage
+// and reports whether it is over.
+func (s *MessageProducer) passDone(text string) bool {
+	s.textMutex.Lock()
+	defer s.textMutex.Unlock()
+	if s.text != text || s.repeat == 0 {
+		return false
+	}
+	s.repeat--
+	if s.repeat == 0 {
+		s.text = ""
+		return true
+	}
+	return false
+}
+
+// encode returns the columns of text, from top to bottom.
+func (s *MessageProducer) encode(text string) [][]Led {
+	color := func(rgb []float64) Led { return Led{rgb[0], rgb[1], rgb[2]} }
+	switch strings.ToUpper(s.cfg.Encoding) {
+	case "DIGITS":
+		colors := make([]Led, len(s.cfg.DigitColors))
+		for i, rgb := range s.cfg.DigitColors {
+			colors[i] = color(rgb)
+		}
+		return encodeDigits(text, colors)
+	case "BITMAP":
+		return encodeBitmap(text, color(s.cfg.LedRGB))
+	default:
+		return encodeMorse(text, color(s.cfg.LedRGB))
+	}
+}
+
+// render draws the columns shifted by shift LEDs into the rows. The
+// message enters at the end of the rows.
+func (s *MessageProducer) render(columns [][]Led, shift int) {
+	s.ledsMutex.Lock()
+	defer s.ledsMutex.Unlock()
+	for y, row := range s.rows {
+		for x := range row.width {
+			var led Led
+			if k := x - row.width + shift; k >= 0 && k < len(columns) {
+				led = columns[k][y]
+			}
+			s.leds[row.start+x*row.direction] = led
+		}
+	}
+}
+
+func (s *MessageProducer) runner() {
+	tick := s.clock.NewTicker(s.cfg.Delay)
+	defer func() {
+		s.ledsMutex.Lock()
+		clear(s.leds) // Reset LEDs
+		s.ledsMutex.Unlock()
+		s.ledsChanged.Send(s.GetUID(), s)
+		tick.Stop()
+	}()
+
+	var text string
+	var columns [][]Led
+	var start time.Time
+	var passes int
+	restart := func() {
+		text, _ = s.Message()
+		columns = s.encode(text)
+		start = s.clock.Now()
+		passes = 0
+	}
+	restart()
+	for {
+		select {
+		case <-s.changed.Channel():
+			restart()
+		case <-s.stopchan:
+			return
+		case <-tick.C():
+			// A pass ends when the message has left at the start.
+			cycle := s.rows[0].width + len(columns)
+			shift := int(s.clock.Now().Sub(start).Seconds() * s.cfg.Speed)
+			if pass := shift / cycle; pass > passes {
+				passes = pass
+				if s.passDone(text) {
+					restart()
+					shift = 0
+				}
+			}
+			shift %= cycle
+			s.render(columns, shift)
+			s.ledsChanged.Send(s.GetUID(), s)
+		}
+	}
+}
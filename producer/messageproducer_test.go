@@ -0,0 +1,114 @@
+package producer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	c "lautenbacher.net/goleds/config"
+	u "lautenbacher.net/goleds/util"
+)
+
+var messageConfig = c.MessageLEDConfig{
+	Enabled:  true,
+	Encoding: "Morse",
+	StartLed: 9,
+	EndLed:   0,
+	LedRGB:   []float64{0, 0, 100},
+	Message:  "E",
+	Speed:    10,
+	Delay:    100 * time.Millisecond,
+}
+
+func TestMessageProducer_render(t *testing.T) {
+	p := NewMessageProducer("message", u.NewAtomicMapEvent[LedProducer](), 12, messageConfig, nil)
+	leds := make([]Led, 12)
+	columns := encodeMorse("T", Led{0, 0, 100})
+
+	// The message enters at the end of the range (LED 0) and moves
+	// towards its start (LED 9).
+	p.render(columns, 1)
+	p.GetLeds(leds)
+	assert.Equal(t, "#...........", lit(toColumns(leds)))
+	p.render(columns, 5)
+	p.GetLeds(leds)
+	assert.Equal(t, "..###.......", lit(toColumns(leds)))
+	// The last column leaves at the start, LEDs 10 and 11 are outside
+	// the range.
+	p.render(columns, 12)
+	p.GetLeds(leds)
+	assert.Equal(t, ".........#..", lit(toColumns(leds)))
+
+	// Bitmap draws every column on the rows of the matrix.
+	cfg := messageConfig
+	cfg.Encoding = "Bitmap"
+	rows := []c.LedSegmentConfig{{FirstLed: 0, LastLed: 3}, {FirstLed: 4, LastLed: 7}, {FirstLed: 8, LastLed: 11},
+		{FirstLed: 12, LastLed: 15}, {FirstLed: 16, LastLed: 19}}
+	p = NewMessageProducer("message", u.NewAtomicMapEvent[LedProducer](), 20, cfg, rows)
+	leds = make([]Led, 20)
+	p.render(encodeBitmap("L", Led{0, 0, 100}), 4)
+	p.GetLeds(leds)
+	assert.Equal(t, "#...#...#...#...###.", lit(toColumns(leds)))
+}
+
+// toColumns turns LEDs into single-row columns for lit.
+func toColumns(leds []Led) [][]Led {
+	columns := make([][]Led, len(leds))
+	for i := range leds {
+		columns[i] = leds[i : i+1]
+	}
+	return columns
+}
+
+func TestMessageProducer_Message(t *testing.T) {
+	p := NewMessageProducer("message", u.NewAtomicMapEvent[LedProducer](), 10, messageConfig, nil)
+	text, _ := p.Message()
+	assert.Equal(t, "E", text)
+
+	// The schedule replaces the configured message, the API both.
+	p.SetText("T")
+	text, _ = p.Message()
+	assert.Equal(t, "T", text)
+	assert.NoError(t, p.ShowMessage("SOS", 2))
+	text, repeat := p.Message()
+	assert.Equal(t, "SOS", text)
+	assert.Equal(t, 2, repeat)
+	assert.Error(t, p.ShowMessage("SOS", -1))
+
+	// After its passes the schedule's message is back.
+	assert.False(t, p.passDone("SOS"))
+	assert.True(t, p.passDone("SOS"))
+	text, _ = p.Message()
+	assert.Equal(t, "T", text)
+
+	p.SetText("")
+	assert.NoError(t, p.ShowMessage("SOS", 0))
+	p.ClearMessage()
+	text, _ = p.Message()
+	assert.Equal(t, "E", text)
+}
+
+func TestMessageProducer_Runner(t *testing.T) {
+	clock := u.NewFakeClock(time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC))
+	p := NewMessageProducer("message", u.NewAtomicMapEvent[LedProducer](), 10, messageConfig, nil)
+	p.SetClock(clock)
+	assert.NoError(t, p.ShowMessage("T", 1))
+	p.Start()
+	defer p.Exit()
+
+	// At 10 LEDs per second the dash of T is fully visible after 0.3s.
+	leds := make([]Led, 10)
+	clock.BlockUntil(1)
+	clock.Advance(300 * time.Millisecond)
+	assert.Eventually(t, func() bool {
+		p.GetLeds(leds)
+		return lit(toColumns(leds)) == "###......."
+	}, time.Second, time.Millisecond)
+
+	// After one pass of 10 + 3 LEDs the configured message follows.
+	clock.Advance(time.Second)
+	assert.Eventually(t, func() bool {
+		text, _ := p.Message()
+		return text == "E"
+	}, time.Second, time.Millisecond)
+}
@@ -3,8 +3,10 @@ package producer
 import (
 	"fmt"
 	"log/slog"
+	"maps"
 	"math"
 	"math/rand/v2"
+	"slices"
 	"sync"
 	"time"
 
@@ -20,6 +22,11 @@ type Blob struct {
 	width  float64
 	delta  float64
 	dir    float64
+	// mass is used by the physics, parts are the blobs a merged blob
+	// consists of.
+	mass     float64
+	parts    []*Blob
+	mergedAt time.Time
 }
 
 func NewBlob(uid string, ledRGB []float64, x, width, deltaX float64) *Blob {
@@ -34,6 +41,7 @@ func NewBlob(uid string, ledRGB []float64, x, width, deltaX float64) *Blob {
 		x:      x,
 		width:  width,
 		delta:  deltaX,
+		mass:   width,
 	}
 	if inst.delta < 0 {
 		inst.dir = -1
@@ -76,27 +84,70 @@ func (s *Blob) switchDirection() {
 type MultiBlobProducer struct {
 	*AbstractProducer
 	allblobs map[string]*Blob
+	blobCfg  []c.BlobCfg
 	duration time.Duration
 	delay    time.Duration
+	beats    BeatSource
+	physics  c.MultiBlobPhysicsConfig
+	spawnLed int
+	rng      *rand.Rand
 }
 
 func NewMultiBlobProducer(uid string, ledsChanged *u.AtomicMapEvent[LedProducer], ledsTotal int, duration, delay time.Duration, blobCfg []c.BlobCfg, endwg *sync.WaitGroup) *MultiBlobProducer {
 	inst := &MultiBlobProducer{
+		blobCfg:  blobCfg,
 		duration: duration,
 		delay:    delay,
+		spawnLed: -1,
 	}
 	inst.AbstractProducer = NewAbstractProducer(uid, ledsChanged, inst.runner, ledsTotal)
 	if endwg != nil {
 		inst.AbstractProducer.endWg = endwg
 	}
+	inst.resetBlobs()
+	return inst
+}
 
-	inst.allblobs = make(map[string]*Blob)
-	for ind, cfg := range blobCfg {
+// resetBlobs creates the blobs of the config and reseeds the random
+// impulses, so with a Seed every run is the same. With SpawnAtTrigger
+// the blobs start at the LED of the last sensor trigger.
+func (s *MultiBlobProducer) resetBlobs() {
+	seed := s.physics.Seed
+	if seed == 0 {
+		seed = uint64(time.Now().UnixNano())
+	}
+	s.rng = rand.New(rand.NewPCG(seed, 0))
+	s.ledsMutex.Lock()
+	spawnLed := s.spawnLed
+	s.ledsMutex.Unlock()
+	s.allblobs = make(map[string]*Blob)
+	for ind, cfg := range s.blobCfg {
 		uid := fmt.Sprintf("Blob_%d", ind)
-		blob := NewBlob(uid, cfg.LedRGB, cfg.X, cfg.Width, cfg.DeltaX)
-		inst.allblobs[uid] = blob
+		x := cfg.X
+		if s.physics.SpawnAtTrigger && spawnLed >= 0 {
+			x = float64(spawnLed)
+		}
+		blob := NewBlob(uid, cfg.LedRGB, x, cfg.Width, cfg.DeltaX)
+		if cfg.Mass > 0 {
+			blob.mass = cfg.Mass
+		}
+		s.allblobs[uid] = blob
 	}
-	return inst
+}
+
+// SetPhysics replaces the fixed speed of the blobs by the physics of
+// cfg. The blobs start at their configured state with every run. It
+// must be called before Start().
+func (s *MultiBlobProducer) SetPhysics(cfg c.MultiBlobPhysicsConfig) {
+	s.physics = cfg
+}
+
+// SetSpawnLed implements Spawnable, with SpawnAtTrigger the blobs of
+// the next run start at index.
+func (s *MultiBlobProducer) SetSpawnLed(index int) {
+	s.ledsMutex.Lock()
+	defer s.ledsMutex.Unlock()
+	s.spawnLed = index
 }
 
 func (s *MultiBlobProducer) fade_in_or_out(fadein bool) {
@@ -128,22 +179,49 @@ func (s *MultiBlobProducer) fade_in_or_out(fadein bool) {
 		}
 		s.ledsMutex.Unlock()
 		s.ledsChanged.Send(s.GetUID(), s) // Send one notification per fade step
-		time.Sleep(delay)
+		s.clock.Sleep(delay)
 	}
 }
 
+// SetBeatSource makes the blobs pass their colours on to the next blob
+// on every beat. It must be called before Start().
+func (s *MultiBlobProducer) SetBeatSource(beats BeatSource) {
+	s.beats = beats
+}
+
+// rotateColors passes the colour of every blob on to the next one.
+func (s *MultiBlobProducer) rotateColors() {
+	uids := slices.Sorted(maps.Keys(s.allblobs))
+	if len(uids) < 2 {
+		return
+	}
+	last := s.allblobs[uids[len(uids)-1]].led
+	for i := len(uids) - 1; i > 0; i-- {
+		s.allblobs[uids[i]].led = s.allblobs[uids[i-1]].led
+	}
+	s.allblobs[uids[0]].led = last
+}
+
 func (s *MultiBlobProducer) runner() {
-	triggerduration := time.NewTicker(s.duration)
-	tick := time.NewTicker(s.delay)
+	triggerduration := s.clock.NewTicker(s.duration)
+	tick := s.clock.NewTicker(s.delay)
 	countup_run := false
+	var beatChan <-chan struct{}
+	if s.beats != nil {
+		beatChan = s.beats.SubscribeBeats(s.GetUID()).Channel()
+		defer s.beats.Unsubscribe(s.GetUID())
+	}
 	defer func() {
 		tick.Stop()
 		triggerduration.Stop()
 	}()
+	if s.physics.Enabled {
+		s.resetBlobs()
+	}
 
 	for {
 		select {
-		case <-triggerduration.C:
+		case <-triggerduration.C():
 			// Doing the fadeout after the time is up
 			s.fade_in_or_out(false)
 			return
@@ -151,14 +229,20 @@ func (s *MultiBlobProducer) runner() {
 			// Doing the fadeout when Stop() is triggered
 			s.fade_in_or_out(false)
 			return
-		case <-tick.C:
-			// compute new x value
-			for _, blob := range s.allblobs {
-				blob.x = blob.x + (blob.delta * blob.dir)
-			}
+		case <-beatChan:
+			s.rotateColors()
+		case <-tick.C():
+			if s.physics.Enabled {
+				s.stepPhysics(s.clock.Now())
+			} else {
+				// compute new x value
+				for _, blob := range s.allblobs {
+					blob.x = blob.x + (blob.delta * blob.dir)
+				}
 
-			// detect & handle collision
-			detectAndHandleCollisions(s.allblobs, len(s.leds))
+				// detect & handle collision
+				detectAndHandleCollisions(s.allblobs, len(s.leds))
+			}
 
 			// push update event for Leds
 			s.ledsMutex.Lock()
@@ -121,3 +121,16 @@ func TestDetectAndHandleCollisions_Boundary(t *testing.T) {
 	assert.Equal(t, float64(1), blobNoHit.dir, "Blob not hitting boundary should not change direction")
 	assert.Equal(t, 5.0, blobNoHit.x, "Blob x should not be reverted")
 }
+
+func TestMultiBlobProducer_rotateColors(t *testing.T) {
+	cfg := []c.BlobCfg{
+		{LedRGB: []float64{1, 0, 0}, X: 1, Width: 1, DeltaX: 1},
+		{LedRGB: []float64{0, 1, 0}, X: 5, Width: 1, DeltaX: 1},
+		{LedRGB: []float64{0, 0, 1}, X: 9, Width: 1, DeltaX: 1},
+	}
+	prod := NewMultiBlobProducer("multi", u.NewAtomicMapEvent[LedProducer](), 10, time.Second, time.Millisecond, cfg, nil)
+	prod.rotateColors()
+	assert.Equal(t, Led{Blue: 1}, prod.allblobs["Blob_0"].led)
+	assert.Equal(t, Led{Red: 1}, prod.allblobs["Blob_1"].led)
+	assert.Equal(t, Led{Green: 1}, prod.allblobs["Blob_2"].led)
+}
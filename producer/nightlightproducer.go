@@ -1,25 +1,50 @@
 // This producer displays a constant color on the stripes between
 // sunset and sunrise. There can be different colors for different
 // times of the night. The colors are configured in the config file.
+//
+// Alternatively the colors are given as stops anchored to clock times
+// or astronomical events (e.g. civil dusk, solar midnight). Between
+// two stops the color is interpolated linearly, before the first and
+// after the last stop of a night the LEDs are off.
 
 package producer
 
 import (
+	"log/slog"
+	"math"
+	"sort"
 	"time"
 
+	c "lautenbacher.net/goleds/config"
 	u "lautenbacher.net/goleds/util"
 
 	"github.com/nathan-osman/go-sunrise"
 )
 
+// minGradientStep is the minimum time between two updates while the
+// color changes between two stops.
+const minGradientStep = time.Second
+
 type NightlightProducer struct {
 	*AbstractProducer
 	latitude  float64
 	longitude float64
 	ledNight  []Led
+	stops     []nightStop
+}
+
+type nightStop struct {
+	at    u.TimeAnchor
+	color Led
+}
+
+// timedStop is a nightStop resolved for a specific night.
+type timedStop struct {
+	at    time.Time
+	color Led
 }
 
-func NewNightlightProducer(uid string, ledsChanged *u.AtomicMapEvent[LedProducer], ledsTotal int, latitude float64, longitude float64, ledRGB [][]float64) *NightlightProducer {
+func NewNightlightProducer(uid string, ledsChanged *u.AtomicMapEvent[LedProducer], ledsTotal int, latitude float64, longitude float64, ledRGB [][]float64, stops []c.NightLEDStop) *NightlightProducer {
 	inst := &NightlightProducer{
 		latitude:  latitude,
 		longitude: longitude,
@@ -29,13 +54,123 @@ func NewNightlightProducer(uid string, ledsChanged *u.AtomicMapEvent[LedProducer
 	for index, led := range ledRGB {
 		inst.ledNight[index] = Led{led[0], led[1], led[2]}
 	}
+	for _, stop := range stops {
+		at, err := u.ParseTimeAnchor(stop.At)
+		if err != nil {
+			// Can't happen with a validated config
+			slog.Error("Ignoring invalid NightLED stop", "at", stop.At, "error", err)
+			continue
+		}
+		inst.stops = append(inst.stops, nightStop{at: at, color: Led{stop.LedRGB[0], stop.LedRGB[1], stop.LedRGB[2]}})
+	}
 	return inst
 }
 
-func (s *NightlightProducer) setNightLed(index int) {
+func (s *NightlightProducer) setNightLed(color Led) {
 	for i := range s.leds {
-		s.setLed(i, s.ledNight[index])
+		s.setLed(i, color)
+	}
+}
+
+// nightPhase computes which of the configured night colors has to be
+// shown at the given time. night is false during the day. wakeupAfter
+// is the duration until the next change is due.
+func (s *NightlightProducer) nightPhase(now time.Time) (index int, night bool, wakeupAfter time.Duration) {
+	next := now.Add(24 * time.Hour)  // tomorrow
+	prev := now.Add(-24 * time.Hour) // yesterday
+	rise, set := sunrise.SunriseSunset(s.latitude, s.longitude, now.Year(), now.Month(), now.Day())
+	rise_next_day, _ := sunrise.SunriseSunset(s.latitude, s.longitude, next.Year(), next.Month(), next.Day())
+	_, set_prev_day := sunrise.SunriseSunset(s.latitude, s.longitude, prev.Year(), prev.Month(), prev.Day())
+	if now.After(rise) && now.Before(set) {
+		// During the day - between sunrise and sunset
+		return 0, false, set.Sub(now)
+	}
+
+	var waitIntervalDuration time.Duration
+	var tillNextInterval time.Duration
+	var currInterval int
+	if now.Before(rise) {
+		// in the night after midnight but before sunrise.
+		// The "total" night duration is this days sunrise -
+		// previous days sunset The lenght that each
+		// configured LED value should be used is computed by
+		// dividing the night duration by the number of
+		// configured night LED Konfigurations
+		waitIntervalDuration = time.Duration(rise.Sub(set_prev_day).Nanoseconds() / int64(len(s.ledNight)))
+		currInterval = int(now.Sub(set_prev_day) / waitIntervalDuration)
+		tillNextInterval = set_prev_day.Add(time.Duration((currInterval + 1)) * waitIntervalDuration).Sub(now)
+	} else {
+		// in the night before midnight - similar as above but
+		// using current days sunset and next days sunrise
+		waitIntervalDuration = time.Duration(rise_next_day.Sub(set).Nanoseconds() / int64(len(s.ledNight)))
+		currInterval = int(now.Sub(set) / waitIntervalDuration)
+		tillNextInterval = set.Add(time.Duration((currInterval + 1)) * waitIntervalDuration).Sub(now)
+	}
+	// + 1s maybe not needed, but so we are sure to really be
+	// in the next interval
+	return currInterval, true, tillNextInterval + time.Second
+}
+
+// nightStops resolves the stops for the night following the noon of
+// the day of date, in chronological order. Stops whose event doesn't
+// happen in that night (e.g. no astronomical dusk in summer far up
+// north) are skipped.
+func (s *NightlightProducer) nightStops(date time.Time) []timedStop {
+	year, month, day := date.Date()
+	noon := time.Date(year, month, day, 12, 0, 0, 0, date.Location())
+	nextNoon := noon.AddDate(0, 0, 1)
+	stops := make([]timedStop, 0, len(s.stops))
+	for _, stop := range s.stops {
+		for _, d := range []time.Time{noon, nextNoon} {
+			if at, ok := stop.at.On(d, s.latitude, s.longitude); ok && !at.Before(noon) && at.Before(nextNoon) {
+				stops = append(stops, timedStop{at: at, color: stop.color})
+				break
+			}
+		}
+	}
+	sort.SliceStable(stops, func(i, j int) bool { return stops[i].at.Before(stops[j].at) })
+	return stops
+}
+
+// gradientPhase computes the interpolated color of the configured
+// stops at the given time. night is false outside of the stops.
+// wakeupAfter is the duration until the color changes visibly, i.e.
+// by about one unit in one of its channels.
+func (s *NightlightProducer) gradientPhase(now time.Time) (color Led, night bool, wakeupAfter time.Duration) {
+	nightOf := now
+	if now.Hour() < 12 {
+		nightOf = now.AddDate(0, 0, -1)
+	}
+	year, month, day := nightOf.Date()
+	nextNoon := time.Date(year, month, day+1, 12, 0, 0, 0, now.Location())
+
+	stops := s.nightStops(nightOf)
+	if len(stops) < 2 || !now.Before(stops[len(stops)-1].at) {
+		// Night is over (or no night at all), check again at noon
+		return Led{}, false, nextNoon.Sub(now)
+	}
+	if now.Before(stops[0].at) {
+		return Led{}, false, stops[0].at.Sub(now)
+	}
+
+	i := sort.Search(len(stops), func(i int) bool { return stops[i].at.After(now) }) - 1
+	from, to := stops[i], stops[i+1]
+	length := to.at.Sub(from.at)
+	f := float64(now.Sub(from.at)) / float64(length)
+	color = Led{
+		Red:   from.color.Red + f*(to.color.Red-from.color.Red),
+		Green: from.color.Green + f*(to.color.Green-from.color.Green),
+		Blue:  from.color.Blue + f*(to.color.Blue-from.color.Blue),
+	}
+
+	wakeupAfter = to.at.Sub(now)
+	diff := math.Max(math.Abs(to.color.Red-from.color.Red),
+		math.Max(math.Abs(to.color.Green-from.color.Green), math.Abs(to.color.Blue-from.color.Blue)))
+	if diff > 0 {
+		step := max(time.Duration(float64(length)/diff), minGradientStep)
+		wakeupAfter = min(wakeupAfter, step)
 	}
+	return color, true, wakeupAfter
 }
 
 func (s *NightlightProducer) runner() {
@@ -45,48 +180,26 @@ func (s *NightlightProducer) runner() {
 	}()
 
 	for {
-		now := time.Now()
-		next := now.Add(24 * time.Hour)  // tomorrow
-		prev := now.Add(-24 * time.Hour) // yesterday
-		rise, set := sunrise.SunriseSunset(s.latitude, s.longitude, now.Year(), now.Month(), now.Day())
-		rise_next_day, _ := sunrise.SunriseSunset(s.latitude, s.longitude, next.Year(), next.Month(), next.Day())
-		_, set_prev_day := sunrise.SunriseSunset(s.latitude, s.longitude, prev.Year(), prev.Month(), prev.Day())
+		var color Led
+		var night bool
 		var wakeupAfter time.Duration
-		if now.After(rise) && now.Before(set) {
-			// During the day - between sunrise and sunset
-			s.leds = make([]Led, len(s.leds)) // Reset LEDs
-			s.ledsChanged.Send(s.GetUID(), s)
-			wakeupAfter = set.Sub(now)
+		if len(s.stops) > 0 {
+			color, night, wakeupAfter = s.gradientPhase(s.clock.Now())
 		} else {
-			var waitIntervalDuration time.Duration
-			var tillNextInterval time.Duration
 			var currInterval int
-			if now.Before(rise) {
-				// in the night after midnight but before sunrise.
-				// The "total" night duration is this days sunrise -
-				// previous days sunset The lenght that each
-				// configured LED value should be used is computed by
-				// dividing the night duration by the number of
-				// configured night LED Konfigurations
-				waitIntervalDuration = time.Duration(rise.Sub(set_prev_day).Nanoseconds() / int64(len(s.ledNight)))
-				currInterval = int(now.Sub(set_prev_day) / waitIntervalDuration)
-				tillNextInterval = set_prev_day.Add(time.Duration((currInterval + 1)) * waitIntervalDuration).Sub(now)
-			} else {
-				// in the night before midnight - similar as above but
-				// using current days sunset and next days sunrise
-				waitIntervalDuration = time.Duration(rise_next_day.Sub(set).Nanoseconds() / int64(len(s.ledNight)))
-				currInterval = int(now.Sub(set) / waitIntervalDuration)
-				tillNextInterval = set.Add(time.Duration((currInterval + 1)) * waitIntervalDuration).Sub(now)
+			currInterval, night, wakeupAfter = s.nightPhase(s.clock.Now())
+			if night {
+				color = s.ledNight[currInterval]
 			}
-			// log.Printf("Current NightLED index %d : waitInterval %d : tillNextInterval %d", currInterval, waitIntervalDuration, tillNextInterval)
-			s.setNightLed(currInterval)
-			s.ledsChanged.Send(s.GetUID(), s)
-			// + 1s maybe not needed, but so we are sure to really be
-			// in the next interval
-			wakeupAfter = tillNextInterval + time.Second
 		}
+		if night {
+			s.setNightLed(color)
+		} else {
+			s.leds = make([]Led, len(s.leds)) // Reset LEDs
+		}
+		s.ledsChanged.Send(s.GetUID(), s)
 		select {
-		case <-time.After(wakeupAfter):
+		case <-s.clock.After(wakeupAfter):
 			// nothing, just continue
 		case <-s.stopchan:
 			// log.Println("Stopped NightlightProducer...")
@@ -0,0 +1,161 @@
+package producer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nathan-osman/go-sunrise"
+	"github.com/stretchr/testify/assert"
+	c "lautenbacher.net/goleds/config"
+	u "lautenbacher.net/goleds/util"
+)
+
+const (
+	testLatitude  = 49.014
+	testLongitude = 8.4043
+)
+
+var nightColors = [][]float64{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+
+func TestNightlightProducer_nightPhase(t *testing.T) {
+	p := NewNightlightProducer("night", u.NewAtomicMapEvent[LedProducer](), 5, testLatitude, testLongitude, nightColors, nil)
+	rise, set := sunrise.SunriseSunset(testLatitude, testLongitude, 2024, time.June, 21)
+	riseNext, _ := sunrise.SunriseSunset(testLatitude, testLongitude, 2024, time.June, 22)
+
+	// Noon: day, wake up at sunset
+	noon := time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC)
+	_, night, wakeup := p.nightPhase(noon)
+	assert.False(t, night)
+	assert.Equal(t, set.Sub(noon), wakeup)
+
+	// The night is split into three equally long intervals.
+	third := riseNext.Sub(set) / 3
+	index, night, wakeup := p.nightPhase(set.Add(time.Minute))
+	assert.True(t, night)
+	assert.Equal(t, 0, index)
+	assert.Equal(t, third-time.Minute+time.Second, wakeup)
+
+	index, night, _ = p.nightPhase(set.Add(third + time.Minute))
+	assert.True(t, night)
+	assert.Equal(t, 1, index)
+
+	// After midnight the previous day's sunset is used.
+	index, night, _ = p.nightPhase(riseNext.Add(-time.Minute))
+	assert.True(t, night)
+	assert.Equal(t, 2, index)
+
+	// Early morning before sunrise of the same day
+	index, night, _ = p.nightPhase(rise.Add(-time.Minute))
+	assert.True(t, night)
+	assert.Equal(t, 2, index)
+}
+
+func TestNightlightProducer_WholeNightWithFakeClock(t *testing.T) {
+	_, set := sunrise.SunriseSunset(testLatitude, testLongitude, 2024, time.June, 21)
+	riseNext, _ := sunrise.SunriseSunset(testLatitude, testLongitude, 2024, time.June, 22)
+	clock := u.NewFakeClock(time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC))
+
+	p := NewNightlightProducer("night", u.NewAtomicMapEvent[LedProducer](), 5, testLatitude, testLongitude, nightColors, nil)
+	p.SetClock(clock)
+	p.Start()
+	defer p.Exit()
+
+	leds := make([]Led, 5)
+	expectColor := func(expected Led) {
+		t.Helper()
+		// The runner sets the LEDs before it waits for the next wakeup.
+		clock.BlockUntil(1)
+		p.GetLeds(leds)
+		for i, led := range leds {
+			assert.Equal(t, expected, led, "LED %d at %s", i, clock.Now())
+		}
+	}
+
+	expectColor(Led{})
+	third := riseNext.Sub(set) / 3
+
+	clock.AdvanceTo(set.Add(time.Minute))
+	expectColor(Led{1, 0, 0})
+
+	clock.AdvanceTo(set.Add(third + time.Minute))
+	expectColor(Led{0, 1, 0})
+
+	clock.AdvanceTo(set.Add(2*third + time.Minute))
+	expectColor(Led{0, 0, 1})
+
+	clock.AdvanceTo(riseNext.Add(time.Minute))
+	expectColor(Led{})
+}
+
+var gradientStops = []c.NightLEDStop{
+	{At: "sunset", LedRGB: []float64{0, 0, 0}},
+	{At: "civil-dusk", LedRGB: []float64{100, 40, 0}},
+	{At: "23:00", LedRGB: []float64{10, 0, 0}},
+	{At: "03:00", LedRGB: []float64{10, 0, 0}}, // sunrise is at about 03:20 UTC
+	{At: "sunrise", LedRGB: []float64{0, 0, 0}},
+}
+
+func TestNightlightProducer_gradientPhase(t *testing.T) {
+	p := NewNightlightProducer("night", u.NewAtomicMapEvent[LedProducer](), 5, testLatitude, testLongitude, nil, gradientStops)
+	_, set := sunrise.SunriseSunset(testLatitude, testLongitude, 2024, time.June, 21)
+	riseNext, _ := sunrise.SunriseSunset(testLatitude, testLongitude, 2024, time.June, 22)
+	_, dusk := sunrise.TimeOfElevation(testLatitude, testLongitude, -6, 2024, time.June, 21)
+
+	// Day: off, wake up at sunset
+	noon := time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC)
+	_, night, wakeup := p.gradientPhase(noon)
+	assert.False(t, night)
+	assert.Equal(t, set.Sub(noon), wakeup)
+
+	// Halfway between sunset and civil dusk
+	half := set.Add(dusk.Sub(set) / 2)
+	color, night, wakeup := p.gradientPhase(half)
+	assert.True(t, night)
+	assert.InDelta(t, 50, color.Red, 0.01)
+	assert.InDelta(t, 20, color.Green, 0.01)
+	assert.Equal(t, 0.0, color.Blue)
+	// The red channel changes by 100 during the ramp: one update per unit.
+	assert.Equal(t, dusk.Sub(set)/100, wakeup)
+
+	// Constant color between 23:00 and 03:00: wake up at the next stop,
+	// also after midnight.
+	color, night, wakeup = p.gradientPhase(time.Date(2024, 6, 22, 1, 0, 0, 0, time.UTC))
+	assert.True(t, night)
+	assert.Equal(t, Led{Red: 10}, color)
+	assert.Equal(t, 2*time.Hour, wakeup)
+
+	// The night ends at sunrise, the next check is at noon.
+	_, night, wakeup = p.gradientPhase(riseNext.Add(time.Minute))
+	assert.False(t, night)
+	assert.Equal(t, time.Date(2024, 6, 22, 12, 0, 0, 0, time.UTC).Sub(riseNext.Add(time.Minute)), wakeup)
+}
+
+func TestNightlightProducer_GradientWithFakeClock(t *testing.T) {
+	_, set := sunrise.SunriseSunset(testLatitude, testLongitude, 2024, time.June, 21)
+	clock := u.NewFakeClock(time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC))
+
+	p := NewNightlightProducer("night", u.NewAtomicMapEvent[LedProducer](), 5, testLatitude, testLongitude, nil, gradientStops)
+	p.SetClock(clock)
+	p.Start()
+	defer p.Exit()
+
+	leds := make([]Led, 5)
+	red := func() float64 {
+		clock.BlockUntil(1)
+		p.GetLeds(leds)
+		return leds[0].Red
+	}
+	assert.Equal(t, 0.0, red())
+
+	// The producer wakes up often during the ramp, so the red channel
+	// grows in small steps.
+	clock.AdvanceTo(set)
+	last := red()
+	for i := 0; i < 20; i++ {
+		clock.Advance(30 * time.Second)
+		curr := red()
+		assert.Greater(t, curr, last, "at %s", clock.Now())
+		assert.Less(t, curr-last, 3.0, "at %s", clock.Now())
+		last = curr
+	}
+}
@@ -0,0 +1,62 @@
+package producer
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	c "lautenbacher.net/goleds/config"
+	u "lautenbacher.net/goleds/util"
+)
+
+// noiseEffect colours the LEDs by Perlin noise along the strip and
+// over time, so the colours flow and change smoothly. With a matrix
+// layout the noise is sampled at the cells of the LEDs and drifts
+// diagonally over the matrix. With positions it is sampled at the
+// distance along the strip in metres, so it continues across gaps.
+type noiseEffect struct {
+	cfg      c.NoiseLEDConfig
+	perlin   *u.Perlin
+	cells    []Cell // nil without layout
+	inMatrix []bool
+	path     []float64 // nil without positions
+}
+
+// NewNoiseProducer creates the NoiseLED producer. endwg is only used
+// with AfterSensor.
+func NewNoiseProducer(uid string, ledsChanged *u.AtomicMapEvent[LedProducer], ledsTotal int, cfg c.NoiseLEDConfig, endwg *sync.WaitGroup) *AmbientProducer {
+	return newAmbientProducer(uid, ledsChanged, ledsTotal, cfg.AmbientConfig, &noiseEffect{cfg: cfg}, endwg)
+}
+
+// reset drops the noise, it is created again from the reseeded random
+// numbers.
+func (s *noiseEffect) reset() {
+	s.perlin = nil
+}
+
+func (s *noiseEffect) step(frame []Led, rng *rand.Rand, elapsed, dt time.Duration) {
+	if s.perlin == nil {
+		s.perlin = u.NewPerlin(rng)
+	}
+	y := elapsed.Seconds() * s.cfg.Speed
+	for k := range frame {
+		var n float64
+		if s.cells != nil && s.inMatrix[k] {
+			cell := s.cells[k]
+			n = s.perlin.Noise2D(float64(cell.X)/s.cfg.Scale+y, float64(cell.Y)/s.cfg.Scale+y)
+		} else if s.path != nil {
+			n = s.perlin.Noise2D(s.path[k]/s.cfg.Scale, y)
+		} else {
+			n = s.perlin.Noise2D(float64(k)/s.cfg.Scale, y)
+		}
+		frame[k] = gradientColor(s.cfg.Colors, (n+1)/2)
+	}
+}
+
+func (s *noiseEffect) setCells(cells []Cell, inMatrix []bool) {
+	s.cells, s.inMatrix = cells, inMatrix
+}
+
+func (s *noiseEffect) setPath(path []float64) {
+	s.path = path
+}
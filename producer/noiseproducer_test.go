@@ -0,0 +1,70 @@
+package producer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	c "lautenbacher.net/goleds/config"
+	u "lautenbacher.net/goleds/util"
+)
+
+func TestNoiseProducer_step(t *testing.T) {
+	cfg := c.NoiseLEDConfig{
+		AmbientConfig: c.AmbientConfig{StartLed: 0, EndLed: 49, Delay: 50 * time.Millisecond, Seed: 5},
+		Colors:        [][]float64{{0, 0, 0, 255}, {1, 255, 0, 0}},
+		Scale:         10,
+		Speed:         0,
+	}
+	p := NewNoiseProducer("noise", u.NewAtomicMapEvent[LedProducer](), 50, cfg, nil)
+	frame := make([]Led, 50)
+	p.effect.step(p.frame, p.rng, time.Second, cfg.Delay)
+	copy(frame, p.frame)
+
+	// The colours change smoothly along the strip.
+	for k := 1; k < 50; k++ {
+		assert.InDelta(t, frame[k-1].Red, frame[k].Red, 60, "LED %d", k)
+	}
+	assert.NotEqual(t, frame[0], frame[25])
+
+	// Without Speed they stand still, otherwise they flow.
+	p.effect.step(p.frame, p.rng, 2*time.Second, cfg.Delay)
+	assert.Equal(t, frame, p.frame)
+	p.effect.(*noiseEffect).cfg.Speed = 1
+	p.effect.step(p.frame, p.rng, 2*time.Second, cfg.Delay)
+	assert.NotEqual(t, frame, p.frame)
+}
+
+func TestNoiseProducer_layout(t *testing.T) {
+	cfg := c.NoiseLEDConfig{
+		AmbientConfig: c.AmbientConfig{StartLed: 0, EndLed: 9, Delay: 50 * time.Millisecond, Seed: 5},
+		Colors:        [][]float64{{0, 0, 0, 255}, {1, 255, 0, 0}},
+		Scale:         4,
+	}
+	// A serpentine 5x2 matrix: LEDs 0 and 9 are above each other.
+	layout := NewLayout(c.LayoutConfig{Enabled: true, Width: 5, Height: 2, Serpentine: true}, 10)
+	p := NewNoiseProducer("noise", u.NewAtomicMapEvent[LedProducer](), 10, cfg, nil)
+	p.SetLayout(layout)
+	p.effect.step(p.frame, p.rng, 0, cfg.Delay)
+
+	// The noise is sampled at the cells, LED 9 is next to LED 0 now.
+	perlin := p.effect.(*noiseEffect).perlin
+	assert.Equal(t, gradientColor(cfg.Colors, (perlin.Noise2D(0, 0.25)+1)/2), p.frame[9])
+	assert.Equal(t, gradientColor(cfg.Colors, (perlin.Noise2D(0.25, 0.25)+1)/2), p.frame[8])
+}
+
+func TestNoiseProducer_positions(t *testing.T) {
+	cfg := c.NoiseLEDConfig{
+		AmbientConfig: c.AmbientConfig{StartLed: 9, EndLed: 0, Delay: 50 * time.Millisecond, Seed: 5},
+		Colors:        [][]float64{{0, 0, 0, 255}, {1, 255, 0, 0}},
+		Scale:         2,
+	}
+	p := NewNoiseProducer("noise", u.NewAtomicMapEvent[LedProducer](), 10, cfg, nil)
+	p.SetPositions(NewPositions(gapPositions, 10))
+	p.effect.step(p.frame, p.rng, 0, cfg.Delay)
+
+	// The noise is sampled at the metres along the strip, LED 5 (frame
+	// index 4) lies 3m from LED 0.
+	perlin := p.effect.(*noiseEffect).perlin
+	assert.Equal(t, gradientColor(cfg.Colors, (perlin.Noise2D(1.5, 0)+1)/2), p.frame[4])
+}
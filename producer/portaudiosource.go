@@ -0,0 +1,109 @@
+package producer
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/gordonklaus/portaudio"
+)
+
+var (
+	paMutex       sync.Mutex
+	paInitialized bool
+)
+
+// portaudioSource reads from the PortAudio input device whose name
+// contains device.
+type portaudioSource struct {
+	stream   *portaudio.Stream
+	buffer   []float32
+	channels int
+}
+
+func newPortAudioSource(device string, sampleRate, framesPerBuffer int) (*portaudioSource, error) {
+	paMutex.Lock()
+	if !paInitialized {
+		if err := portaudio.Initialize(); err != nil {
+			paMutex.Unlock()
+			return nil, fmt.Errorf("failed to initialize portaudio: %w", err)
+		}
+		slog.Info("AudioSource: PortAudio initialized.")
+		paInitialized = true
+	}
+	paMutex.Unlock()
+
+	inDevice, err := findDevice(device)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("AudioSource", "device", inDevice.Name, "sampleRate", sampleRate, "framesPerBuffer", framesPerBuffer)
+
+	source := &portaudioSource{
+		buffer:   make([]float32, framesPerBuffer*inDevice.MaxInputChannels),
+		channels: inDevice.MaxInputChannels,
+	}
+	streamParams := portaudio.StreamParameters{
+		Input: portaudio.StreamDeviceParameters{
+			Device:   inDevice,
+			Channels: inDevice.MaxInputChannels,
+			Latency:  inDevice.DefaultLowInputLatency,
+		},
+		SampleRate:      float64(sampleRate),
+		FramesPerBuffer: framesPerBuffer,
+	}
+	if source.stream, err = portaudio.OpenStream(streamParams, source.buffer); err != nil {
+		return nil, fmt.Errorf("failed to open stream: %w", err)
+	}
+	if err := source.stream.Start(); err != nil {
+		source.stream.Close()
+		return nil, fmt.Errorf("failed to start stream: %w", err)
+	}
+	return source, nil
+}
+
+func findDevice(name string) (*portaudio.DeviceInfo, error) {
+	devices, err := portaudio.Devices()
+	if err != nil {
+		return nil, fmt.Errorf("could not list audio devices: %w", err)
+	}
+
+	// Look for a squeezelite device
+	for _, device := range devices {
+		if device.MaxInputChannels > 0 && strings.Contains(strings.ToLower(device.Name), name) {
+			return device, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no suitable audio input device found")
+}
+
+// terminatePortAudio releases PortAudio if it was initialised.
+func terminatePortAudio() {
+	paMutex.Lock()
+	defer paMutex.Unlock()
+	if paInitialized {
+		if err := portaudio.Terminate(); err != nil {
+			slog.Error("AudioSource: failed to terminate portaudio", "error", err)
+		} else {
+			slog.Info("AudioSource: PortAudio terminated.")
+			paInitialized = false
+		}
+	}
+}
+
+func (s *portaudioSource) Channels() int {
+	return s.channels
+}
+
+func (s *portaudioSource) Read(buffer []float32) error {
+	err := s.stream.Read()
+	copy(buffer, s.buffer)
+	return err
+}
+
+func (s *portaudioSource) Close() error {
+	s.stream.Stop()
+	return s.stream.Close()
+}
@@ -0,0 +1,99 @@
+package producer
+
+import (
+	"math"
+
+	c "lautenbacher.net/goleds/config"
+)
+
+// Positions gives the LEDs of the virtual strip physical coordinates
+// in metres, so producers can work with real distances. LEDs in
+// invisible gaps get the coordinates between their visible neighbours,
+// which keeps effects continuous across the gaps.
+type Positions struct {
+	points [][]float64 // point of every LED
+	path   []float64   // distance of every LED from LED 0 along the strip
+}
+
+// NewPositions creates the positions described by cfg, nil if they
+// aren't enabled. cfg must be valid for ledsTotal.
+func NewPositions(cfg c.PositionsConfig, ledsTotal int) *Positions {
+	if !cfg.Enabled {
+		return nil
+	}
+	inst := &Positions{
+		points: make([][]float64, ledsTotal),
+		path:   make([]float64, ledsTotal),
+	}
+	for led := range inst.points {
+		inst.points[led] = cfg.PointOf(led)
+	}
+	for i := 1; i < ledsTotal; i++ {
+		inst.path[i] = inst.path[i-1] + distance(inst.points[i-1], inst.points[i])
+	}
+	return inst
+}
+
+// Point returns the coordinates of the LED index.
+func (pos *Positions) Point(index int) []float64 {
+	return pos.points[index]
+}
+
+// Path returns the distance of the LED index from LED 0 along the
+// strip.
+func (pos *Positions) Path(index int) float64 {
+	return pos.path[index]
+}
+
+// Paths returns the distance of every LED from LED 0 along the strip.
+// The slice must not be modified.
+func (pos *Positions) Paths() []float64 {
+	return pos.path
+}
+
+// PathOf returns the distance from LED 0 along the strip of the point
+// of the strip nearest to point.
+func (pos *Positions) PathOf(point []float64) float64 {
+	best, bestDist := 0.0, math.Inf(1)
+	for i := 1; i < len(pos.points); i++ {
+		a, b := pos.points[i-1], pos.points[i]
+		length := pos.path[i] - pos.path[i-1]
+		f := 0.0
+		if length > 0 {
+			// Projection of point onto the line from a to b.
+			for d := range a {
+				f += (point[d] - a[d]) * (b[d] - a[d])
+			}
+			f = math.Max(0, math.Min(1, f/(length*length)))
+		}
+		nearest := make([]float64, len(a))
+		for d := range a {
+			nearest[d] = a[d] + f*(b[d]-a[d])
+		}
+		if dist := distance(point, nearest); dist < bestDist {
+			best, bestDist = pos.path[i-1]+f*length, dist
+		}
+	}
+	return best
+}
+
+// NearestLed returns the LED nearest to the distance path from LED 0
+// along the strip.
+func (pos *Positions) NearestLed(path float64) int {
+	nearest := 0
+	for i, p := range pos.path {
+		if math.Abs(p-path) < math.Abs(pos.path[nearest]-path) {
+			nearest = i
+		}
+	}
+	return nearest
+}
+
+// distance returns the euclidean distance of the points a and b.
+func distance(a, b []float64) float64 {
+	sum := 0.0
+	for d := range a {
+		sum += (a[d] - b[d]) * (a[d] - b[d])
+	}
+	return math.Sqrt(sum)
+}
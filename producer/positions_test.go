@@ -0,0 +1,44 @@
+package producer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	c "lautenbacher.net/goleds/config"
+)
+
+// gapPositions places 10 LEDs 0.25m apart along a path with a gap of
+// 2m between LED 4 and 5 (e.g. a doorway).
+var gapPositions = c.PositionsConfig{Enabled: true, Points: []c.PositionPointConfig{
+	{Led: 0, At: []float64{0}},
+	{Led: 4, At: []float64{1}},
+	{Led: 5, At: []float64{3}},
+	{Led: 9, At: []float64{4}},
+}}
+
+func TestNewPositions(t *testing.T) {
+	assert.Nil(t, NewPositions(c.PositionsConfig{}, 10))
+
+	pos := NewPositions(gapPositions, 10)
+	assert.Equal(t, []float64{0, 0.25, 0.5, 0.75, 1, 3, 3.25, 3.5, 3.75, 4}, pos.Paths())
+	assert.Equal(t, []float64{0.5}, pos.Point(2))
+	assert.Equal(t, 5, pos.NearestLed(2.1))
+	assert.Equal(t, 4, pos.NearestLed(1.9))
+}
+
+func TestPositions_corner(t *testing.T) {
+	// A strip of 7 LEDs 0.5m apart running around a corner at LED 3.
+	pos := NewPositions(c.PositionsConfig{Enabled: true, Points: []c.PositionPointConfig{
+		{Led: 0, At: []float64{0, 0}},
+		{Led: 3, At: []float64{1.5, 0}},
+		{Led: 6, At: []float64{1.5, 1.5}},
+	}}, 7)
+	assert.Equal(t, []float64{1.5, 1}, pos.Point(5))
+	assert.InDelta(t, 2.5, pos.Path(5), 1e-9)
+
+	// The sensor is 0.2m in front of the wall with LEDs 3 to 6.
+	assert.InDelta(t, 2.2, pos.PathOf([]float64{1.3, 0.7}), 1e-9)
+	// Points beyond the ends belong to the ends.
+	assert.InDelta(t, 0, pos.PathOf([]float64{-1, 0}), 1e-9)
+	assert.InDelta(t, 3, pos.PathOf([]float64{1.5, 2}), 1e-9)
+}
@@ -16,6 +16,9 @@
 //   - runDownT: The delay between steps as the light contracts inwards.
 //   - holdT: The minimum duration the entire strip remains lit after the last
 //     trigger.
+//   - runUpSpeed, runDownSpeed: With positions (SetPositions) the metres per
+//     second the light spreads and contracts along the strip, runUpT and
+//     runDownT are then the time between two frames.
 //
 // 2. Animation Cycle (State Machine):
 // The core logic is implemented as a state machine orchestrated by the runner
@@ -59,11 +62,16 @@ type SensorLedProducer struct {
 	runUpT            t.Duration
 	runDownT          t.Duration
 	ledOn             Led
+	ledOnDefault      Led
 	latchEnabled      bool
 	latchTriggerValue int
 	latchTriggerDelay t.Duration
 	latchTime         t.Duration
 	latchLed          Led
+	runUpSpeed        float64
+	runDownSpeed      float64
+	path              []float64 // distance of every LED along the strip, nil without positions
+	origin            float64   // distance of the sensor along the strip
 }
 
 func NewSensorLedProducer(uid string, index int, ledsChanged *u.AtomicMapEvent[LedProducer], ledsTotal int, cfg c.SensorLEDConfig, endwg *sync.WaitGroup) *SensorLedProducer {
@@ -76,6 +84,8 @@ func NewSensorLedProducer(uid string, index int, ledsChanged *u.AtomicMapEvent[L
 		latchTriggerValue: cfg.LatchTriggerValue,
 		latchTriggerDelay: cfg.LatchTriggerDelay,
 		latchTime:         cfg.LatchTime,
+		runUpSpeed:        cfg.RunUpSpeed,
+		runDownSpeed:      cfg.RunDownSpeed,
 		ledOn: Led{
 			Red:   cfg.LedRGB[0],
 			Green: cfg.LedRGB[1],
@@ -87,6 +97,7 @@ func NewSensorLedProducer(uid string, index int, ledsChanged *u.AtomicMapEvent[L
 			Blue:  cfg.LatchLedRGB[2],
 		},
 	}
+	inst.ledOnDefault = inst.ledOn
 	inst.AbstractProducer = NewAbstractProducer(uid, ledsChanged, inst.runner, ledsTotal)
 	if endwg != nil {
 		inst.AbstractProducer.endWg = endwg
@@ -94,18 +105,66 @@ func NewSensorLedProducer(uid string, index int, ledsChanged *u.AtomicMapEvent[L
 	return inst
 }
 
+// SetPositions lets the light spread and contract by the configured
+// speeds along the strip, starting at the strip's point nearest to the
+// sensor's point, or at its LED if point is nil. positions may be nil.
+// It must be called before Start().
+func (s *SensorLedProducer) SetPositions(positions *Positions, point []float64) {
+	if positions == nil {
+		return
+	}
+	s.path = positions.Paths()
+	if point == nil {
+		s.origin = positions.Path(s.ledIndex)
+	} else {
+		s.origin = positions.PathOf(point)
+		s.ledIndex = positions.NearestLed(s.origin)
+	}
+}
+
+// SetColor implements Recolorable. LEDs that are currently lit with
+// the old colour change immediately.
+func (s *SensorLedProducer) SetColor(rgb []float64) {
+	color := s.ledOnDefault
+	if rgb != nil {
+		color = Led{Red: rgb[0], Green: rgb[1], Blue: rgb[2]}
+	}
+	s.ledsMutex.Lock()
+	old := s.ledOn
+	s.ledOn = color
+	for i, led := range s.leds {
+		if led == old {
+			s.leds[i] = color
+		}
+	}
+	s.ledsMutex.Unlock()
+	if old != color {
+		s.ledsChanged.Send(s.GetUID(), s)
+	}
+}
+
+// onColor returns the current colour of lit LEDs.
+func (s *SensorLedProducer) onColor() Led {
+	s.ledsMutex.RLock()
+	defer s.ledsMutex.RUnlock()
+	return s.ledOn
+}
+
 // runUpPhase handles the "run-up" part of the animation, where LEDs
 // are turned on from the center outwards.
 func (s *SensorLedProducer) runUpPhase(left, right int) (nleft, nright int, stopped bool) {
-	ticker := t.NewTicker(s.runUpT)
+	if s.path != nil && s.runUpSpeed > 0 {
+		return s.runUpMetres(left, right)
+	}
+	ticker := s.clock.NewTicker(s.runUpT)
 	defer ticker.Stop()
 
 	for {
 		if left >= 0 {
-			s.setLed(left, s.ledOn)
+			s.setLed(left, s.onColor())
 		}
 		if right < len(s.leds) {
-			s.setLed(right, s.ledOn)
+			s.setLed(right, s.onColor())
 		}
 		s.ledsChanged.Send(s.GetUID(), s)
 
@@ -118,7 +177,45 @@ func (s *SensorLedProducer) runUpPhase(left, right int) (nleft, nright int, stop
 		right++
 
 		select {
-		case <-ticker.C:
+		case <-ticker.C():
+		case <-s.stopchan:
+			return left, right, true
+		}
+	}
+}
+
+// runUpMetres is the run-up with positions: both ends of the light
+// move outwards by runUpSpeed along the strip, lighting all LEDs they
+// pass.
+func (s *SensorLedProducer) runUpMetres(left, right int) (nleft, nright int, stopped bool) {
+	ticker := s.clock.NewTicker(s.runUpT)
+	defer ticker.Stop()
+
+	// The reach grows with the time since the start, so late ticks
+	// don't slow the light down.
+	start := s.clock.Now()
+	startLeft, startRight := s.origin-s.path[left], s.path[right]-s.origin
+	for {
+		grown := s.runUpSpeed * s.clock.Since(start).Seconds()
+		reachLeft, reachRight := startLeft+grown, startRight+grown
+		for left > 0 && s.origin-s.path[left-1] <= reachLeft {
+			left--
+		}
+		for right < len(s.leds)-1 && s.path[right+1]-s.origin <= reachRight {
+			right++
+		}
+		for i := left; i <= right; i++ {
+			s.setLed(i, s.onColor())
+		}
+		s.ledsChanged.Send(s.GetUID(), s)
+
+		if left == 0 && right == len(s.leds)-1 {
+			// run-up is complete
+			return left, right, false
+		}
+
+		select {
+		case <-ticker.C():
 		case <-s.stopchan:
 			return left, right, true
 		}
@@ -132,13 +229,13 @@ func (s *SensorLedProducer) holdPhase() (stopped bool) {
 	inLatchZone := false
 
 	for {
-		holdTimer := t.NewTimer(s.holdT)
+		holdTimer := s.clock.NewTimer(s.holdT)
 
 		select {
 		case <-s.stopchan:
 			holdTimer.Stop()
 			return true // Stop requested
-		case <-holdTimer.C:
+		case <-holdTimer.C():
 			return false // Hold time expired
 		case <-s.triggerEvent.Channel():
 			holdTimer.Stop() // Reset hold timer on any trigger
@@ -151,7 +248,7 @@ func (s *SensorLedProducer) holdPhase() (stopped bool) {
 					latchStart = trigger.Timestamp
 				} else {
 					// Check if the latch-on delay has been met
-					if t.Since(latchStart) >= s.latchTriggerDelay {
+					if s.clock.Since(latchStart) >= s.latchTriggerDelay {
 						if s.runLatchMode() {
 							return true // Latch mode was stopped via stopchan
 						}
@@ -180,12 +277,12 @@ func (s *SensorLedProducer) runLatchMode() (stopped bool) {
 	// Defer reverting the LEDs to the normal color to simplify exit paths.
 	defer func() {
 		for i := range s.leds {
-			s.setLed(i, s.ledOn)
+			s.setLed(i, s.onColor())
 		}
 		s.ledsChanged.Send(s.GetUID(), s)
 	}()
 
-	latchTimer := t.NewTimer(s.latchTime)
+	latchTimer := s.clock.NewTimer(s.latchTime)
 	defer latchTimer.Stop()
 
 	var latchOffStart t.Time
@@ -195,7 +292,7 @@ func (s *SensorLedProducer) runLatchMode() (stopped bool) {
 		select {
 		case <-s.stopchan:
 			return true // Stop requested by system
-		case <-latchTimer.C:
+		case <-latchTimer.C():
 			// Main latch time expired
 			slog.Info("Latch Mode Timed Out", "uid", s.GetUID())
 			return false
@@ -208,7 +305,7 @@ func (s *SensorLedProducer) runLatchMode() (stopped bool) {
 					latchOffStart = trigger.Timestamp
 				} else {
 					// Check if the latch-off delay has been met
-					if t.Since(latchOffStart) >= s.latchTriggerDelay {
+					if s.clock.Since(latchOffStart) >= s.latchTriggerDelay {
 						slog.Info("Latch Mode Deactivated by toggle", "uid", s.GetUID())
 						return false
 					}
@@ -225,7 +322,10 @@ func (s *SensorLedProducer) runLatchMode() (stopped bool) {
 // edges inwards. It can be interrupted by a new trigger, which
 // signals that the animation should restart.
 func (s *SensorLedProducer) runDownPhase(left, right int) (nleft, nright int, shouldRestart, stopped bool) {
-	ticker := t.NewTicker(s.runDownT)
+	if s.path != nil && s.runDownSpeed > 0 {
+		return s.runDownMetres(left, right)
+	}
+	ticker := s.clock.NewTicker(s.runDownT)
 	defer ticker.Stop()
 	for {
 		if left <= s.ledIndex && left >= 0 {
@@ -251,7 +351,48 @@ func (s *SensorLedProducer) runDownPhase(left, right int) (nleft, nright int, sh
 			return left, right, true, false
 		case <-s.stopchan:
 			return left, right, false, true // Stop requested
-		case <-ticker.C:
+		case <-ticker.C():
+			// Continue run-down phase
+		}
+	}
+}
+
+// runDownMetres is the run-down with positions: both ends of the light
+// move inwards by runDownSpeed along the strip, turning off all LEDs
+// they pass. The sensor's LED goes off when both are back at the
+// sensor.
+func (s *SensorLedProducer) runDownMetres(left, right int) (nleft, nright int, shouldRestart, stopped bool) {
+	ticker := s.clock.NewTicker(s.runDownT)
+	defer ticker.Stop()
+
+	start := s.clock.Now()
+	startLeft, startRight := s.origin-s.path[left], s.path[right]-s.origin
+	for {
+		shrunk := s.runDownSpeed * s.clock.Since(start).Seconds()
+		reachLeft, reachRight := startLeft-shrunk, startRight-shrunk
+		for left < s.ledIndex && s.origin-s.path[left] > reachLeft {
+			s.setLed(left, Led{})
+			left++
+		}
+		for right > s.ledIndex && s.path[right]-s.origin > reachRight {
+			s.setLed(right, Led{})
+			right--
+		}
+		if left == s.ledIndex && right == s.ledIndex && reachLeft < 0 && reachRight < 0 {
+			// Both ends are back at the sensor.
+			s.setLed(s.ledIndex, Led{})
+			s.ledsChanged.Send(s.GetUID(), s)
+			return left, right, false, false // normal exit
+		}
+		s.ledsChanged.Send(s.GetUID(), s)
+
+		select {
+		case <-s.triggerEvent.Channel():
+			// New trigger arrived, restart animation cycle
+			return left, right, true, false
+		case <-s.stopchan:
+			return left, right, false, true // Stop requested
+		case <-ticker.C():
 			// Continue run-down phase
 		}
 	}
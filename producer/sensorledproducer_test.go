@@ -0,0 +1,160 @@
+package producer
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	c "lautenbacher.net/goleds/config"
+	u "lautenbacher.net/goleds/util"
+)
+
+func countLit(leds []Led) int {
+	count := 0
+	for _, led := range leds {
+		if !led.IsEmpty() {
+			count++
+		}
+	}
+	return count
+}
+
+func TestSensorLedProducer_HoldTimeWithFakeClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC)
+	clock := u.NewFakeClock(start)
+	cfg := c.SensorLEDConfig{
+		RunUpDelay:   10 * time.Millisecond,
+		RunDownDelay: 10 * time.Millisecond,
+		HoldTime:     10 * time.Second,
+		LedRGB:       []float64{10, 10, 10},
+		LatchLedRGB:  []float64{0, 0, 0},
+	}
+	var wg sync.WaitGroup
+	p := NewSensorLedProducer("S0", 5, u.NewAtomicMapEvent[LedProducer](), 10, cfg, &wg)
+	p.SetClock(clock)
+	defer p.Exit()
+
+	leds := make([]Led, 10)
+	lit := func() int {
+		p.GetLeds(leds)
+		return countLit(leds)
+	}
+
+	p.SendTrigger(u.NewTrigger("S0", 200, clock.Now()))
+
+	// Run-up: 5 steps of 10ms until both ends are reached.
+	clock.BlockUntil(1)
+	clock.Advance(100 * time.Millisecond)
+	assert.Eventually(t, func() bool { return lit() == 10 }, time.Second, time.Millisecond, "strip should be fully lit after run-up")
+
+	// Hold: the strip stays lit for the whole HoldTime.
+	clock.BlockUntil(1)
+	clock.Advance(9 * time.Second)
+	assert.Equal(t, 10, lit(), "strip should still be lit during HoldTime")
+
+	// Run-down after HoldTime has expired. The run-down ticker is
+	// registered asynchronously, so the clock is advanced in small steps
+	// until the strip is dark.
+	assert.Eventually(t, func() bool {
+		clock.Advance(10 * time.Millisecond)
+		return lit() == 0
+	}, 2*time.Second, time.Millisecond, "strip should be dark after run-down")
+	assert.GreaterOrEqual(t, clock.Since(start), 10*time.Second, "run-down must not start before HoldTime")
+
+	done := make(chan bool)
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("SensorLedProducer did not finish after run-down")
+	}
+}
+
+func TestSensorLedProducer_BrightnessAndColor(t *testing.T) {
+	cfg := c.SensorLEDConfig{
+		LedRGB:      []float64{100, 50, 10},
+		LatchLedRGB: []float64{0, 0, 0},
+	}
+	p := NewSensorLedProducer("S0", 1, u.NewAtomicMapEvent[LedProducer](), 3, cfg, nil)
+	p.setLed(0, p.onColor())
+	p.setLed(1, Led{Red: 1})
+
+	leds := make([]Led, 3)
+	p.SetBrightness(0.5)
+	p.GetLeds(leds)
+	assert.Equal(t, []Led{{50, 25, 5}, {Red: 0.5}, {}}, leds)
+
+	// Only LEDs lit with the old colour are recoloured.
+	p.SetBrightness(1)
+	p.SetColor([]float64{10, 10, 10})
+	p.GetLeds(leds)
+	assert.Equal(t, []Led{{10, 10, 10}, {Red: 1}, {}}, leds)
+
+	p.SetColor(nil)
+	p.GetLeds(leds)
+	assert.Equal(t, Led{100, 50, 10}, leds[0])
+}
+
+func TestSensorLedProducer_Positions(t *testing.T) {
+	clock := u.NewFakeClock(time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC))
+	cfg := c.SensorLEDConfig{
+		RunUpDelay:   100 * time.Millisecond,
+		RunDownDelay: 100 * time.Millisecond,
+		HoldTime:     10 * time.Second,
+		LedRGB:       []float64{10, 10, 10},
+		LatchLedRGB:  []float64{0, 0, 0},
+		RunUpSpeed:   1,
+		RunDownSpeed: 2,
+	}
+	var wg sync.WaitGroup
+	p := NewSensorLedProducer("S0", 0, u.NewAtomicMapEvent[LedProducer](), 10, cfg, &wg)
+	p.SetPositions(NewPositions(gapPositions, 10), nil)
+	p.SetClock(clock)
+	defer p.Exit()
+
+	leds := make([]Led, 10)
+	lit := func() int {
+		p.GetLeds(leds)
+		return countLit(leds)
+	}
+
+	p.SendTrigger(u.NewTrigger("S0", 200, clock.Now()))
+	clock.BlockUntil(1)
+	assert.Eventually(t, func() bool { return lit() == 1 }, time.Second, time.Millisecond)
+
+	// At 1m/s the light reaches the gap after 1s and crosses it in 2s.
+	clock.Advance(time.Second)
+	assert.Eventually(t, func() bool { return lit() == 5 }, time.Second, time.Millisecond)
+	clock.Advance(1900 * time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 5, lit(), "LED 5 is 2m behind the gap")
+	clock.Advance(100 * time.Millisecond)
+	assert.Eventually(t, func() bool { return lit() == 6 }, time.Second, time.Millisecond)
+	clock.Advance(time.Second)
+	assert.Eventually(t, func() bool { return lit() == 10 }, time.Second, time.Millisecond)
+
+	// The run-down ends at the sensor, LED 0 is the last one lit.
+	assert.Eventually(t, func() bool {
+		clock.Advance(50 * time.Millisecond)
+		p.GetLeds(leds)
+		if lit() == 1 {
+			assert.False(t, leds[0].IsEmpty(), "the sensor's LED goes off last")
+		}
+		return lit() == 0
+	}, 2*time.Second, time.Millisecond)
+
+	done := make(chan bool)
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("SensorLedProducer did not finish after run-down")
+	}
+}
@@ -0,0 +1,69 @@
+package producer
+
+import (
+	"math"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	c "lautenbacher.net/goleds/config"
+	u "lautenbacher.net/goleds/util"
+)
+
+// twinkleStar is a star lit at an LED, age runs from 0 to 1 over the
+// Lifetime.
+type twinkleStar struct {
+	color Led
+	age   float64
+}
+
+// twinkleEffect lights stars at random dark LEDs that brighten and fade
+// again over their Lifetime.
+type twinkleEffect struct {
+	cfg    c.TwinkleLEDConfig
+	colors []Led
+	stars  []*twinkleStar
+}
+
+// NewTwinkleProducer creates the TwinkleLED producer. endwg is only
+// used with AfterSensor.
+func NewTwinkleProducer(uid string, ledsChanged *u.AtomicMapEvent[LedProducer], ledsTotal int, cfg c.TwinkleLEDConfig, endwg *sync.WaitGroup) *AmbientProducer {
+	effect := &twinkleEffect{cfg: cfg}
+	for _, rgb := range cfg.Colors {
+		effect.colors = append(effect.colors, Led{rgb[0], rgb[1], rgb[2]})
+	}
+	return newAmbientProducer(uid, ledsChanged, ledsTotal, cfg.AmbientConfig, effect, endwg)
+}
+
+func (s *twinkleEffect) reset() {
+	s.stars = nil
+}
+
+func (s *twinkleEffect) step(frame []Led, rng *rand.Rand, elapsed, dt time.Duration) {
+	if len(s.stars) != len(frame) {
+		s.stars = make([]*twinkleStar, len(frame))
+	}
+	// A star lives for lifetime steps. With the probability q of a
+	// dark LED to light up per step, the share lit on average is
+	// q*lifetime / (1 + q*lifetime), which is the Density.
+	lifetime := float64(s.cfg.Lifetime) / float64(max(dt, s.cfg.Delay))
+	q := s.cfg.Density / (lifetime * (1 - s.cfg.Density))
+	for k, star := range s.stars {
+		if star == nil && rng.Float64() < q {
+			star = &twinkleStar{color: s.colors[rng.IntN(len(s.colors))]}
+			s.stars[k] = star
+		}
+		if star != nil {
+			star.age += 1 / lifetime
+			if star.age >= 1 {
+				s.stars[k], star = nil, nil
+			}
+		}
+		if star == nil {
+			frame[k] = Led{}
+			continue
+		}
+		b := math.Sin(math.Pi * star.age)
+		frame[k] = Led{star.color.Red * b, star.color.Green * b, star.color.Blue * b}
+	}
+}
@@ -0,0 +1,52 @@
+package producer
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	c "lautenbacher.net/goleds/config"
+	u "lautenbacher.net/goleds/util"
+)
+
+func TestTwinkleProducer_step(t *testing.T) {
+	cfg := c.TwinkleLEDConfig{
+		AmbientConfig: c.AmbientConfig{StartLed: 0, EndLed: 99, Delay: 50 * time.Millisecond, Seed: 3},
+		Colors:        [][]float64{{255, 255, 255}, {0, 0, 255}},
+		Density:       0.2,
+		Lifetime:      time.Second,
+	}
+	p := NewTwinkleProducer("twinkle", u.NewAtomicMapEvent[LedProducer](), 100, cfg, nil)
+	stepAmbient(p, 100)
+
+	lit := 0
+	for range 500 {
+		for _, led := range stepAmbient(p, 1) {
+			if led.IsEmpty() {
+				continue
+			}
+			lit++
+			// The stars have one of the colours, dimmed.
+			white := led.Red > 0 && led.Red == led.Green && led.Green == led.Blue
+			blue := led.Red == 0 && led.Green == 0 && led.Blue > 0
+			assert.True(t, white || blue, "unexpected colour %v", led)
+			assert.LessOrEqual(t, led.Blue, 255.0)
+		}
+	}
+	assert.InDelta(t, 0.2, float64(lit)/500/100, 0.03, "Density of the LEDs are lit")
+
+	// A star brightens and fades again.
+	effect := p.effect.(*twinkleEffect)
+	k := slices.IndexFunc(effect.stars, func(star *twinkleStar) bool { return star != nil && star.age < 0.1 })
+	if assert.GreaterOrEqual(t, k, 0) {
+		var brightness []float64
+		for effect.stars[k] != nil {
+			brightness = append(brightness, stepAmbient(p, 1)[k].Blue)
+		}
+		peak := slices.Index(brightness, slices.Max(brightness))
+		assert.True(t, slices.IsSorted(brightness[:peak+1]))
+		assert.Greater(t, peak, 5)
+		assert.Less(t, peak, len(brightness)-1)
+	}
+}
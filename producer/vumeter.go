@@ -0,0 +1,174 @@
+package producer
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	c "lautenbacher.net/goleds/config"
+)
+
+// levelHold holds the peak of a level for hold, then lets it fall by
+// fallOff (full scale per second), or at once if fallOff is 0.
+type levelHold struct {
+	hold       time.Duration
+	fallOff    float64
+	level      float64
+	peakTime   time.Time
+	lastUpdate time.Time
+}
+
+// update adds the level at now and returns the held level.
+func (h *levelHold) update(level float64, now time.Time) float64 {
+	defer func() { h.lastUpdate = now }()
+	if level >= h.level {
+		h.level = level
+		h.peakTime = now
+		return h.level
+	}
+	fallStart := h.peakTime.Add(h.hold)
+	if now.Before(fallStart) {
+		return h.level
+	}
+	if h.fallOff <= 0 {
+		h.level = level
+		return h.level
+	}
+	if h.lastUpdate.After(fallStart) {
+		fallStart = h.lastUpdate
+	}
+	h.level = max(level, h.level-h.fallOff*now.Sub(fallStart).Seconds())
+	return h.level
+}
+
+// vuMeter shows a level as a bar: the level follows the input with the
+// attack and release time constants, an optional peak marker holds the
+// peaks of the input.
+type vuMeter struct {
+	attack     time.Duration
+	release    time.Duration
+	colors     [3]Led // green, yellow, red
+	peakColor  *Led
+	layout     string
+	level      float64
+	peak       levelHold
+	lastUpdate time.Time
+}
+
+func newVUMeter(cfg c.AudioLEDConfig) *vuMeter {
+	m := &vuMeter{
+		layout: strings.ToUpper(cfg.Layout),
+		peak:   levelHold{hold: cfg.PeakHold, fallOff: cfg.PeakFallOff},
+	}
+	switch strings.ToUpper(cfg.Ballistics) {
+	case "VU":
+		m.attack, m.release = 300*time.Millisecond, 300*time.Millisecond
+	case "PPM":
+		m.attack, m.release = 10*time.Millisecond, 650*time.Millisecond
+	}
+	if cfg.Attack > 0 {
+		m.attack = cfg.Attack
+	}
+	if cfg.Release > 0 {
+		m.release = cfg.Release
+	}
+	for i, rgb := range [][]float64{cfg.LedGreen, cfg.LedYellow, cfg.LedRed} {
+		m.colors[i] = Led{Red: rgb[0], Green: rgb[1], Blue: rgb[2]}
+	}
+	if len(cfg.PeakLedRGB) > 0 {
+		m.peakColor = &Led{Red: cfg.PeakLedRGB[0], Green: cfg.PeakLedRGB[1], Blue: cfg.PeakLedRGB[2]}
+	}
+	return m
+}
+
+// update adds the input level (0..1) at now and returns the level of
+// the bar and of the peak marker.
+func (m *vuMeter) update(input float64, now time.Time) (level, peak float64) {
+	tau := m.release
+	if input > m.level {
+		tau = m.attack
+	}
+	if m.lastUpdate.IsZero() || tau <= 0 {
+		m.level = input
+	} else {
+		m.level += (1 - math.Exp(-float64(now.Sub(m.lastUpdate))/float64(tau))) * (input - m.level)
+	}
+	m.lastUpdate = now
+	return m.level, m.peak.update(input, now)
+}
+
+// steps groups the positions of the bar into the steps it grows by: one
+// LED at a time from the start for the linear layout, two at a time
+// from the centre (CENTREOUT) or from both ends (MIRROR).
+func (m *vuMeter) steps(positions []int) [][]int {
+	n := len(positions)
+	if m.layout != "CENTREOUT" && m.layout != "MIRROR" {
+		steps := make([][]int, n)
+		for i, pos := range positions {
+			steps[i] = []int{pos}
+		}
+		return steps
+	}
+	// from the edges to the centre
+	steps := make([][]int, 0, (n+1)/2)
+	for i := 0; i < n-1-i; i++ {
+		steps = append(steps, []int{positions[i], positions[n-1-i]})
+	}
+	if n%2 == 1 {
+		steps = append(steps, []int{positions[n/2]})
+	}
+	if m.layout == "CENTREOUT" {
+		for i, j := 0, len(steps)-1; i < j; i, j = i+1, j-1 {
+			steps[i], steps[j] = steps[j], steps[i]
+		}
+	}
+	return steps
+}
+
+// draw shows the bar for level and the peak marker on the LEDs at
+// positions, in the order the bar grows.
+func (m *vuMeter) draw(leds []Led, positions []int, level, peak float64) {
+	steps := m.steps(positions)
+	n := len(steps)
+	lit := int(math.Ceil(level * float64(n)))
+
+	// Color sections: 70% green, 20% yellow, 10% red
+	greenEnd := int(float64(n) * 0.7)
+	yellowEnd := int(float64(n) * 0.9)
+
+	for i, step := range steps {
+		color := Led{} // Off
+		if i < lit {
+			switch {
+			case i < greenEnd:
+				color = m.colors[0]
+			case i < yellowEnd:
+				color = m.colors[1]
+			default:
+				color = m.colors[2]
+			}
+		}
+		for _, pos := range step {
+			leds[pos] = color
+		}
+	}
+	if m.peakColor != nil && peak > 0 {
+		for _, pos := range steps[min(int(math.Ceil(peak*float64(n))), n)-1] {
+			leds[pos] = *m.peakColor
+		}
+	}
+}
+
+// ledRange returns the LEDs from start to end (both included) in this
+// order.
+func ledRange(start, end int) []int {
+	direction := 1
+	if start > end {
+		direction = -1
+	}
+	positions := make([]int, 0, (end-start)*direction+1)
+	for i := start; i != end+direction; i += direction {
+		positions = append(positions, i)
+	}
+	return positions
+}
@@ -0,0 +1,94 @@
+package producer
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	c "lautenbacher.net/goleds/config"
+	u "lautenbacher.net/goleds/util"
+)
+
+func vuConfig() c.AudioLEDConfig {
+	return c.AudioLEDConfig{
+		StartLedLeft: 4, EndLedLeft: 0, StartLedRight: 5, EndLedRight: 9,
+		LedGreen: []float64{0, 10, 0}, LedYellow: []float64{10, 10, 0}, LedRed: []float64{10, 0, 0},
+		MinDB: -60, MaxDB: 0,
+	}
+}
+
+func TestVUMeter_Ballistics(t *testing.T) {
+	cfg := vuConfig()
+	cfg.Ballistics = "VU"
+	cfg.Attack = 100 * time.Millisecond
+	m := newVUMeter(cfg)
+	assert.Equal(t, 300*time.Millisecond, m.release, "the preset is kept unless overridden")
+
+	start := time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC)
+	level, _ := m.update(0, start)
+	assert.Equal(t, 0.0, level)
+	// One attack time constant reaches 63% of a jump.
+	level, _ = m.update(1, start.Add(100*time.Millisecond))
+	assert.InDelta(t, 1-math.Exp(-1), level, 1e-9)
+	// Releasing is slower.
+	released, _ := m.update(0, start.Add(400*time.Millisecond))
+	assert.InDelta(t, level*math.Exp(-1), released, 1e-9)
+
+	// Without ballistics the bar follows the input at once.
+	m = newVUMeter(vuConfig())
+	m.update(0, start)
+	level, _ = m.update(0.7, start.Add(time.Millisecond))
+	assert.Equal(t, 0.7, level)
+}
+
+func TestVUMeter_PeakMarker(t *testing.T) {
+	cfg := vuConfig()
+	cfg.PeakLedRGB = []float64{0, 0, 50}
+	cfg.PeakHold = time.Second
+	cfg.PeakFallOff = 0.5
+	m := newVUMeter(cfg)
+	start := time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC)
+
+	m.update(0.8, start)
+	level, peak := m.update(0.2, start.Add(500*time.Millisecond))
+	assert.Equal(t, 0.2, level)
+	assert.Equal(t, 0.8, peak)
+	_, peak = m.update(0.2, start.Add(1500*time.Millisecond))
+	assert.InDelta(t, 0.55, peak, 1e-9)
+
+	leds := make([]Led, 10)
+	m.draw(leds, ledRange(0, 9), 0.2, 0.55)
+	assert.Equal(t, Led{Green: 10}, leds[1])
+	assert.Equal(t, Led{}, leds[2])
+	assert.Equal(t, Led{Blue: 50}, leds[5])
+	assert.Equal(t, Led{}, leds[6])
+}
+
+func TestVUMeter_Layouts(t *testing.T) {
+	cfg := vuConfig()
+	cfg.Layout = "CentreOut"
+	assert.Equal(t, [][]int{{2}, {1, 3}, {0, 4}}, newVUMeter(cfg).steps(ledRange(0, 4)))
+	assert.Equal(t, [][]int{{1, 2}, {0, 3}}, newVUMeter(cfg).steps(ledRange(0, 3)))
+	cfg.Layout = "Mirror"
+	assert.Equal(t, [][]int{{0, 4}, {1, 3}, {2}}, newVUMeter(cfg).steps(ledRange(0, 4)))
+
+	leds := make([]Led, 5)
+	newVUMeter(cfg).draw(leds, ledRange(0, 4), 0.5, 0)
+	assert.Equal(t, []Led{{Green: 10}, {Green: 10}, {}, {Green: 10}, {Green: 10}}, leds)
+}
+
+func TestAudioLEDProducer_Mono(t *testing.T) {
+	cfg := vuConfig()
+	cfg.Mono = true
+	prod := NewAudioLEDProducer("audio", u.NewAtomicMapEvent[LedProducer](), 10, cfg, nil)
+
+	// -30 dB fills half of the bar across both ranges: LED 4 down to 0.
+	prod.updateLeds(math.Pow(10, -30.0/20)*0.5, math.Pow(10, -30.0/20)*1.5, time.Now())
+	for i := range 5 {
+		assert.Equal(t, Led{Green: 10}, prod.leds[i], "LED %d", i)
+		assert.Equal(t, Led{}, prod.leds[5+i], "LED %d", 5+i)
+	}
+	prod.updateLeds(1, 1, time.Now())
+	assert.Equal(t, Led{Red: 10}, prod.leds[9])
+}
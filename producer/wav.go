@@ -0,0 +1,93 @@
+package producer
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	wavFormatPCM        = 1
+	wavFormatFloat      = 3
+	wavFormatExtensible = 0xFFFE
+)
+
+// wavInfo describes the samples of a WAV file.
+type wavInfo struct {
+	format     string // "S16LE" or "F32LE"
+	channels   int
+	sampleRate int
+	dataOffset int64
+	dataSize   int64
+}
+
+// parseWAV reads the chunks of a RIFF WAVE file up to the data chunk.
+// Only 16 bit integer and 32 bit float samples are supported.
+func parseWAV(r io.ReadSeeker) (*wavInfo, error) {
+	if _, err := r.Seek(12, io.SeekStart); err != nil {
+		return nil, err
+	}
+	info := &wavInfo{}
+	haveFmt := false
+	offset := int64(12)
+	for {
+		var chunk struct {
+			ID   [4]byte
+			Size uint32
+		}
+		if err := binary.Read(r, binary.LittleEndian, &chunk); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil, fmt.Errorf("no data chunk in WAV file")
+			}
+			return nil, err
+		}
+		offset += 8
+		switch string(chunk.ID[:]) {
+		case "fmt ":
+			data := make([]byte, chunk.Size)
+			if _, err := io.ReadFull(r, data); err != nil || len(data) < 16 {
+				return nil, fmt.Errorf("invalid fmt chunk in WAV file")
+			}
+			format := binary.LittleEndian.Uint16(data[0:])
+			if format == wavFormatExtensible && len(data) >= 26 {
+				// the sub format GUID starts with the format code
+				format = binary.LittleEndian.Uint16(data[24:])
+			}
+			info.channels = int(binary.LittleEndian.Uint16(data[2:]))
+			info.sampleRate = int(binary.LittleEndian.Uint32(data[4:]))
+			bits := binary.LittleEndian.Uint16(data[14:])
+			switch {
+			case format == wavFormatPCM && bits == 16:
+				info.format = "S16LE"
+			case format == wavFormatFloat && bits == 32:
+				info.format = "F32LE"
+			default:
+				return nil, fmt.Errorf("unsupported WAV format %d with %d bits, only 16 bit PCM and 32 bit float are supported", format, bits)
+			}
+			if info.channels != 1 && info.channels != 2 {
+				return nil, fmt.Errorf("unsupported number of channels %d in WAV file", info.channels)
+			}
+			haveFmt = true
+		case "data":
+			if !haveFmt {
+				return nil, fmt.Errorf("data chunk before fmt chunk in WAV file")
+			}
+			info.dataOffset = offset
+			info.dataSize = int64(chunk.Size)
+			return info, nil
+		default:
+			if _, err := r.Seek(int64(chunk.Size), io.SeekCurrent); err != nil {
+				return nil, err
+			}
+		}
+		// chunks are padded to an even size
+		offset += int64(chunk.Size)
+		if chunk.Size%2 == 1 {
+			if _, err := r.Seek(1, io.SeekCurrent); err != nil {
+				return nil, err
+			}
+			offset++
+		}
+	}
+}
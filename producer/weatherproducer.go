@@ -0,0 +1,129 @@
+// This producer shows the weather read from a WeatherSource: the
+// temperature selects the base colour from a gradient, the wind speed
+// moves a brightness wave along the LEDs and rain lets random LEDs
+// sparkle in the rain colour.
+
+package producer
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+
+	c "lautenbacher.net/goleds/config"
+	u "lautenbacher.net/goleds/util"
+)
+
+const (
+	// weatherWaveLength is the length of the brightness wave in LEDs,
+	// weatherWaveDepth how much it darkens the LEDs at its minimum.
+	weatherWaveLength = 20
+	weatherWaveDepth  = 0.5
+	// weatherRainSparkle is the share of the LEDs sparkling in every
+	// step at a rain rate of MaxRain.
+	weatherRainSparkle = 0.5
+)
+
+type WeatherProducer struct {
+	*AbstractProducer
+	cfg        c.WeatherLEDConfig
+	source     WeatherSource
+	start      int
+	end        int
+	delay      time.Duration
+	colors     [][]float64
+	rain       Led
+	maxRain    float64
+	windFactor float64
+	phase      float64
+	rng        *rand.Rand
+	// The last reading is kept while the producer is stopped during
+	// sensor events, a source may take long to send the next one.
+	weather Weather
+	known   bool
+}
+
+func NewWeatherProducer(uid string, ledsChanged *u.AtomicMapEvent[LedProducer], ledsTotal int, cfg c.WeatherLEDConfig) *WeatherProducer {
+	inst := &WeatherProducer{
+		cfg:        cfg,
+		start:      cfg.StartLed,
+		end:        cfg.EndLed,
+		delay:      cfg.Delay,
+		colors:     cfg.TemperatureColors,
+		maxRain:    cfg.MaxRain,
+		windFactor: cfg.WindFactor,
+		rng:        rand.New(rand.NewPCG(uint64(time.Now().UnixNano()), 0)),
+	}
+	if len(cfg.RainLedRGB) > 0 {
+		inst.rain = Led{cfg.RainLedRGB[0], cfg.RainLedRGB[1], cfg.RainLedRGB[2]}
+	}
+	inst.AbstractProducer = NewAbstractProducer(uid, ledsChanged, inst.runner, ledsTotal)
+	return inst
+}
+
+// SetSource replaces the source selected in the config. It must be
+// called before Start().
+func (s *WeatherProducer) SetSource(source WeatherSource) {
+	s.source = source
+}
+
+// render draws one animation step for weather, the wave moves on by
+// the wind speed during elapsed.
+func (s *WeatherProducer) render(weather Weather, elapsed time.Duration) {
+	base := gradientColor(s.colors, weather.Temperature)
+	s.phase = math.Mod(s.phase+weather.WindSpeed*s.windFactor*elapsed.Seconds(), weatherWaveLength)
+	sparkle := 0.0
+	if s.maxRain > 0 {
+		sparkle = min(weather.Rain/s.maxRain, 1) * weatherRainSparkle
+	}
+
+	s.ledsMutex.Lock()
+	defer s.ledsMutex.Unlock()
+	for i := s.start; i <= s.end; i++ {
+		if sparkle > 0 && s.rng.Float64() < sparkle {
+			s.leds[i] = s.rain
+			continue
+		}
+		f := 1.0
+		if s.windFactor > 0 {
+			wave := math.Sin(2 * math.Pi * (float64(i) - s.phase) / weatherWaveLength)
+			f = 1 - weatherWaveDepth*(0.5+0.5*wave)
+		}
+		s.leds[i] = Led{base.Red * f, base.Green * f, base.Blue * f}
+	}
+}
+
+func (s *WeatherProducer) runner() {
+	source := s.source
+	if source == nil {
+		source = NewWeatherSource(s.cfg, s.clock)
+	}
+	updates := u.NewAtomicEvent[Weather]()
+	stop := make(chan struct{})
+	go source.Watch(stop, updates)
+	tick := s.clock.NewTicker(s.delay)
+	defer func() {
+		close(stop)
+		tick.Stop()
+		s.ledsMutex.Lock()
+		clear(s.leds) // Reset LEDs
+		s.ledsMutex.Unlock()
+		s.ledsChanged.Send(s.GetUID(), s)
+	}()
+
+	// Nothing is shown before the first reading arrives.
+	for {
+		select {
+		case <-updates.Channel():
+			s.weather = updates.Value()
+			s.known = true
+		case <-tick.C():
+			if s.known {
+				s.render(s.weather, s.delay)
+				s.ledsChanged.Send(s.GetUID(), s)
+			}
+		case <-s.stopchan:
+			return
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package producer
+
+import (
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	c "lautenbacher.net/goleds/config"
+	u "lautenbacher.net/goleds/util"
+)
+
+var weatherConfig = c.WeatherLEDConfig{
+	StartLed: 5,
+	EndLed:   44,
+	Delay:    50 * time.Millisecond,
+	TemperatureColors: [][]float64{
+		{-10, 0, 0, 200},
+		{10, 0, 200, 0},
+		{30, 200, 0, 0},
+	},
+	RainLedRGB: []float64{100, 100, 255},
+	MaxRain:    10,
+}
+
+func newTestWeatherProducer(cfg c.WeatherLEDConfig) *WeatherProducer {
+	p := NewWeatherProducer("weather", u.NewAtomicMapEvent[LedProducer](), 50, cfg)
+	p.rng = rand.New(rand.NewPCG(1, 2))
+	return p
+}
+
+func TestWeatherProducer_render(t *testing.T) {
+	p := newTestWeatherProducer(weatherConfig)
+	leds := make([]Led, 50)
+
+	// No wind and no rain: the whole range has the temperature colour.
+	p.render(Weather{Temperature: 10}, time.Second)
+	p.GetLeds(leds)
+	for i, led := range leds {
+		if i < 5 || i > 44 {
+			assert.Equal(t, Led{}, led, "LED %d", i)
+		} else {
+			assert.Equal(t, Led{0, 200, 0}, led, "LED %d", i)
+		}
+	}
+
+	// Heavy rain lets about half of the LEDs sparkle, light rain less.
+	countRain := func(rain float64) int {
+		count := 0
+		for range 100 {
+			p.render(Weather{Temperature: 10, Rain: rain}, time.Second)
+			p.GetLeds(leds)
+			for _, led := range leds {
+				if led == p.rain {
+					count++
+				}
+			}
+		}
+		return count
+	}
+	assert.InDelta(t, 2000, countRain(20), 200)
+	assert.InDelta(t, 400, countRain(2), 100)
+}
+
+func TestWeatherProducer_renderWind(t *testing.T) {
+	cfg := weatherConfig
+	cfg.WindFactor = 0.5
+	p := newTestWeatherProducer(cfg)
+	before := make([]Led, 50)
+	after := make([]Led, 50)
+
+	// Without wind the wave stands still.
+	p.render(Weather{Temperature: 10}, time.Second)
+	p.GetLeds(before)
+	p.render(Weather{Temperature: 10}, time.Second)
+	p.GetLeds(after)
+	assert.Equal(t, before, after)
+	assert.Less(t, before[5].Green, before[15].Green, "the wave darkens the LEDs")
+
+	// A wind speed of 4 moves the wave by 2 LEDs per second.
+	p.render(Weather{Temperature: 10, WindSpeed: 4}, time.Second)
+	p.GetLeds(after)
+	for i := 7; i <= 44; i++ {
+		assert.InDelta(t, before[i-2].Green, after[i].Green, 1e-9, "LED %d", i)
+	}
+}
+
+// fixedWeather is a WeatherSource sending a single reading.
+type fixedWeather Weather
+
+func (f fixedWeather) Watch(stop <-chan struct{}, updates *u.AtomicEvent[Weather]) {
+	updates.Send(Weather(f))
+	<-stop
+}
+
+// silentWeather is a WeatherSource that never sends a reading.
+type silentWeather struct{}
+
+func (silentWeather) Watch(stop <-chan struct{}, updates *u.AtomicEvent[Weather]) {
+	<-stop
+}
+
+func TestWeatherProducer_Runner(t *testing.T) {
+	clock := u.NewFakeClock(time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC))
+	p := newTestWeatherProducer(weatherConfig)
+	p.SetClock(clock)
+	p.SetSource(fixedWeather{Temperature: 30})
+	p.Start()
+	defer p.Exit()
+
+	leds := make([]Led, 50)
+	assert.Eventually(t, func() bool {
+		clock.BlockUntil(1)
+		clock.Advance(weatherConfig.Delay)
+		p.GetLeds(leds)
+		return leds[5] == Led{200, 0, 0}
+	}, time.Second, time.Millisecond)
+
+	// The LEDs are reset when the producer stops.
+	stopped, err := p.TryStop()
+	assert.True(t, stopped)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		p.GetLeds(leds)
+		return leds[5] == Led{}
+	}, time.Second, time.Millisecond)
+
+	// After a restart the last reading is shown without waiting for
+	// the source.
+	p.SetSource(silentWeather{})
+	p.Start()
+	clock.BlockUntil(1)
+	clock.Advance(weatherConfig.Delay)
+	assert.Eventually(t, func() bool {
+		p.GetLeds(leds)
+		return leds[5] == Led{200, 0, 0}
+	}, time.Second, time.Millisecond)
+}
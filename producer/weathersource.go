@@ -0,0 +1,50 @@
+package producer
+
+import (
+	c "lautenbacher.net/goleds/config"
+	u "lautenbacher.net/goleds/util"
+)
+
+// Weather are the conditions shown by the WeatherProducer. The units
+// are the ones of the source, e.g. °C, mm/h and km/h.
+type Weather struct {
+	Temperature float64
+	Rain        float64
+	WindSpeed   float64
+}
+
+// WeatherSource delivers the current weather.
+type WeatherSource = DataSource[Weather]
+
+// NewWeatherSource creates the source selected in cfg.
+func NewWeatherSource(cfg c.WeatherLEDConfig, clock u.Clock) WeatherSource {
+	parse := func(data []byte) (Weather, error) { return parseWeather(data, cfg.Fields) }
+	return NewDataSource("WeatherLED", cfg.DataSourceConfig, parse, clock)
+}
+
+// parseWeather extracts the values given by fields from a JSON document.
+func parseWeather(data []byte, fields c.WeatherFieldsConfig) (Weather, error) {
+	doc, err := parseJSON(data)
+	if err != nil {
+		return Weather{}, err
+	}
+	var weather Weather
+	for _, field := range []struct {
+		path  string
+		value *float64
+	}{
+		{fields.Temperature, &weather.Temperature},
+		{fields.Rain, &weather.Rain},
+		{fields.WindSpeed, &weather.WindSpeed},
+	} {
+		if field.path == "" {
+			continue
+		}
+		value, err := jsonNumber(doc, field.path)
+		if err != nil {
+			return Weather{}, err
+		}
+		*field.value = value
+	}
+	return weather, nil
+}
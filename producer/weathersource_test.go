@@ -0,0 +1,112 @@
+package producer
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	c "lautenbacher.net/goleds/config"
+	u "lautenbacher.net/goleds/util"
+)
+
+var openMeteoFields = c.WeatherFieldsConfig{
+	Temperature: "current.temperature_2m",
+	Rain:        "hourly.rain.1",
+	WindSpeed:   "current.wind_speed_10m",
+}
+
+const openMeteoDocument = `{
+	"current": {"temperature_2m": 21.5, "wind_speed_10m": 12},
+	"hourly": {"rain": [0, 0.4, 1.2]}
+}`
+
+func TestParseWeather(t *testing.T) {
+	weather, err := parseWeather([]byte(openMeteoDocument), openMeteoFields)
+	require.NoError(t, err)
+	assert.Equal(t, Weather{Temperature: 21.5, Rain: 0.4, WindSpeed: 12}, weather)
+
+	// An empty path leaves the value at 0.
+	weather, err = parseWeather([]byte(`{"temperature": -3}`), c.WeatherFieldsConfig{Temperature: "temperature"})
+	require.NoError(t, err)
+	assert.Equal(t, Weather{Temperature: -3}, weather)
+
+	tests := map[string]struct {
+		doc, path, expected string
+	}{
+		"invalid json":  {`{"temperature":`, "temperature", "invalid JSON document"},
+		"missing field": {openMeteoDocument, "current.humidity", `no field "humidity"`},
+		"bad index":     {openMeteoDocument, "hourly.rain.3", `invalid index "3"`},
+		"not a number":  {`{"temperature": "warm"}`, "temperature", "is not a number"},
+		"not an object": {openMeteoDocument, "current.temperature_2m.value", "is not an object or array"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseWeather([]byte(tc.doc), c.WeatherFieldsConfig{Temperature: tc.path})
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.expected)
+			}
+		})
+	}
+}
+
+// nextWeather waits for the next reading of a source.
+func nextWeather(t *testing.T, updates *u.AtomicEvent[Weather]) Weather {
+	t.Helper()
+	select {
+	case <-updates.Channel():
+		return updates.Value()
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for the weather")
+		return Weather{}
+	}
+}
+
+func TestWeatherSource_HTTP(t *testing.T) {
+	var temperature atomic.Int32
+	temperature.Store(20)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"temperature": %d}`, temperature.Load())
+	}))
+	defer server.Close()
+
+	clock := u.NewFakeClock(time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC))
+	cfg := c.WeatherLEDConfig{DataSourceConfig: c.DataSourceConfig{Source: "HTTP", URL: server.URL, PollInterval: 10 * time.Minute},
+		Fields: c.WeatherFieldsConfig{Temperature: "temperature"}}
+	source := NewWeatherSource(cfg, clock)
+	updates := u.NewAtomicEvent[Weather]()
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		source.Watch(stop, updates)
+		close(done)
+	}()
+
+	assert.Equal(t, 20.0, nextWeather(t, updates).Temperature)
+	temperature.Store(25)
+	clock.BlockUntil(1)
+	clock.Advance(10 * time.Minute)
+	assert.Equal(t, 25.0, nextWeather(t, updates).Temperature)
+
+	clock.BlockUntil(1)
+	close(stop)
+	<-done
+}
+
+func TestWeatherSource_File(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "weather.json")
+	require.NoError(t, os.WriteFile(file, []byte(openMeteoDocument), 0o644))
+	cfg := c.WeatherLEDConfig{DataSourceConfig: c.DataSourceConfig{Source: "File", File: file, PollInterval: time.Minute}, Fields: openMeteoFields}
+	source := NewWeatherSource(cfg, u.NewFakeClock(time.Now()))
+	updates := u.NewAtomicEvent[Weather]()
+	stop := make(chan struct{})
+	defer close(stop)
+	go source.Watch(stop, updates)
+	assert.Equal(t, Weather{Temperature: 21.5, Rain: 0.4, WindSpeed: 12}, nextWeather(t, updates))
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	c "lautenbacher.net/goleds/config"
+	pl "lautenbacher.net/goleds/platform"
+	p "lautenbacher.net/goleds/producer"
+	u "lautenbacher.net/goleds/util"
+)
+
+// renderProducers maps the names accepted by "goleds render -producers"
+// to the Enabled flag of the corresponding config section. The
+// AudioLED, WeatherLED and GaugeLED producers need live input and
+// can't be rendered.
+var renderProducers = map[string]func(conf *c.Config) *bool{
+	"sensor":    func(conf *c.Config) *bool { return &conf.SensorLED.Enabled },
+	"night":     func(conf *c.Config) *bool { return &conf.NightLED.Enabled },
+	"clock":     func(conf *c.Config) *bool { return &conf.ClockLED.Enabled },
+	"multiblob": func(conf *c.Config) *bool { return &conf.MultiBlobLED.Enabled },
+	"cylon":     func(conf *c.Config) *bool { return &conf.CylonLED.Enabled },
+	"fire":      func(conf *c.Config) *bool { return &conf.FireLED.Enabled },
+	"candle":    func(conf *c.Config) *bool { return &conf.CandleLED.Enabled },
+	"twinkle":   func(conf *c.Config) *bool { return &conf.TwinkleLED.Enabled },
+	"noise":     func(conf *c.Config) *bool { return &conf.NoiseLED.Enabled },
+	"breathing": func(conf *c.Config) *bool { return &conf.BreathingLED.Enabled },
+	"image":     func(conf *c.Config) *bool { return &conf.ImageLED.Enabled },
+	"message":   func(conf *c.Config) *bool { return &conf.MessageLED.Enabled },
+}
+
+// renderTriggerValue is the sensor value of triggers given on the
+// command line without an explicit value (the maximum of the ADC).
+const renderTriggerValue = 1023
+
+// renderSettleDelay is the (real) time to wait between two checks
+// whether the producers have finished reacting to a clock advance.
+const renderSettleDelay = 200 * time.Microsecond
+
+// renderOptions holds the settings of the render subcommand.
+type renderOptions struct {
+	configFile string
+	producers  []string
+	duration   time.Duration
+	interval   time.Duration
+	start      time.Time
+	triggers   []u.TriggerRecord
+}
+
+// runRender implements "goleds render": it runs the selected producers
+// on a virtual clock without hardware or terminal and writes the
+// resulting timeline as an image (one row per frame) or animated GIF.
+func runRender(args []string, defaultConfig string) error {
+	fs := flag.NewFlagSet("render", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: goleds render [options]\n\n"+
+			"Runs producers on a virtual clock and writes the LED timeline as an image.\n\n")
+		fs.PrintDefaults()
+	}
+	cfile := fs.String("config", defaultConfig, "Config file to use")
+	prods := fs.String("producers", "", "Comma separated producers to render (sensor, night, clock, multiblob, cylon,\n"+
+		"fire, candle, twinkle, noise, breathing, image, message);\n"+
+		"default: all enabled in the config file")
+	duration := fs.Duration("duration", 10*time.Second, "Length of the rendered timeline")
+	interval := fs.Duration("interval", 20*time.Millisecond, "Time between two frames")
+	startp := fs.String("start", "", "Start time of the virtual clock (RFC 3339, default: now)")
+	triggerfile := fs.String("triggers", "", "File with sensor events recorded with -record")
+	out := fs.String("out", "timeline.png", "Output file, a .gif extension writes an animated GIF instead of a PNG strip")
+	pixel := fs.Int("pixel", 4, "Size in pixels of a single LED")
+	gain := fs.Float64("gain", 1, "Factor applied to the LED values before clamping to 255")
+	var triggers []u.TriggerRecord
+	fs.Func("trigger", "Sensor event as `ID@offset[=value]`, e.g. S0@1.5s (may be repeated)", func(s string) error {
+		rec, err := parseRenderTrigger(s)
+		if err != nil {
+			return err
+		}
+		triggers = append(triggers, rec)
+		return nil
+	})
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// Only warnings and errors, the producers are quite chatty otherwise.
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})))
+
+	opts := renderOptions{
+		configFile: *cfile,
+		duration:   *duration,
+		interval:   *interval,
+		start:      time.Now(),
+		triggers:   triggers,
+	}
+	if *prods != "" {
+		opts.producers = strings.Split(*prods, ",")
+	}
+	if *startp != "" {
+		start, err := time.Parse(time.RFC3339, *startp)
+		if err != nil {
+			return fmt.Errorf("invalid start time: %w", err)
+		}
+		opts.start = start
+	}
+	if *triggerfile != "" {
+		file, err := os.Open(*triggerfile)
+		if err != nil {
+			return fmt.Errorf("failed to open trigger file: %w", err)
+		}
+		records, err := u.ReadTriggerLog(file)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read trigger file: %w", err)
+		}
+		opts.triggers = append(opts.triggers, records...)
+	}
+
+	frames, err := render(opts)
+	if err != nil {
+		return err
+	}
+
+	outfile, err := os.Create(*out)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	export := pl.ExportOptions{PixelSize: *pixel, Gain: *gain}
+	if strings.EqualFold(filepath.Ext(*out), ".gif") {
+		err = pl.WriteGIF(outfile, frames, opts.interval, export)
+	} else {
+		err = pl.WritePNGStrip(outfile, frames, export)
+	}
+	if cerr := outfile.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", *out, err)
+	}
+	fmt.Printf("Wrote %d frames to %s\n", len(frames), *out)
+	return nil
+}
+
+// parseRenderTrigger parses a trigger given as ID@offset[=value].
+func parseRenderTrigger(s string) (u.TriggerRecord, error) {
+	id, rest, found := strings.Cut(s, "@")
+	if !found || id == "" {
+		return u.TriggerRecord{}, fmt.Errorf("trigger %q: expected ID@offset[=value]", s)
+	}
+	offsetStr, valueStr, hasValue := strings.Cut(rest, "=")
+	offset, err := time.ParseDuration(offsetStr)
+	if err != nil || offset < 0 {
+		return u.TriggerRecord{}, fmt.Errorf("trigger %q: invalid offset %q", s, offsetStr)
+	}
+	value := renderTriggerValue
+	if hasValue {
+		if value, err = strconv.Atoi(valueStr); err != nil {
+			return u.TriggerRecord{}, fmt.Errorf("trigger %q: invalid value %q", s, valueStr)
+		}
+	}
+	return u.TriggerRecord{Offset: offset, ID: id, Value: value}, nil
+}
+
+// render runs the producers selected in opts against a FakeClock and
+// a HeadlessPlatform and returns one frame per interval.
+func render(opts renderOptions) ([]pl.Frame, error) {
+	if opts.interval <= 0 {
+		return nil, fmt.Errorf("interval must be positive, got %v", opts.interval)
+	}
+	// ReadConfig would create a missing config file from the defaults.
+	if _, err := os.Stat(opts.configFile); err != nil {
+		return nil, fmt.Errorf("config file: %w", err)
+	}
+	conf, err := c.ReadConfig(opts.configFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read or validate config: %w", err)
+	}
+	if err := selectRenderProducers(conf, opts.producers); err != nil {
+		return nil, err
+	}
+	for name, zone := range conf.Zones {
+		if err := selectRenderProducers(&zone.Config, opts.producers); err != nil {
+			return nil, err
+		}
+		conf.Zones[name] = zone
+	}
+
+	triggers := make([]u.TriggerRecord, len(opts.triggers))
+	copy(triggers, opts.triggers)
+	sort.SliceStable(triggers, func(i, j int) bool { return triggers[i].Offset < triggers[j].Offset })
+
+	clock := u.NewFakeClock(opts.start)
+	headless := pl.NewHeadlessPlatform(conf)
+	headless.SetClock(clock)
+	replay := pl.NewReplayPlatform(headless, triggers, 1)
+	replay.SetClock(clock)
+
+	app := NewApp(make(chan os.Signal, 1))
+	app.clock = clock
+	app.platform = replay
+	app.reset()
+	if err := app.start(conf); err != nil {
+		return nil, err
+	}
+	defer app.shutdown()
+	replay.Play()
+
+	ledsTotal := headless.GetLedsTotal()
+	current := make([]p.Led, ledsTotal)
+	frames := make([]pl.Frame, 0, int(opts.duration/opts.interval)+1)
+	for elapsed := time.Duration(0); elapsed <= opts.duration; elapsed += opts.interval {
+		if elapsed > 0 {
+			clock.Advance(opts.interval)
+		}
+		settle(clock, headless)
+		if captured := headless.Frames(); len(captured) > 0 {
+			current = captured[len(captured)-1].Leds
+			headless.ClearFrames()
+		}
+		frame := pl.Frame{Time: clock.Now(), Leds: make([]p.Led, ledsTotal)}
+		copy(frame.Leds, current)
+		frames = append(frames, frame)
+	}
+	return frames, nil
+}
+
+// selectRenderProducers enables only the producers of conf named in
+// producers, all if producers is empty, and disables the producers
+// and beat features needing live input.
+func selectRenderProducers(conf *c.Config, producers []string) error {
+	if len(producers) > 0 {
+		for _, enabled := range renderProducers {
+			*enabled(conf) = false
+		}
+		for _, name := range producers {
+			enabled, ok := renderProducers[strings.TrimSpace(name)]
+			if !ok {
+				return fmt.Errorf("unknown producer %q", name)
+			}
+			*enabled(conf) = true
+		}
+	}
+	conf.AudioLED.Enabled = false
+	// Without the beats of the audio input, no audio capture is opened.
+	conf.AudioLED.Beat.Enabled = false
+	conf.CylonLED.BeatSync = false
+	conf.MultiBlobLED.BeatColors = false
+	conf.WeatherLED.Enabled = false
+	conf.GaugeLED.Enabled = false
+	return nil
+}
+
+// settle waits (in real time) until the go-routines have reacted to the
+// last clock advance, i.e. until neither the number of pending timers
+// nor the number of captured frames changes for a few checks.
+func settle(clock *u.FakeClock, headless *pl.HeadlessPlatform) {
+	waiters, frames := -1, -1
+	for stable, checks := 0, 0; stable < 3 && checks < 500; checks++ {
+		time.Sleep(renderSettleDelay)
+		w, f := clock.Waiters(), len(headless.Frames())
+		if w == waiters && f == frames {
+			stable++
+		} else {
+			stable = 0
+		}
+		waiters, frames = w, f
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	u "lautenbacher.net/goleds/util"
+)
+
+const renderTestConfig = `
+Hardware:
+  Display:
+    ForceUpdateDelay: 1s
+    LedsTotal: 20
+  Sensors:
+    SensorCfg:
+      S0: {LedIndex: 0, TriggerValue: 100, SpiMultiplex: P0}
+  SpiMultiplexGPIO:
+    P0: {Low: [], High: []}
+Logging:
+  TUI: {Level: WARN, Format: text, File: ""}
+  HW: {Level: WARN, Format: text, File: ""}
+SensorLED:
+  Enabled: true
+  RunUpDelay: 10ms
+  RunDownDelay: 10ms
+  HoldTime: 500ms
+  LedRGB: [100, 100, 100]
+  LatchLedRGB: [0, 0, 0]
+CylonLED:
+  Enabled: true
+  Duration: 1s
+  Delay: 20ms
+  Step: 1
+  Width: 2
+  LedRGB: [255, 0, 0]
+NightLED: {Enabled: false, LedRGB: [[0, 0, 0]]}
+ClockLED: {Enabled: false, StartLedHour: 0, EndLedHour: 1, StartLedMinute: 2, EndLedMinute: 3, LedHour: [0, 0, 0], LedMinute: [0, 0, 0]}
+AudioLED: {Enabled: false, StartLedLeft: 0, EndLedLeft: 1, StartLedRight: 2, EndLedRight: 3, LedGreen: [0, 0, 0], LedYellow: [0, 0, 0], LedRed: [0, 0, 0], SampleRate: 44100, FramesPerBuffer: 1024, UpdateFreq: 10ms, MinDB: -60, MaxDB: -10}
+MultiBlobLED: {Enabled: false, Duration: 1s, Delay: 10ms, BlobCfg: []}
+`
+
+func TestRender_SensorAndCylonTimeline(t *testing.T) {
+	cfile := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(cfile, []byte(renderTestConfig), 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	frames, err := render(renderOptions{
+		configFile: cfile,
+		duration:   3 * time.Second,
+		interval:   50 * time.Millisecond,
+		start:      time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC),
+		triggers:   []u.TriggerRecord{{Offset: 100 * time.Millisecond, ID: "S0", Value: 300}},
+	})
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	if len(frames) != 61 {
+		t.Fatalf("Expected 61 frames, got %d", len(frames))
+	}
+	if d := frames[1].Time.Sub(frames[0].Time); d != 50*time.Millisecond {
+		t.Errorf("Expected frames 50ms apart, got %v", d)
+	}
+
+	// Dark before the trigger, fully lit during HoldTime, afterwards
+	// the red Cylon eye, dark again once its Duration has passed.
+	if n := litCount(frames[0].Leds); n != 0 {
+		t.Errorf("Expected a dark strip before the trigger, got %d lit LEDs", n)
+	}
+	if n := litCount(frames[10].Leds); n != 20 {
+		t.Errorf("Expected a fully lit strip during HoldTime, got %d lit LEDs", n)
+	}
+	cylonSeen := false
+	for _, frame := range frames[20:40] {
+		for _, led := range frame.Leds {
+			if led.Red > 0 && led.Green == 0 {
+				cylonSeen = true
+			}
+		}
+	}
+	if !cylonSeen {
+		t.Error("Expected the Cylon producer to run after the sensor run-down")
+	}
+	if n := litCount(frames[len(frames)-1].Leds); n != 0 {
+		t.Errorf("Expected a dark strip at the end, got %d lit LEDs", n)
+	}
+}
+
+func TestRender_BeatSyncWithoutAudio(t *testing.T) {
+	// Cylon with BeatSync would open the audio input, which an offline
+	// render must not do.
+	beat := strings.Replace(renderTestConfig, "MinDB: -60, MaxDB: -10}", "MinDB: -60, MaxDB: -10, Beat: {Enabled: true, Sensitivity: 1.4, MinInterval: 250ms}}", 1)
+	beat = strings.Replace(beat, "  LedRGB: [255, 0, 0]\n", "  LedRGB: [255, 0, 0]\n  BeatSync: true\n", 1)
+	cfile := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(cfile, []byte(beat), 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	frames, err := render(renderOptions{
+		configFile: cfile,
+		duration:   2 * time.Second,
+		interval:   50 * time.Millisecond,
+		start:      time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC),
+		triggers:   []u.TriggerRecord{{Offset: 100 * time.Millisecond, ID: "S0", Value: 300}},
+	})
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	cylonSeen := false
+	for _, frame := range frames[20:40] {
+		for _, led := range frame.Leds {
+			if led.Red > 0 && led.Green == 0 {
+				cylonSeen = true
+			}
+		}
+	}
+	if !cylonSeen {
+		t.Error("Expected the Cylon producer to run without the beats")
+	}
+}
+
+func TestRender_UnknownProducer(t *testing.T) {
+	cfile := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(cfile, []byte(renderTestConfig), 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	_, err := render(renderOptions{configFile: cfile, producers: []string{"sparkle"}, duration: time.Second, interval: 10 * time.Millisecond})
+	if err == nil {
+		t.Error("Expected an error for an unknown producer")
+	}
+}
+
+func TestParseRenderTrigger(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected u.TriggerRecord
+		wantErr  bool
+	}{
+		{"S0@1.5s", u.TriggerRecord{Offset: 1500 * time.Millisecond, ID: "S0", Value: renderTriggerValue}, false},
+		{"S1@0s=300", u.TriggerRecord{Offset: 0, ID: "S1", Value: 300}, false},
+		{"S0", u.TriggerRecord{}, true},
+		{"@1s", u.TriggerRecord{}, true},
+		{"S0@soon", u.TriggerRecord{}, true},
+		{"S0@-1s", u.TriggerRecord{}, true},
+		{"S0@1s=high", u.TriggerRecord{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseRenderTrigger(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: unexpected error state: %v", tt.in, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("%q: expected %+v, got %+v", tt.in, tt.expected, got)
+		}
+	}
+}
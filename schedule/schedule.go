@@ -0,0 +1,148 @@
+// Package schedule evaluates the time based rules of the Schedule
+// config section. For a given point in time it tells for every
+// producer section whether it is disabled and which brightness and
+// colour it should use.
+package schedule
+
+import (
+	"fmt"
+	"time"
+
+	c "lautenbacher.net/goleds/config"
+	u "lautenbacher.net/goleds/util"
+)
+
+// State is the scheduled state of a producer section.
+type State struct {
+	Disabled   bool
+	Brightness float64   // factor between 0 and 1
+	LedRGB     []float64 // nil for the configured colour
+	Message    string    // empty for the configured message
+}
+
+// DefaultState is the state of a section no rule applies to.
+var DefaultState = State{Brightness: 1}
+
+type rule struct {
+	cfg      c.ScheduleRule
+	days     u.DaySet
+	cron     *u.CronSpec
+	from, to *u.TimeAnchor
+}
+
+type Schedule struct {
+	rules     []rule
+	holidays  map[string]bool // keys are "2006-01-02" or "01-02"
+	latitude  float64
+	longitude float64
+}
+
+// New parses the rules of cfg. The config is expected to be validated
+// already, errors are only returned for robustness.
+func New(cfg c.ScheduleConfig) (*Schedule, error) {
+	s := &Schedule{
+		holidays:  make(map[string]bool, len(cfg.Holidays)),
+		latitude:  cfg.Latitude,
+		longitude: cfg.Longitude,
+	}
+	for _, holiday := range cfg.Holidays {
+		if _, err := c.ParseHoliday(holiday); err != nil {
+			return nil, err
+		}
+		s.holidays[holiday] = true
+	}
+	for i, cfgRule := range cfg.Rules {
+		r := rule{cfg: cfgRule}
+		var err error
+		if r.days, err = u.ParseDaySet(cfgRule.Days); err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i, err)
+		}
+		if cfgRule.Cron != "" {
+			cron, err := u.ParseCron(cfgRule.Cron)
+			if err != nil {
+				return nil, fmt.Errorf("rule %d: %w", i, err)
+			}
+			r.cron = &cron
+		}
+		if cfgRule.From != "" {
+			from, err := u.ParseTimeAnchor(cfgRule.From)
+			if err != nil {
+				return nil, fmt.Errorf("rule %d: %w", i, err)
+			}
+			// Without To the rule is active until the end of the day.
+			to, _ := u.ParseTimeAnchor("24:00")
+			if cfgRule.To != "" {
+				if to, err = u.ParseTimeAnchor(cfgRule.To); err != nil {
+					return nil, fmt.Errorf("rule %d: %w", i, err)
+				}
+			}
+			r.from, r.to = &from, &to
+		}
+		s.rules = append(s.rules, r)
+	}
+	return s, nil
+}
+
+// Evaluate returns the state of every producer section at now. Rules
+// are applied in order, so later rules override earlier ones.
+func (s *Schedule) Evaluate(now time.Time) map[string]State {
+	states := make(map[string]State, len(c.ProducerSections))
+	for _, section := range c.ProducerSections {
+		states[section] = DefaultState
+	}
+	for _, r := range s.rules {
+		if !s.active(r, now) {
+			continue
+		}
+		for _, section := range r.cfg.Producers {
+			state := states[section]
+			if r.cfg.Disable {
+				state.Disabled = true
+			}
+			if r.cfg.Brightness > 0 {
+				state.Brightness = r.cfg.Brightness
+			}
+			if len(r.cfg.LedRGB) > 0 {
+				state.LedRGB = r.cfg.LedRGB
+			}
+			if r.cfg.Message != "" {
+				state.Message = r.cfg.Message
+			}
+			states[section] = state
+		}
+	}
+	return states
+}
+
+func (s *Schedule) isHoliday(date time.Time) bool {
+	return s.holidays[date.Format("2006-01-02")] || s.holidays[date.Format("01-02")]
+}
+
+func (s *Schedule) dayMatches(r rule, date time.Time) bool {
+	return r.days.Matches(date, s.isHoliday(date))
+}
+
+// active reports whether rule r applies at now. A From/To interval
+// that ends before it starts spans midnight; its part after midnight
+// belongs to the previous day when matching Days.
+func (s *Schedule) active(r rule, now time.Time) bool {
+	switch {
+	case r.cron != nil:
+		return r.cron.Matches(now) && s.dayMatches(r, now)
+	case r.from != nil:
+		from, okFrom := r.from.On(now, s.latitude, s.longitude)
+		to, okTo := r.to.On(now, s.latitude, s.longitude)
+		if !okFrom || !okTo {
+			return false
+		}
+		if from.Before(to) {
+			return !now.Before(from) && now.Before(to) && s.dayMatches(r, now)
+		}
+		if !now.Before(from) {
+			return s.dayMatches(r, now)
+		}
+		return now.Before(to) && s.dayMatches(r, now.AddDate(0, 0, -1))
+	default:
+		return s.dayMatches(r, now)
+	}
+}
@@ -0,0 +1,108 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	c "lautenbacher.net/goleds/config"
+)
+
+func newSchedule(t *testing.T, rules ...c.ScheduleRule) *Schedule {
+	t.Helper()
+	cfg := c.ScheduleConfig{
+		Enabled:   true,
+		Latitude:  49.014,
+		Longitude: 8.4043,
+		Holidays:  []string{"12-25", "2024-05-01"},
+		Rules:     rules,
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Invalid schedule: %v", err)
+	}
+	s, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return s
+}
+
+// at returns a time in June 2024; the 21st is a Friday.
+func at(day, hour, minute int) time.Time {
+	return time.Date(2024, 6, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestSchedule_DefaultState(t *testing.T) {
+	states := newSchedule(t).Evaluate(at(21, 12, 0))
+	for _, section := range c.ProducerSections {
+		assert.Equal(t, DefaultState, states[section], section)
+	}
+}
+
+func TestSchedule_FromToAcrossMidnight(t *testing.T) {
+	s := newSchedule(t, c.ScheduleRule{Producers: []string{"AudioLED"}, From: "23:00", To: "06:00", Disable: true})
+	tests := []struct {
+		time     time.Time
+		disabled bool
+	}{
+		{at(21, 22, 59), false},
+		{at(21, 23, 0), true},
+		{at(22, 3, 0), true},
+		{at(22, 5, 59), true},
+		{at(22, 6, 0), false},
+	}
+	for _, tc := range tests {
+		states := s.Evaluate(tc.time)
+		assert.Equal(t, tc.disabled, states["AudioLED"].Disabled, "at %v", tc.time)
+		assert.False(t, states["SensorLED"].Disabled)
+	}
+}
+
+func TestSchedule_DaysAfterMidnightBelongToPreviousDay(t *testing.T) {
+	// Friday night into Saturday morning is a "fri" night.
+	s := newSchedule(t, c.ScheduleRule{Producers: []string{"ClockLED"}, Days: "fri", From: "22:00", To: "02:00", Disable: true})
+	assert.True(t, s.Evaluate(at(21, 23, 0))["ClockLED"].Disabled)
+	assert.True(t, s.Evaluate(at(22, 1, 0))["ClockLED"].Disabled)
+	assert.False(t, s.Evaluate(at(21, 1, 0))["ClockLED"].Disabled)
+	assert.False(t, s.Evaluate(at(22, 23, 0))["ClockLED"].Disabled)
+}
+
+func TestSchedule_LaterRulesOverride(t *testing.T) {
+	s := newSchedule(t,
+		c.ScheduleRule{Producers: []string{"SensorLED", "ClockLED"}, Cron: "* 0-5 * * *", Brightness: 0.5, LedRGB: []float64{10, 10, 10}},
+		c.ScheduleRule{Producers: []string{"SensorLED"}, Days: "weekend", Brightness: 0.2},
+	)
+	friday := s.Evaluate(at(21, 2, 0))
+	assert.Equal(t, State{Brightness: 0.5, LedRGB: []float64{10, 10, 10}}, friday["SensorLED"])
+	assert.Equal(t, 0.5, friday["ClockLED"].Brightness)
+
+	saturday := s.Evaluate(at(22, 2, 0))
+	assert.Equal(t, State{Brightness: 0.2, LedRGB: []float64{10, 10, 10}}, saturday["SensorLED"])
+
+	assert.Equal(t, DefaultState, s.Evaluate(at(21, 6, 0))["SensorLED"])
+	assert.Equal(t, 0.2, s.Evaluate(at(22, 6, 0))["SensorLED"].Brightness)
+}
+
+func TestSchedule_Message(t *testing.T) {
+	s := newSchedule(t,
+		c.ScheduleRule{Producers: []string{"MessageLED"}, From: "07:00", To: "09:00", Message: "GOOD MORNING"},
+		c.ScheduleRule{Producers: []string{"MessageLED"}, Days: "weekend", Brightness: 0.5},
+	)
+	assert.Equal(t, State{Brightness: 1, Message: "GOOD MORNING"}, s.Evaluate(at(21, 8, 0))["MessageLED"])
+	assert.Equal(t, State{Brightness: 0.5, Message: "GOOD MORNING"}, s.Evaluate(at(22, 8, 0))["MessageLED"])
+	assert.Equal(t, DefaultState, s.Evaluate(at(21, 10, 0))["MessageLED"])
+}
+
+func TestSchedule_Holidays(t *testing.T) {
+	s := newSchedule(t, c.ScheduleRule{Producers: []string{"CylonLED"}, Days: "holiday", Disable: true})
+	assert.True(t, s.Evaluate(time.Date(2030, 12, 25, 12, 0, 0, 0, time.UTC))["CylonLED"].Disabled)
+	assert.True(t, s.Evaluate(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))["CylonLED"].Disabled)
+	assert.False(t, s.Evaluate(time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC))["CylonLED"].Disabled)
+}
+
+func TestSchedule_Sunset(t *testing.T) {
+	// Sunset in Karlsruhe on June 21st is at about 19:35 UTC.
+	s := newSchedule(t, c.ScheduleRule{Producers: []string{"NightLED"}, From: "sunset+1h", To: "24:00", Brightness: 0.3})
+	assert.Equal(t, 1.0, s.Evaluate(at(21, 20, 0))["NightLED"].Brightness)
+	assert.Equal(t, 0.3, s.Evaluate(at(21, 21, 0))["NightLED"].Brightness)
+}
@@ -0,0 +1,289 @@
+package util
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock abstracts the passage of time so that time dependent code
+// (producers, platforms) can be driven by a virtual clock in tests.
+// RealClock is backed by the time package, FakeClock only advances
+// when told to.
+//
+// Types that use a Clock default to RealClock and offer a SetClock
+// method. The clock is read by the goroutines started in Start(), so
+// SetClock must be called before Start() and never afterwards.
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	After(d time.Duration) <-chan time.Time
+	Sleep(d time.Duration)
+	NewTimer(d time.Duration) Timer
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer is the Clock equivalent of time.Timer.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// Ticker is the Clock equivalent of time.Ticker.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+	Reset(d time.Duration)
+}
+
+// RealClock implements Clock using the time package.
+type RealClock struct{}
+
+func (RealClock) Now() time.Time                         { return time.Now() }
+func (RealClock) Since(t time.Time) time.Duration        { return time.Since(t) }
+func (RealClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (RealClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+
+func (RealClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+func (RealClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+type realTimer struct{ *time.Timer }
+
+func (t realTimer) C() <-chan time.Time { return t.Timer.C }
+
+type realTicker struct{ *time.Ticker }
+
+func (t realTicker) C() <-chan time.Time { return t.Ticker.C }
+
+// fakeTickTimeout is the (real) time a FakeClock waits for a ticker's
+// receiver to consume the previous tick before the new one is dropped,
+// just like a real ticker drops ticks for slow receivers.
+const fakeTickTimeout = 100 * time.Millisecond
+
+// FakeClock is a manually advanced Clock for tests and simulations.
+// Timers and tickers created from it fire only during Advance, in
+// chronological order, and Now() reports the time of the event being
+// fired. This allows e.g. a whole night to be simulated in
+// milliseconds.
+//
+// Receivers run in their own goroutines, so after Advance returns they
+// may still be busy with the last events. BlockUntil can be used to
+// wait until the code under test has registered its next timer.
+type FakeClock struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []*fakeWaiter
+}
+
+type fakeWaiter struct {
+	clock  *FakeClock
+	c      chan time.Time
+	when   time.Time
+	period time.Duration // zero for one-shot timers
+	active bool
+}
+
+// NewFakeClock creates a FakeClock starting at the given time.
+func NewFakeClock(start time.Time) *FakeClock {
+	c := &FakeClock{now: start}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	return c.NewTimer(d).C()
+}
+
+// Sleep blocks until the clock has been advanced by at least d.
+func (c *FakeClock) Sleep(d time.Duration) {
+	<-c.After(d)
+}
+
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+	w := &fakeWaiter{clock: c, c: make(chan time.Time, 1)}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if d <= 0 {
+		w.c <- c.now
+		return w
+	}
+	c.addLocked(w, c.now.Add(d))
+	return w
+}
+
+func (c *FakeClock) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for FakeClock.NewTicker")
+	}
+	w := &fakeWaiter{clock: c, c: make(chan time.Time, 1), period: d}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.addLocked(w, c.now.Add(d))
+	return fakeTicker{w}
+}
+
+// Advance moves the clock forward by d and fires all timers and
+// tickers that become due on the way.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	target := c.now.Add(d)
+	for {
+		next := c.nextLocked(target)
+		if next == nil {
+			break
+		}
+		c.now = next.when
+		if next.period > 0 {
+			next.when = next.when.Add(next.period)
+		} else {
+			c.removeLocked(next)
+		}
+		now := c.now
+		c.mu.Unlock()
+		next.fire(now)
+		// Give the receiver a chance to run before the next event.
+		runtime.Gosched()
+		c.mu.Lock()
+	}
+	c.now = target
+	c.mu.Unlock()
+}
+
+// AdvanceTo moves the clock forward to t (if t is in the future).
+func (c *FakeClock) AdvanceTo(t time.Time) {
+	if d := t.Sub(c.Now()); d > 0 {
+		c.Advance(d)
+	}
+}
+
+// BlockUntil blocks until at least n timers or tickers are waiting
+// to fire.
+func (c *FakeClock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.waiters) < n {
+		c.cond.Wait()
+	}
+}
+
+// Waiters returns the number of timers and tickers waiting to fire.
+func (c *FakeClock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+func (c *FakeClock) addLocked(w *fakeWaiter, when time.Time) {
+	w.when = when
+	if !w.active {
+		w.active = true
+		c.waiters = append(c.waiters, w)
+		c.cond.Broadcast()
+	}
+}
+
+func (c *FakeClock) removeLocked(w *fakeWaiter) bool {
+	if !w.active {
+		return false
+	}
+	w.active = false
+	for i, other := range c.waiters {
+		if other == w {
+			c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// nextLocked returns the earliest waiter due at or before target.
+func (c *FakeClock) nextLocked(target time.Time) *fakeWaiter {
+	if len(c.waiters) == 0 {
+		return nil
+	}
+	sort.SliceStable(c.waiters, func(i, j int) bool { return c.waiters[i].when.Before(c.waiters[j].when) })
+	if c.waiters[0].when.After(target) {
+		return nil
+	}
+	return c.waiters[0]
+}
+
+// fire delivers the tick. Timers have a buffered channel and never
+// block. For tickers we wait a little for a slow receiver, so that
+// consecutive ticks of a fast Advance are not lost.
+func (w *fakeWaiter) fire(now time.Time) {
+	select {
+	case w.c <- now:
+		return
+	default:
+	}
+	if w.period == 0 {
+		return
+	}
+	timeout := time.NewTimer(fakeTickTimeout)
+	defer timeout.Stop()
+	select {
+	case w.c <- now:
+	case <-timeout.C:
+	}
+}
+
+func (w *fakeWaiter) C() <-chan time.Time {
+	return w.c
+}
+
+// Stop implements Timer.Stop, see fakeTicker for Ticker.Stop. Like
+// timers since Go 1.23 a value that fired but was not yet received is
+// discarded.
+func (w *fakeWaiter) Stop() bool {
+	w.clock.mu.Lock()
+	defer w.clock.mu.Unlock()
+	w.drain()
+	return w.clock.removeLocked(w)
+}
+
+// Reset implements Timer.Reset. For tickers it also changes the period.
+// A stale value is discarded, as with Stop.
+func (w *fakeWaiter) Reset(d time.Duration) bool {
+	w.clock.mu.Lock()
+	defer w.clock.mu.Unlock()
+	w.drain()
+	wasActive := w.active
+	if w.period > 0 {
+		w.period = d
+	}
+	w.clock.addLocked(w, w.clock.now.Add(d))
+	return wasActive
+}
+
+func (w *fakeWaiter) drain() {
+	select {
+	case <-w.c:
+	default:
+	}
+}
+
+// fakeTicker adapts a fakeWaiter to the Ticker interface, whose Stop
+// and Reset methods have no return values.
+type fakeTicker struct{ *fakeWaiter }
+
+func (t fakeTicker) Stop()                 { t.fakeWaiter.Stop() }
+func (t fakeTicker) Reset(d time.Duration) { t.fakeWaiter.Reset(d) }
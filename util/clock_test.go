@@ -0,0 +1,146 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var clockStart = time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC)
+
+func TestFakeClock_NowAndAdvance(t *testing.T) {
+	c := NewFakeClock(clockStart)
+	assert.Equal(t, clockStart, c.Now())
+
+	c.Advance(90 * time.Minute)
+	assert.Equal(t, clockStart.Add(90*time.Minute), c.Now())
+	assert.Equal(t, 90*time.Minute, c.Since(clockStart))
+
+	c.AdvanceTo(clockStart) // in the past, must be ignored
+	assert.Equal(t, clockStart.Add(90*time.Minute), c.Now())
+}
+
+func TestFakeClock_Timer(t *testing.T) {
+	c := NewFakeClock(clockStart)
+	timer := c.NewTimer(10 * time.Second)
+	assert.Equal(t, 1, c.Waiters())
+
+	c.Advance(9 * time.Second)
+	select {
+	case <-timer.C():
+		t.Fatal("Timer fired too early")
+	default:
+	}
+
+	c.Advance(time.Second)
+	select {
+	case fired := <-timer.C():
+		assert.Equal(t, clockStart.Add(10*time.Second), fired)
+	default:
+		t.Fatal("Timer did not fire")
+	}
+	assert.Equal(t, 0, c.Waiters())
+	assert.False(t, timer.Stop(), "Stop on a fired timer should return false")
+
+	assert.False(t, timer.Reset(time.Second), "Reset on a fired timer should return false")
+	c.Advance(time.Second)
+	assert.Len(t, timer.C(), 1, "Reset timer should fire again")
+}
+
+func TestFakeClock_StoppedTimerDoesNotFire(t *testing.T) {
+	c := NewFakeClock(clockStart)
+	timer := c.NewTimer(time.Second)
+	assert.True(t, timer.Stop())
+	c.Advance(time.Minute)
+	assert.Len(t, timer.C(), 0)
+}
+
+func TestFakeClock_ZeroTimerFiresImmediately(t *testing.T) {
+	c := NewFakeClock(clockStart)
+	assert.Len(t, c.After(0), 1)
+}
+
+func TestFakeClock_TickerDeliversEveryTick(t *testing.T) {
+	c := NewFakeClock(clockStart)
+	ticker := c.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	received := make(chan time.Time, 100)
+	go func() {
+		for i := 0; i < 10; i++ {
+			received <- <-ticker.C()
+		}
+	}()
+
+	c.Advance(10 * time.Second)
+	for i := 1; i <= 10; i++ {
+		select {
+		case tick := <-received:
+			assert.Equal(t, clockStart.Add(time.Duration(i)*time.Second), tick)
+		case <-time.After(time.Second):
+			t.Fatalf("Tick %d not received", i)
+		}
+	}
+}
+
+func TestFakeClock_TickerReset(t *testing.T) {
+	c := NewFakeClock(clockStart)
+	ticker := c.NewTicker(time.Second)
+	ticker.Reset(5 * time.Second)
+
+	c.Advance(4 * time.Second)
+	assert.Len(t, ticker.C(), 0)
+	c.Advance(time.Second)
+	assert.Len(t, ticker.C(), 1)
+
+	ticker.Stop()
+	assert.Equal(t, 0, c.Waiters())
+}
+
+func TestFakeClock_FiresInChronologicalOrder(t *testing.T) {
+	c := NewFakeClock(clockStart)
+	late := c.NewTimer(3 * time.Second)
+	early := c.NewTimer(time.Second)
+
+	c.Advance(5 * time.Second)
+	assert.Equal(t, clockStart.Add(time.Second), <-early.C())
+	assert.Equal(t, clockStart.Add(3*time.Second), <-late.C())
+}
+
+func TestFakeClock_SleepAndBlockUntil(t *testing.T) {
+	c := NewFakeClock(clockStart)
+	done := make(chan bool)
+	go func() {
+		c.Sleep(time.Hour)
+		close(done)
+	}()
+
+	c.BlockUntil(1)
+	c.Advance(time.Hour)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Sleep did not return after advancing the clock")
+	}
+}
+
+func TestFakeClock_ResetDiscardsUnreadFire(t *testing.T) {
+	c := NewFakeClock(clockStart)
+	timer := c.NewTimer(time.Second)
+	c.Advance(time.Second) // fires, but nobody receives
+
+	timer.Reset(5 * time.Second)
+	assert.Len(t, timer.C(), 0, "stale value must be discarded by Reset")
+
+	c.Advance(5 * time.Second)
+	assert.Equal(t, clockStart.Add(6*time.Second), <-timer.C())
+}
+
+func TestFakeClock_StopDiscardsUnreadFire(t *testing.T) {
+	c := NewFakeClock(clockStart)
+	timer := c.NewTimer(time.Second)
+	c.Advance(time.Second)
+	timer.Stop()
+	assert.Len(t, timer.C(), 0, "stale value must be discarded by Stop")
+}
@@ -0,0 +1,232 @@
+package util
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// HSVToRGB converts a colour given by hue (degrees), saturation and
+// value (0..1) to RGB components between 0 and 255.
+func HSVToRGB(h, s, v float64) [3]float64 {
+	h = math.Mod(h, 360)
+	if h < 0 {
+		h += 360
+	}
+	c := v * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := v - c
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	return [3]float64{(r + m) * 255, (g + m) * 255, (b + m) * 255}
+}
+
+// RGBToHSV converts RGB components between 0 and 255 to hue (0..360
+// degrees), saturation and value (0..1). Greys have hue 0.
+func RGBToHSV(rgb [3]float64) (h, s, v float64) {
+	maxC, minC, h := hueOf(rgb)
+	if maxC > 0 {
+		s = (maxC - minC) / maxC
+	}
+	return h, s, maxC
+}
+
+// HSLToRGB converts a colour given by hue (degrees), saturation and
+// lightness (0..1) to RGB components between 0 and 255.
+func HSLToRGB(h, s, l float64) [3]float64 {
+	// HSL is HSV with the value and saturation rescaled.
+	v := l + s*min(l, 1-l)
+	sv := 0.0
+	if v > 0 {
+		sv = 2 * (1 - l/v)
+	}
+	return HSVToRGB(h, sv, v)
+}
+
+// RGBToHSL converts RGB components between 0 and 255 to hue (0..360
+// degrees), saturation and lightness (0..1). Greys have hue 0.
+func RGBToHSL(rgb [3]float64) (h, s, l float64) {
+	maxC, minC, h := hueOf(rgb)
+	l = (maxC + minC) / 2
+	if l > 0 && l < 1 {
+		s = (maxC - l) / min(l, 1-l)
+	}
+	return h, s, l
+}
+
+// hueOf returns the largest and smallest component (0..1) and the hue
+// of rgb.
+func hueOf(rgb [3]float64) (maxC, minC, h float64) {
+	r, g, b := rgb[0]/255, rgb[1]/255, rgb[2]/255
+	maxC, minC = max(r, g, b), min(r, g, b)
+	c := maxC - minC
+	switch {
+	case c == 0:
+		h = 0
+	case maxC == r:
+		h = 60 * math.Mod((g-b)/c, 6)
+	case maxC == g:
+		h = 60 * ((b-r)/c + 2)
+	default:
+		h = 60 * ((r-g)/c + 4)
+	}
+	if h < 0 {
+		h += 360
+	}
+	return maxC, minC, h
+}
+
+// RGBToOKLab converts sRGB components between 0 and 255 to the
+// perceptual OKLab space (lightness 0..1 and the a and b axes), after
+// Björn Ottosson's reference implementation.
+func RGBToOKLab(rgb [3]float64) (l, a, b float64) {
+	toLinear := func(c float64) float64 {
+		c /= 255
+		if c <= 0.04045 {
+			return c / 12.92
+		}
+		return math.Pow((c+0.055)/1.055, 2.4)
+	}
+	r, g, bl := toLinear(rgb[0]), toLinear(rgb[1]), toLinear(rgb[2])
+	lc := math.Cbrt(0.4122214708*r + 0.5363325363*g + 0.0514459929*bl)
+	mc := math.Cbrt(0.2119034982*r + 0.6806995451*g + 0.1073969566*bl)
+	sc := math.Cbrt(0.0883024619*r + 0.2817188376*g + 0.6299787005*bl)
+	return 0.2104542553*lc + 0.7936177850*mc - 0.0040720468*sc,
+		1.9779984951*lc - 2.4285922050*mc + 0.4505937099*sc,
+		0.0259040371*lc + 0.7827717662*mc - 0.8086757660*sc
+}
+
+// OKLabToRGB converts an OKLab colour to sRGB components between 0
+// and 255. Colours outside of the sRGB gamut are clipped.
+func OKLabToRGB(l, a, b float64) [3]float64 {
+	lc := l + 0.3963377774*a + 0.2158037573*b
+	mc := l - 0.1055613458*a - 0.0638541728*b
+	sc := l - 0.0894841775*a - 1.2914855480*b
+	lc, mc, sc = lc*lc*lc, mc*mc*mc, sc*sc*sc
+	fromLinear := func(c float64) float64 {
+		c = min(max(c, 0), 1)
+		if c <= 0.0031308 {
+			return 255 * 12.92 * c
+		}
+		return 255 * (1.055*math.Pow(c, 1/2.4) - 0.055)
+	}
+	return [3]float64{
+		fromLinear(4.0767416621*lc - 3.3077115913*mc + 0.2309699292*sc),
+		fromLinear(-1.2684380046*lc + 2.6097574011*mc - 0.3413193965*sc),
+		fromLinear(-0.0041960863*lc - 0.7034186147*mc + 1.7076147010*sc),
+	}
+}
+
+// KelvinToRGB approximates the colour of a black body at the colour
+// temperature kelvin (1000..40000) with RGB components between 0 and
+// 255, after Tanner Helland's fit of the CIE data.
+func KelvinToRGB(kelvin float64) [3]float64 {
+	t := min(max(kelvin, 1000), 40000) / 100
+	clamp := func(v float64) float64 { return min(max(v, 0), 255) }
+	var r, g, b float64
+	if t <= 66 {
+		r = 255
+		g = 99.4708025861*math.Log(t) - 161.1195681661
+	} else {
+		r = 329.698727446 * math.Pow(t-60, -0.1332047592)
+		g = 288.1221695283 * math.Pow(t-60, -0.0755148492)
+	}
+	switch {
+	case t >= 66:
+		b = 255
+	case t <= 19:
+		b = 0
+	default:
+		b = 138.5177312231*math.Log(t-10) - 305.0447927307
+	}
+	return [3]float64{clamp(r), clamp(g), clamp(b)}
+}
+
+// ParseColor parses a colour notation to RGB components between 0 and
+// 255: "#rrggbb", "hsv(hue, saturation, value)" or "hsl(hue,
+// saturation, lightness)" with the hue in degrees and the other values
+// between 0 and 1, "oklab(lightness, a, b)", or "kelvin(temperature)"
+// with an optional brightness between 0 and 1 as second argument.
+func ParseColor(s string) ([3]float64, error) {
+	s = strings.TrimSpace(s)
+	if hex, ok := strings.CutPrefix(s, "#"); ok {
+		if len(hex) != 6 {
+			return [3]float64{}, fmt.Errorf("invalid colour %q: hex colours must be #rrggbb", s)
+		}
+		v, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil {
+			return [3]float64{}, fmt.Errorf("invalid colour %q: %w", s, err)
+		}
+		return [3]float64{float64(v >> 16), float64(v >> 8 & 0xff), float64(v & 0xff)}, nil
+	}
+
+	name, rest, ok := strings.Cut(s, "(")
+	args, closed := strings.CutSuffix(rest, ")")
+	if !ok || !closed {
+		return [3]float64{}, fmt.Errorf("invalid colour %q: must be #rrggbb, hsv(...), hsl(...), oklab(...) or kelvin(...)", s)
+	}
+	var values []float64
+	for _, arg := range strings.Split(args, ",") {
+		v, err := strconv.ParseFloat(strings.TrimSpace(arg), 64)
+		if err != nil {
+			return [3]float64{}, fmt.Errorf("invalid colour %q: %w", s, err)
+		}
+		values = append(values, v)
+	}
+	notation := strings.ToLower(strings.TrimSpace(name))
+	switch notation {
+	case "hsv", "hsl":
+		third, convert := "value", HSVToRGB
+		if notation == "hsl" {
+			third, convert = "lightness", HSLToRGB
+		}
+		if len(values) != 3 {
+			return [3]float64{}, fmt.Errorf("invalid colour %q: %s needs hue, saturation and %s", s, notation, third)
+		}
+		if values[1] < 0 || values[1] > 1 || values[2] < 0 || values[2] > 1 {
+			return [3]float64{}, fmt.Errorf("invalid colour %q: saturation and %s must be between 0 and 1", s, third)
+		}
+		return convert(values[0], values[1], values[2]), nil
+	case "oklab":
+		if len(values) != 3 {
+			return [3]float64{}, fmt.Errorf("invalid colour %q: oklab needs lightness, a and b", s)
+		}
+		if values[0] < 0 || values[0] > 1 {
+			return [3]float64{}, fmt.Errorf("invalid colour %q: the lightness must be between 0 and 1", s)
+		}
+		return OKLabToRGB(values[0], values[1], values[2]), nil
+	case "kelvin":
+		if len(values) < 1 || len(values) > 2 {
+			return [3]float64{}, fmt.Errorf("invalid colour %q: kelvin needs a temperature and an optional brightness", s)
+		}
+		if values[0] < 1000 || values[0] > 40000 {
+			return [3]float64{}, fmt.Errorf("invalid colour %q: the temperature must be between 1000 and 40000", s)
+		}
+		rgb := KelvinToRGB(values[0])
+		if len(values) == 2 {
+			if values[1] < 0 || values[1] > 1 {
+				return [3]float64{}, fmt.Errorf("invalid colour %q: the brightness must be between 0 and 1", s)
+			}
+			for i := range rgb {
+				rgb[i] *= values[1]
+			}
+		}
+		return rgb, nil
+	default:
+		return [3]float64{}, fmt.Errorf("invalid colour %q: unknown notation %q", s, name)
+	}
+}
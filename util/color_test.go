@@ -0,0 +1,102 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHSVToRGB(t *testing.T) {
+	tests := []struct {
+		h, s, v  float64
+		expected [3]float64
+	}{
+		{0, 1, 1, [3]float64{255, 0, 0}},
+		{120, 1, 1, [3]float64{0, 255, 0}},
+		{240, 1, 0.5, [3]float64{0, 0, 127.5}},
+		{60, 1, 1, [3]float64{255, 255, 0}},
+		{-60, 1, 1, [3]float64{255, 0, 255}},
+		{30, 0, 1, [3]float64{255, 255, 255}},
+	}
+	for _, tc := range tests {
+		rgb := HSVToRGB(tc.h, tc.s, tc.v)
+		assert.InDeltaSlice(t, tc.expected[:], rgb[:], 1e-9, "hsv(%v, %v, %v)", tc.h, tc.s, tc.v)
+	}
+}
+
+// testColors are sample colours for the round trip tests.
+var testColors = [][3]float64{
+	{0, 0, 0}, {255, 255, 255}, {128, 128, 128}, {255, 0, 0}, {0, 255, 0}, {0, 0, 255},
+	{255, 128, 0}, {12, 200, 180}, {90, 30, 200}, {1, 2, 3}, {250, 240, 10},
+}
+
+func TestHSV_RoundTrip(t *testing.T) {
+	for _, rgb := range testColors {
+		back := HSVToRGB(RGBToHSV(rgb))
+		assert.InDeltaSlice(t, rgb[:], back[:], 1e-9, "%v", rgb)
+	}
+	h, s, v := RGBToHSV([3]float64{255, 128, 0})
+	assert.InDelta(t, 30.12, h, 0.01)
+	assert.Equal(t, 1.0, s)
+	assert.Equal(t, 1.0, v)
+}
+
+func TestHSL_RoundTrip(t *testing.T) {
+	for _, rgb := range testColors {
+		back := HSLToRGB(RGBToHSL(rgb))
+		assert.InDeltaSlice(t, rgb[:], back[:], 1e-9, "%v", rgb)
+	}
+	h, s, l := RGBToHSL([3]float64{0, 0, 255})
+	assert.Equal(t, []float64{240, 1, 0.5}, []float64{h, s, l})
+}
+
+func TestOKLab_RoundTrip(t *testing.T) {
+	for _, rgb := range testColors {
+		back := OKLabToRGB(RGBToOKLab(rgb))
+		assert.InDeltaSlice(t, rgb[:], back[:], 1e-3, "%v", rgb)
+	}
+	// Reference values of the OKLab specification
+	l, a, b := RGBToOKLab([3]float64{255, 255, 255})
+	assert.InDeltaSlice(t, []float64{1, 0, 0}, []float64{l, a, b}, 1e-4)
+	l, a, b = RGBToOKLab([3]float64{255, 0, 0})
+	assert.InDeltaSlice(t, []float64{0.628, 0.2249, 0.1258}, []float64{l, a, b}, 1e-3)
+}
+
+func TestKelvinToRGB(t *testing.T) {
+	// Candle light is orange, daylight about white, blue sky bluish.
+	candle := KelvinToRGB(1900)
+	assert.Equal(t, 255.0, candle[0])
+	assert.Less(t, candle[2], candle[1])
+	daylight := KelvinToRGB(6600)
+	assert.InDeltaSlice(t, []float64{255, 255, 255}, daylight[:], 2)
+	sky := KelvinToRGB(12000)
+	assert.Less(t, sky[0], sky[2])
+}
+
+func TestParseColor(t *testing.T) {
+	rgb, err := ParseColor("#FF8000")
+	assert.NoError(t, err)
+	assert.Equal(t, [3]float64{255, 128, 0}, rgb)
+
+	rgb, err = ParseColor("hsv(120, 1, 0.5)")
+	assert.NoError(t, err)
+	assert.InDeltaSlice(t, []float64{0, 127.5, 0}, rgb[:], 1e-9)
+
+	rgb, err = ParseColor("hsl(240, 1, 0.25)")
+	assert.NoError(t, err)
+	assert.InDeltaSlice(t, []float64{0, 0, 127.5}, rgb[:], 1e-9)
+
+	rgb, err = ParseColor("oklab(1, 0, 0)")
+	assert.NoError(t, err)
+	assert.InDeltaSlice(t, []float64{255, 255, 255}, rgb[:], 0.1)
+
+	rgb, err = ParseColor("kelvin(2700, 0.5)")
+	assert.NoError(t, err)
+	assert.Equal(t, 127.5, rgb[0])
+
+	for _, s := range []string{"#ff80", "#gg0000", "red", "hsv(1, 2)", "hsv(0, 1, 2)", "kelvin(500)",
+		"kelvin(2700, 2)", "rgb(1, 2, 3)", "hsv(a, 1, 1)", "hsv(0, 1, 1", "hsl(0, 2, 0.5)", "oklab(2, 0, 0)", "oklab(0.5, 0)"} {
+		_, err := ParseColor(s)
+		assert.Error(t, err, s)
+	}
+}
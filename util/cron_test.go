@@ -0,0 +1,52 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCron_Matches(t *testing.T) {
+	// 2024-06-21 is a Friday.
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 6, day, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		expr     string
+		time     time.Time
+		expected bool
+	}{
+		{"* * * * *", at(21, 13, 7), true},
+		{"30 6 * * *", at(21, 6, 30), true},
+		{"30 6 * * *", at(21, 6, 31), false},
+		{"* 0-5 * * *", at(21, 5, 59), true},
+		{"* 0-5 * * *", at(21, 6, 0), false},
+		{"*/15 * * * *", at(21, 10, 45), true},
+		{"*/15 * * * *", at(21, 10, 46), false},
+		{"10-20/5 * * * *", at(21, 10, 15), true},
+		{"0 8 * * 1-5", at(21, 8, 0), true},
+		{"0 8 * * 1-5", at(22, 8, 0), false},
+		{"0 8 * * 7", at(23, 8, 0), true}, // 7 is Sunday
+		{"0 8 * * 0,6", at(23, 8, 0), true},
+		{"* * 1 6 *", at(21, 1, 0), false},
+		// Both day fields restricted: either one matches.
+		{"* * 1 * 5", at(21, 1, 0), true},
+		{"* * 21 * 1", at(21, 1, 0), true},
+		{"* * 2 * 1", at(21, 1, 0), false},
+	}
+	for _, tc := range tests {
+		spec, err := ParseCron(tc.expr)
+		if assert.NoError(t, err, tc.expr) {
+			assert.Equal(t, tc.expected, spec.Matches(tc.time), "%s at %v", tc.expr, tc.time)
+		}
+	}
+}
+
+func TestParseCron_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *",
+		"* * * 13 *", "* * * * 8", "5-1 * * * *", "*/0 * * * *", "a * * * *"} {
+		_, err := ParseCron(expr)
+		assert.Error(t, err, expr)
+	}
+}
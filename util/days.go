@@ -0,0 +1,58 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// DaySet selects days by a comma separated list of weekday names
+// ("mon".."sun") and the keywords "weekday" (Monday to Friday unless
+// it's a holiday), "weekend" (Saturday, Sunday and holidays) and
+// "holiday". The empty set selects every day.
+type DaySet struct {
+	days                      [7]bool
+	weekday, weekend, holiday bool
+	all                       bool
+}
+
+// ParseDaySet parses a DaySet, see there for the syntax.
+func ParseDaySet(s string) (DaySet, error) {
+	var set DaySet
+	if strings.TrimSpace(s) == "" {
+		set.all = true
+		return set, nil
+	}
+	for _, token := range strings.Split(strings.ToLower(s), ",") {
+		token = strings.TrimSpace(token)
+		switch token {
+		case "weekday":
+			set.weekday = true
+		case "weekend":
+			set.weekend = true
+		case "holiday":
+			set.holiday = true
+		default:
+			day, ok := weekdayNames[token]
+			if !ok {
+				return DaySet{}, fmt.Errorf("invalid day %q: expected mon..sun, weekday, weekend or holiday", token)
+			}
+			set.days[day] = true
+		}
+	}
+	return set, nil
+}
+
+// Matches reports whether the day of date is selected.
+func (s DaySet) Matches(date time.Time, isHoliday bool) bool {
+	if s.all || s.days[date.Weekday()] || (s.holiday && isHoliday) {
+		return true
+	}
+	weekend := date.Weekday() == time.Saturday || date.Weekday() == time.Sunday || isHoliday
+	return (s.weekday && !weekend) || (s.weekend && weekend)
+}
@@ -0,0 +1,40 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDaySet_Matches(t *testing.T) {
+	friday := time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC)
+	saturday := friday.AddDate(0, 0, 1)
+	tests := []struct {
+		days      string
+		date      time.Time
+		isHoliday bool
+		expected  bool
+	}{
+		{"", friday, false, true},
+		{"fri", friday, false, true},
+		{"Mon, Sat", friday, false, false},
+		{"weekday", friday, false, true},
+		{"weekday", friday, true, false},
+		{"weekday", saturday, false, false},
+		{"weekend", saturday, false, true},
+		{"weekend", friday, true, true},
+		{"holiday", friday, false, false},
+		{"holiday", friday, true, true},
+		{"fri", friday, true, true},
+	}
+	for _, tc := range tests {
+		set, err := ParseDaySet(tc.days)
+		if assert.NoError(t, err, tc.days) {
+			assert.Equal(t, tc.expected, set.Matches(tc.date, tc.isHoliday), "%q on %v (holiday %v)", tc.days, tc.date.Weekday(), tc.isHoliday)
+		}
+	}
+
+	_, err := ParseDaySet("mon,someday")
+	assert.Error(t, err)
+}
@@ -0,0 +1,48 @@
+package util
+
+import (
+	"math"
+	"math/bits"
+	"math/cmplx"
+)
+
+// FFT computes the discrete Fourier transform of x in place. The length
+// of x must be a power of two.
+func FFT(x []complex128) {
+	n := len(x)
+	if n < 2 {
+		return
+	}
+	if n&(n-1) != 0 {
+		panic("FFT: length must be a power of two")
+	}
+	// bit reversal permutation
+	shift := 64 - bits.Len(uint(n-1))
+	for i := range n {
+		j := int(bits.Reverse64(uint64(i)) >> shift)
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+	// iterative radix-2 butterflies
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := range size / 2 {
+				even, odd := x[start+k], w*x[start+k+size/2]
+				x[start+k] = even + odd
+				x[start+k+size/2] = even - odd
+				w *= step
+			}
+		}
+	}
+}
+
+// NextPowerOfTwo returns the smallest power of two >= n.
+func NextPowerOfTwo(n int) int {
+	if n <= 1 {
+		return 1
+	}
+	return 1 << bits.Len(uint(n-1))
+}
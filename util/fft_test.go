@@ -0,0 +1,36 @@
+package util
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+func TestFFT(t *testing.T) {
+	const n = 64
+	x := make([]complex128, n)
+	for i := range x {
+		x[i] = complex(math.Cos(2*math.Pi*5*float64(i)/n)+0.5, 0)
+	}
+	FFT(x)
+	for k, v := range x {
+		expected := 0.0
+		switch k {
+		case 0:
+			expected = n / 2
+		case 5, n - 5:
+			expected = n / 2
+		}
+		if math.Abs(cmplx.Abs(v)-expected) > 1e-9 {
+			t.Errorf("Bin %d: expected magnitude %v, got %v", k, expected, cmplx.Abs(v))
+		}
+	}
+}
+
+func TestNextPowerOfTwo(t *testing.T) {
+	for in, expected := range map[int]int{0: 1, 1: 1, 2: 2, 3: 4, 1000: 1024, 1024: 1024} {
+		if got := NextPowerOfTwo(in); got != expected {
+			t.Errorf("NextPowerOfTwo(%d): expected %d, got %d", in, expected, got)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package util
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	mqttConnect    = 1
+	mqttConnAck    = 2
+	mqttPublish    = 3
+	mqttSubscribe  = 8
+	mqttSubAck     = 9
+	mqttPingReq    = 12
+	mqttPingResp   = 13
+	mqttDisconnect = 14
+)
+
+// MQTTSubscriber is a minimal MQTT 3.1.1 client that subscribes to a
+// single topic (filter) with QoS 0 and receives its messages.
+type MQTTSubscriber struct {
+	conn       net.Conn
+	reader     *bufio.Reader
+	writeMutex sync.Mutex
+	stopPing   chan struct{}
+	closeOnce  sync.Once
+}
+
+// MQTTOptions defines the connection to the broker.
+type MQTTOptions struct {
+	Broker    string // host:port
+	ClientID  string
+	Username  string
+	Password  string
+	Topic     string
+	KeepAlive time.Duration
+}
+
+// DialMQTT connects to the broker and subscribes to the topic.
+func DialMQTT(opts MQTTOptions) (*MQTTSubscriber, error) {
+	conn, err := net.DialTimeout("tcp", opts.Broker, 10*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to MQTT broker: %w", err)
+	}
+	s := &MQTTSubscriber{conn: conn, reader: bufio.NewReader(conn), stopPing: make(chan struct{})}
+	if err := s.handshake(opts); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if opts.KeepAlive > 0 {
+		go s.pinger(opts.KeepAlive)
+	}
+	return s, nil
+}
+
+func (s *MQTTSubscriber) handshake(opts MQTTOptions) error {
+	// The broker must answer within 10 seconds.
+	s.conn.SetDeadline(time.Now().Add(10 * time.Second))
+	defer s.conn.SetDeadline(time.Time{})
+
+	flags := byte(0x02) // clean session
+	payload := mqttString(opts.ClientID)
+	if opts.Username != "" {
+		flags |= 0x80
+		payload = append(payload, mqttString(opts.Username)...)
+	}
+	if opts.Password != "" {
+		flags |= 0x40
+		payload = append(payload, mqttString(opts.Password)...)
+	}
+	variable := append(mqttString("MQTT"), 4, flags)
+	variable = binary.BigEndian.AppendUint16(variable, uint16(opts.KeepAlive/time.Second))
+	if err := s.write(mqttConnect<<4, append(variable, payload...)); err != nil {
+		return err
+	}
+	packetType, body, err := s.readPacket()
+	if err != nil {
+		return err
+	}
+	if packetType>>4 != mqttConnAck || len(body) < 2 {
+		return fmt.Errorf("unexpected MQTT packet %#x instead of CONNACK", packetType)
+	}
+	if body[1] != 0 {
+		return fmt.Errorf("MQTT broker refused the connection with code %d", body[1])
+	}
+
+	subscribe := binary.BigEndian.AppendUint16(nil, 1) // packet identifier
+	subscribe = append(append(subscribe, mqttString(opts.Topic)...), 0)
+	if err := s.write(mqttSubscribe<<4|0x02, subscribe); err != nil {
+		return err
+	}
+	for {
+		packetType, body, err := s.readPacket()
+		if err != nil {
+			return err
+		}
+		if packetType>>4 != mqttSubAck {
+			continue
+		}
+		if len(body) < 3 || body[2] == 0x80 {
+			return fmt.Errorf("MQTT broker refused the subscription to %q", opts.Topic)
+		}
+		return nil
+	}
+}
+
+// Next blocks until the next message arrives and returns its topic and
+// payload.
+func (s *MQTTSubscriber) Next() (string, []byte, error) {
+	for {
+		packetType, body, err := s.readPacket()
+		if err != nil {
+			return "", nil, err
+		}
+		if packetType>>4 != mqttPublish {
+			continue
+		}
+		if len(body) < 2 {
+			return "", nil, errors.New("invalid MQTT PUBLISH packet")
+		}
+		topicLen := int(binary.BigEndian.Uint16(body))
+		start := 2 + topicLen
+		if (packetType>>1)&0x03 > 0 {
+			start += 2 // packet identifier of QoS 1 and 2
+		}
+		if start > len(body) {
+			return "", nil, errors.New("invalid MQTT PUBLISH packet")
+		}
+		return string(body[2 : 2+topicLen]), body[start:], nil
+	}
+}
+
+// Close disconnects from the broker. A blocked Next returns an error.
+func (s *MQTTSubscriber) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.stopPing)
+		s.write(mqttDisconnect<<4, nil)
+		err = s.conn.Close()
+	})
+	return err
+}
+
+func (s *MQTTSubscriber) pinger(keepAlive time.Duration) {
+	ticker := time.NewTicker(keepAlive / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stopPing:
+			return
+		case <-ticker.C:
+			if err := s.write(mqttPingReq<<4, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *MQTTSubscriber) write(header byte, body []byte) error {
+	packet := []byte{header}
+	length := len(body)
+	for {
+		b := byte(length % 128)
+		length /= 128
+		if length > 0 {
+			b |= 0x80
+		}
+		packet = append(packet, b)
+		if length == 0 {
+			break
+		}
+	}
+	s.writeMutex.Lock()
+	defer s.writeMutex.Unlock()
+	_, err := s.conn.Write(append(packet, body...))
+	return err
+}
+
+func (s *MQTTSubscriber) readPacket() (byte, []byte, error) {
+	header, err := s.reader.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		b, err := s.reader.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length += int(b&0x7F) * multiplier
+		if b&0x80 == 0 {
+			break
+		}
+		if i == 3 {
+			return 0, nil, errors.New("invalid MQTT remaining length")
+		}
+		multiplier *= 128
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(s.reader, body); err != nil {
+		return 0, nil, err
+	}
+	return header, body, nil
+}
+
+func mqttString(s string) []byte {
+	return append(binary.BigEndian.AppendUint16(nil, uint16(len(s))), s...)
+}
@@ -0,0 +1,101 @@
+package util
+
+import (
+	"bufio"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBroker accepts one client, checks its CONNECT and SUBSCRIBE and
+// publishes messages to it.
+func fakeBroker(t *testing.T, messages ...[]byte) (string, chan []byte) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	received := make(chan []byte, 10)
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		broker := &MQTTSubscriber{conn: conn, reader: bufio.NewReader(conn)}
+		for {
+			header, body, err := broker.readPacket()
+			if err != nil {
+				return
+			}
+			received <- append([]byte{header}, body...)
+			switch header >> 4 {
+			case mqttConnect:
+				broker.write(mqttConnAck<<4, []byte{0, 0})
+			case mqttSubscribe:
+				broker.write(mqttSubAck<<4, []byte{0, 1, 0})
+				for _, message := range messages {
+					broker.write(mqttPublish<<4, message)
+				}
+			case mqttPingReq:
+				broker.write(mqttPingResp<<4, nil)
+			}
+		}
+	}()
+	return listener.Addr().String(), received
+}
+
+func TestMQTTSubscriber(t *testing.T) {
+	publish := append(mqttString("weather/now"), `{"temperature": 21.5}`...)
+	addr, received := fakeBroker(t, publish)
+
+	sub, err := DialMQTT(MQTTOptions{Broker: addr, ClientID: "goleds", Username: "user", Password: "secret", Topic: "weather/#", KeepAlive: 100 * time.Millisecond})
+	require.NoError(t, err)
+
+	connect := <-received
+	assert.Equal(t, byte(mqttConnect<<4), connect[0])
+	assert.Equal(t, append(mqttString("MQTT"), 4, 0xC2, 0, 0), connect[1:11])
+	assert.Equal(t, append(append(mqttString("goleds"), mqttString("user")...), mqttString("secret")...), connect[11:])
+	subscribe := <-received
+	assert.Equal(t, byte(mqttSubscribe<<4|0x02), subscribe[0])
+	assert.Equal(t, append(append([]byte{0, 1}, mqttString("weather/#")...), 0), subscribe[1:])
+
+	topic, payload, err := sub.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "weather/now", topic)
+	assert.Equal(t, `{"temperature": 21.5}`, string(payload))
+
+	// The keep alive pings the broker.
+	select {
+	case ping := <-received:
+		assert.Equal(t, []byte{mqttPingReq << 4}, ping)
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for PINGREQ")
+	}
+
+	// Close unblocks Next.
+	done := make(chan error)
+	go func() {
+		_, _, err := sub.Next()
+		done <- err
+	}()
+	sub.Close()
+	assert.Error(t, <-done)
+}
+
+func TestMQTTSubscriber_RemainingLength(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	sub := &MQTTSubscriber{conn: client, reader: bufio.NewReader(client)}
+	go func() {
+		writer := &MQTTSubscriber{conn: server}
+		// QoS 1 messages carry a packet identifier before the payload.
+		writer.write(mqttPublish<<4|0x02, append(append(mqttString("t"), 0, 7), make([]byte, 300)...))
+	}()
+	topic, payload, err := sub.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "t", topic)
+	assert.Len(t, payload, 300)
+}
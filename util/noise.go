@@ -0,0 +1,73 @@
+package util
+
+import (
+	"math"
+	"math/rand/v2"
+)
+
+// Perlin is two-dimensional gradient noise (Ken Perlin's improved
+// noise) with a permutation drawn from a random source, so the same
+// seed gives the same noise.
+type Perlin struct {
+	perm [512]uint8
+}
+
+// NewPerlin creates the noise with a permutation drawn from rng.
+func NewPerlin(rng *rand.Rand) *Perlin {
+	p := &Perlin{}
+	for i, v := range rng.Perm(256) {
+		p.perm[i] = uint8(v)
+		p.perm[i+256] = uint8(v)
+	}
+	return p
+}
+
+// Noise2D returns the noise at (x, y), a value between -1 and 1 that
+// is 0 at integer coordinates and changes smoothly in between.
+func (p *Perlin) Noise2D(x, y float64) float64 {
+	xf, yf := math.Floor(x), math.Floor(y)
+	xi, yi := int(xf)&255, int(yf)&255
+	x, y = x-xf, y-yf
+	u, v := fade(x), fade(y)
+	aa := p.perm[int(p.perm[xi])+yi]
+	ab := p.perm[int(p.perm[xi])+yi+1]
+	ba := p.perm[int(p.perm[xi+1])+yi]
+	bb := p.perm[int(p.perm[xi+1])+yi+1]
+	n := lerp(v,
+		lerp(u, grad2(aa, x, y), grad2(ba, x-1, y)),
+		lerp(u, grad2(ab, x, y-1), grad2(bb, x-1, y-1)))
+	// The largest value of two-dimensional gradient noise is 1/sqrt(2).
+	return math.Max(-1, math.Min(1, n*math.Sqrt2))
+}
+
+// fade is the quintic smoothstep 6t^5 - 15t^4 + 10t^3.
+func fade(t float64) float64 {
+	return t * t * t * (t*(t*6-15) + 10)
+}
+
+func lerp(t, a, b float64) float64 {
+	return a + t*(b-a)
+}
+
+// grad2 returns the dot product of (x, y) with one of eight gradients
+// selected by hash.
+func grad2(hash uint8, x, y float64) float64 {
+	switch hash & 7 {
+	case 0:
+		return x + y
+	case 1:
+		return -x + y
+	case 2:
+		return x - y
+	case 3:
+		return -x - y
+	case 4:
+		return x
+	case 5:
+		return -x
+	case 6:
+		return y
+	default:
+		return -y
+	}
+}
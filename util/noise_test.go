@@ -0,0 +1,30 @@
+package util
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPerlin_Noise2D(t *testing.T) {
+	p := NewPerlin(rand.New(rand.NewPCG(1, 2)))
+	q := NewPerlin(rand.New(rand.NewPCG(1, 2)))
+	other := NewPerlin(rand.New(rand.NewPCG(3, 4)))
+
+	// Zero at integer coordinates, also negative ones.
+	assert.Equal(t, 0.0, p.Noise2D(3, -7))
+
+	differs := false
+	for i := range 1000 {
+		x, y := float64(i)*0.173, float64(i%37)*0.311-5
+		n := p.Noise2D(x, y)
+		assert.Equal(t, n, q.Noise2D(x, y), "the same seed gives the same noise")
+		assert.GreaterOrEqual(t, n, -1.0)
+		assert.LessOrEqual(t, n, 1.0)
+		// It changes smoothly.
+		assert.InDelta(t, n, p.Noise2D(x+0.001, y), 0.01)
+		differs = differs || n != other.Noise2D(x, y)
+	}
+	assert.True(t, differs, "another seed gives other noise")
+}
@@ -0,0 +1,124 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nathan-osman/go-sunrise"
+)
+
+// TimeAnchor is a time of day, either a clock time ("23:00") or an
+// offset to an astronomical event ("sunset", "sunrise-1h",
+// "civil-dusk+30m"). The zero value is midnight.
+type TimeAnchor struct {
+	event  string        // "" for clock times, otherwise one of sunEvents
+	clock  time.Duration // time since midnight for clock times
+	offset time.Duration // offset to the event
+}
+
+// twilightElevations are the elevations of the sun's centre at the
+// start (dawn, morning) and end (dusk, evening) of the twilight phases.
+var twilightElevations = map[string]float64{
+	"civil":        -6,
+	"nautical":     -12,
+	"astronomical": -18,
+}
+
+// sunEvents are the astronomical events a TimeAnchor can refer to.
+var sunEvents = []string{"sunrise", "sunset", "civil-dawn", "civil-dusk", "nautical-dawn", "nautical-dusk",
+	"astronomical-dawn", "astronomical-dusk", "solar-noon", "solar-midnight"}
+
+// ParseTimeAnchor parses a TimeAnchor. Clock times range from "00:00"
+// to "24:00" (the end of the day).
+func ParseTimeAnchor(s string) (TimeAnchor, error) {
+	s = strings.TrimSpace(s)
+	for _, event := range sunEvents {
+		rest, found := strings.CutPrefix(strings.ToLower(s), event)
+		if !found {
+			continue
+		}
+		anchor := TimeAnchor{event: event}
+		if rest != "" {
+			if rest[0] != '+' && rest[0] != '-' {
+				return TimeAnchor{}, fmt.Errorf("invalid time %q: expected +/- after %s", s, event)
+			}
+			offset, err := time.ParseDuration(rest)
+			if err != nil {
+				return TimeAnchor{}, fmt.Errorf("invalid offset in time %q: %w", s, err)
+			}
+			anchor.offset = offset
+		}
+		return anchor, nil
+	}
+
+	var hour, minute int
+	if n, err := fmt.Sscanf(s, "%d:%d", &hour, &minute); err != nil || n != 2 ||
+		hour < 0 || minute < 0 || minute > 59 || hour*60+minute > 24*60 {
+		return TimeAnchor{}, fmt.Errorf("invalid time %q: expected HH:MM or one of %s with an optional +/-offset",
+			s, strings.Join(sunEvents, ", "))
+	}
+	return TimeAnchor{clock: time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute}, nil
+}
+
+// On returns the time of the anchor on the day of date, in the
+// location of date. Astronomical events are calculated for the given
+// position; ok is false if the event doesn't happen on that day (e.g.
+// no sunset during the polar day). Solar midnight is the one before
+// the solar noon of that day.
+func (a TimeAnchor) On(date time.Time, latitude, longitude float64) (t time.Time, ok bool) {
+	year, month, day := date.Date()
+	var event time.Time
+	switch a.event {
+	case "":
+		hours, minutes := int(a.clock/time.Hour), int(a.clock%time.Hour/time.Minute)
+		// time.Date normalises 24:00 to the next midnight.
+		return time.Date(year, month, day, hours, minutes, 0, 0, date.Location()), true
+	case "sunrise", "sunset":
+		rise, set := sunrise.SunriseSunset(latitude, longitude, year, month, day)
+		if rise.IsZero() || set.IsZero() {
+			return time.Time{}, false
+		}
+		event = rise
+		if a.event == "sunset" {
+			event = set
+		}
+	case "solar-noon", "solar-midnight":
+		event = solarNoon(longitude, year, month, day)
+		if a.event == "solar-midnight" {
+			event = event.Add(-12 * time.Hour)
+		}
+	default:
+		phase, kind, _ := strings.Cut(a.event, "-")
+		morning, evening := sunrise.TimeOfElevation(latitude, longitude, twilightElevations[phase], year, month, day)
+		if morning.IsZero() || evening.IsZero() {
+			return time.Time{}, false
+		}
+		event = morning
+		if kind == "dusk" {
+			event = evening
+		}
+	}
+	return event.In(date.Location()).Add(a.offset), true
+}
+
+// solarNoon returns the time the sun crosses the meridian.
+func solarNoon(longitude float64, year int, month time.Month, day int) time.Time {
+	d := sunrise.MeanSolarNoon(longitude, year, month, day)
+	anomaly := sunrise.SolarMeanAnomaly(d)
+	eclipticLongitude := sunrise.EclipticLongitude(anomaly, sunrise.EquationOfCenter(anomaly), d)
+	return sunrise.JulianDayToTime(sunrise.SolarTransit(d, anomaly, eclipticLongitude))
+}
+
+func (a TimeAnchor) String() string {
+	if a.event == "" {
+		return fmt.Sprintf("%02d:%02d", int(a.clock/time.Hour), int(a.clock%time.Hour/time.Minute))
+	}
+	if a.offset == 0 {
+		return a.event
+	}
+	if a.offset > 0 {
+		return a.event + "+" + a.offset.String()
+	}
+	return a.event + a.offset.String()
+}
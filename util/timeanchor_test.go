@@ -0,0 +1,79 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTimeAnchor(t *testing.T) {
+	for _, s := range []string{"00:00", "23:00", "24:00", "sunrise", "sunset+30m0s", "sunrise-1h0m0s"} {
+		anchor, err := ParseTimeAnchor(s)
+		if assert.NoError(t, err, s) {
+			assert.Equal(t, s, anchor.String())
+		}
+	}
+	for _, s := range []string{"", "24:01", "12:60", "-1:00", "noon", "sunset30m", "sunrise+x"} {
+		_, err := ParseTimeAnchor(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestTimeAnchor_On(t *testing.T) {
+	loc := time.FixedZone("CEST", 2*60*60)
+	date := time.Date(2024, 6, 21, 15, 0, 0, 0, loc)
+
+	anchor, _ := ParseTimeAnchor("23:30")
+	at, ok := anchor.On(date, 0, 0)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2024, 6, 21, 23, 30, 0, 0, loc), at)
+
+	anchor, _ = ParseTimeAnchor("24:00")
+	at, _ = anchor.On(date, 0, 0)
+	assert.Equal(t, time.Date(2024, 6, 22, 0, 0, 0, 0, loc), at)
+
+	// Karlsruhe, sunset on midsummer is at about 21:35 local time.
+	anchor, _ = ParseTimeAnchor("sunset+30m")
+	at, ok = anchor.On(date, 49.014, 8.4043)
+	assert.True(t, ok)
+	assert.Equal(t, loc, at.Location())
+	expected := time.Date(2024, 6, 21, 22, 5, 0, 0, loc)
+	assert.WithinDuration(t, expected, at, 10*time.Minute)
+
+	// No sunset during the polar day.
+	_, ok = anchor.On(date, 80, 0)
+	assert.False(t, ok)
+}
+
+func TestTimeAnchor_Twilight(t *testing.T) {
+	date := time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC)
+	on := func(s string) time.Time {
+		t.Helper()
+		anchor, err := ParseTimeAnchor(s)
+		assert.NoError(t, err, s)
+		at, ok := anchor.On(date, 49.014, 8.4043)
+		assert.True(t, ok, s)
+		return at
+	}
+
+	// The evening events follow each other, the morning events mirror them.
+	sunset, civil, nautical, astronomical := on("sunset"), on("civil-dusk"), on("nautical-dusk"), on("astronomical-dusk")
+	assert.True(t, sunset.Before(civil) && civil.Before(nautical) && nautical.Before(astronomical))
+	assert.True(t, on("astronomical-dawn").Before(on("nautical-dawn")))
+	assert.True(t, on("nautical-dawn").Before(on("civil-dawn")))
+	assert.True(t, on("civil-dawn").Before(on("sunrise")))
+
+	// Solar noon is halfway between sunrise and sunset, about 11:33 UTC
+	// at this longitude on the equinox.
+	noon := on("solar-noon")
+	assert.WithinDuration(t, on("sunrise").Add(on("sunset").Sub(on("sunrise"))/2), noon, time.Minute)
+	assert.WithinDuration(t, time.Date(2024, 3, 20, 11, 33, 0, 0, time.UTC), noon, 5*time.Minute)
+	assert.Equal(t, noon.Add(-12*time.Hour), on("solar-midnight"))
+	assert.Equal(t, civil.Add(15*time.Minute), on("civil-dusk+15m"))
+
+	// No astronomical night in June at 60° north.
+	anchor, _ := ParseTimeAnchor("astronomical-dusk")
+	_, ok := anchor.On(time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC), 60, 10)
+	assert.False(t, ok)
+}
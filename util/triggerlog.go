@@ -0,0 +1,113 @@
+package util
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TriggerRecord is a single sensor event as stored in a recording.
+// Offset is the time since the recording was started, Timestamp the
+// original wall clock time of the trigger.
+type TriggerRecord struct {
+	Offset    time.Duration
+	ID        string
+	Value     int
+	Timestamp time.Time
+}
+
+// triggerRecordJSON is the on-disk form of a TriggerRecord. The
+// offset is written as a duration string ("1.5s") so that recordings
+// can be read and written by hand.
+type triggerRecordJSON struct {
+	Offset    string    `json:"Offset"`
+	ID        string    `json:"ID"`
+	Value     int       `json:"Value"`
+	Timestamp time.Time `json:"Timestamp"`
+}
+
+func (r TriggerRecord) MarshalJSON() ([]byte, error) {
+	return json.Marshal(triggerRecordJSON{
+		Offset:    r.Offset.String(),
+		ID:        r.ID,
+		Value:     r.Value,
+		Timestamp: r.Timestamp,
+	})
+}
+
+func (r *TriggerRecord) UnmarshalJSON(data []byte) error {
+	var raw triggerRecordJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	offset, err := time.ParseDuration(raw.Offset)
+	if err != nil {
+		return fmt.Errorf("invalid Offset %q: %w", raw.Offset, err)
+	}
+	r.Offset = offset
+	r.ID = raw.ID
+	r.Value = raw.Value
+	r.Timestamp = raw.Timestamp
+	return nil
+}
+
+// TriggerRecorder writes sensor events as JSON lines to an io.Writer.
+// It is safe for concurrent use.
+type TriggerRecorder struct {
+	mu    sync.Mutex
+	enc   *json.Encoder
+	start time.Time
+}
+
+// NewTriggerRecorder creates a new TriggerRecorder. All offsets are
+// computed relative to start.
+func NewTriggerRecorder(w io.Writer, start time.Time) *TriggerRecorder {
+	return &TriggerRecorder{
+		enc:   json.NewEncoder(w),
+		start: start,
+	}
+}
+
+// Record appends the trigger to the recording.
+func (r *TriggerRecorder) Record(trigger *Trigger) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.enc.Encode(TriggerRecord{
+		Offset:    trigger.Timestamp.Sub(r.start),
+		ID:        trigger.ID,
+		Value:     trigger.Value,
+		Timestamp: trigger.Timestamp,
+	})
+}
+
+// ReadTriggerLog reads a recording written by a TriggerRecorder. Empty
+// lines and lines starting with '#' are ignored. The records are
+// returned in file order; offsets must not decrease.
+func ReadTriggerLog(r io.Reader) ([]TriggerRecord, error) {
+	var records []TriggerRecord
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		var rec TriggerRecord
+		if err := json.Unmarshal([]byte(text), &rec); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if len(records) > 0 && rec.Offset < records[len(records)-1].Offset {
+			return nil, fmt.Errorf("line %d: offset %s is before previous offset %s", line, rec.Offset, records[len(records)-1].Offset)
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
@@ -0,0 +1,53 @@
+package util
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTriggerRecorder_RoundTrip(t *testing.T) {
+	start := time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC)
+	var buf bytes.Buffer
+	rec := NewTriggerRecorder(&buf, start)
+
+	assert.NoError(t, rec.Record(NewTrigger("S0", 200, start.Add(500*time.Millisecond))))
+	assert.NoError(t, rec.Record(NewTrigger("S1", 600, start.Add(2*time.Second))))
+
+	assert.Contains(t, buf.String(), `"Offset":"500ms"`, "offsets should be written as duration strings")
+
+	records, err := ReadTriggerLog(&buf)
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "S0", records[0].ID)
+	assert.Equal(t, 200, records[0].Value)
+	assert.Equal(t, 500*time.Millisecond, records[0].Offset)
+	assert.True(t, start.Add(500*time.Millisecond).Equal(records[0].Timestamp))
+	assert.Equal(t, "S1", records[1].ID)
+	assert.Equal(t, 2*time.Second, records[1].Offset)
+}
+
+func TestReadTriggerLog_HandWritten(t *testing.T) {
+	log := `
+# comments and empty lines are ignored
+{"Offset": "0s", "ID": "S0", "Value": 300}
+
+{"Offset": "1m30s", "ID": "S3", "Value": 700}
+`
+	records, err := ReadTriggerLog(strings.NewReader(log))
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, 90*time.Second, records[1].Offset)
+	assert.Equal(t, "S3", records[1].ID)
+}
+
+func TestReadTriggerLog_Errors(t *testing.T) {
+	_, err := ReadTriggerLog(strings.NewReader(`{"Offset": "soon", "ID": "S0"}`))
+	assert.ErrorContains(t, err, "line 1")
+
+	_, err = ReadTriggerLog(strings.NewReader("{\"Offset\": \"2s\", \"ID\": \"S0\"}\n{\"Offset\": \"1s\", \"ID\": \"S0\"}"))
+	assert.ErrorContains(t, err, "before previous offset")
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+
+	c "lautenbacher.net/goleds/config"
+	pl "lautenbacher.net/goleds/platform"
+	u "lautenbacher.net/goleds/util"
+)
+
+// zoneRoute is a zone a sensor event is handed to, as the sensor id.
+type zoneRoute struct {
+	zone *pl.ZonePlatform
+	id   string
+}
+
+// startZones starts an App of its own for every zone of conf on its
+// part of a.platform, which must be started with pool, and the
+// go-routines handing the sensor events and the master brightness to
+// the zones.
+func (a *App) startZones(conf *c.Config, pool *sync.Pool) error {
+	strip := pl.NewSharedStrip(a.platform, pool)
+	platforms := make(map[string]*pl.ZonePlatform)
+	names := slices.Sorted(maps.Keys(conf.Zones))
+	for _, name := range names {
+		zoneConf := conf.Zone(name)
+		zoneCfg := conf.Zones[name]
+		platform := strip.NewZone(zoneConf, zoneCfg.Leds())
+		zone := NewApp(a.ossignal)
+		zone.clock = a.clock
+		zone.platform = platform
+		zone.brightness.Send(a.brightness.Value())
+		zone.reset()
+		slog.Info("Starting zone", "zone", name, "leds", platform.GetLedsTotal())
+		if err := zone.start(zoneConf); err != nil {
+			for _, started := range a.zones {
+				started.shutdown()
+			}
+			a.zones = nil
+			return fmt.Errorf("failed to start zone %q: %w", name, err)
+		}
+		a.zones = append(a.zones, zone)
+		platforms[name] = platform
+	}
+	// The web API drives the ApiZone or the first zone with the
+	// producer.
+	apiZones := a.zones
+	if conf.ApiZone != "" {
+		apiZones = []*App{a.zones[slices.Index(names, conf.ApiZone)]}
+	} else {
+		warnSharedAPI(conf, names)
+	}
+	for _, zone := range apiZones {
+		a.alerts.CompareAndSwap(nil, zone.alerts.Load())
+		a.gauge.CompareAndSwap(nil, zone.gauge.Load())
+		a.message.CompareAndSwap(nil, zone.message.Load())
+	}
+
+	routes := make(map[string][]zoneRoute)
+	for name, zone := range conf.Zones {
+		for _, uid := range zone.Sensors {
+			routes[uid] = append(routes[uid], zoneRoute{zone: platforms[name], id: uid})
+			for linked, id := range zone.Links {
+				routes[uid] = append(routes[uid], zoneRoute{zone: platforms[linked], id: id})
+			}
+		}
+	}
+
+	a.shutdownWg.Add(2)
+	go a.dispatchZoneEvents(routes)
+	go a.forwardBrightness()
+	return nil
+}
+
+// apiSections maps the producer sections driven by the web API to
+// their Enabled flag.
+var apiSections = map[string]func(conf *c.Config) bool{
+	"AlertLED":   func(conf *c.Config) bool { return conf.AlertLED.Enabled },
+	"GaugeLED":   func(conf *c.Config) bool { return conf.GaugeLED.Enabled },
+	"MessageLED": func(conf *c.Config) bool { return conf.MessageLED.Enabled },
+}
+
+// warnSharedAPI warns about producers of the web API enabled in more
+// than one of the zones names, only the first one is driven by the
+// API.
+func warnSharedAPI(conf *c.Config, names []string) {
+	for _, section := range slices.Sorted(maps.Keys(apiSections)) {
+		var zones []string
+		for _, name := range names {
+			zone := conf.Zones[name]
+			if apiSections[section](&zone.Config) {
+				zones = append(zones, name)
+			}
+		}
+		if len(zones) > 1 {
+			slog.Warn("Several zones enable the producer, the web API only drives the first one, set ApiZone to choose",
+				"section", section, "zones", zones)
+		}
+	}
+}
+
+// dispatchZoneEvents hands the sensor events of a.platform to the zone
+// of the sensor and, as their linked sensor, to the linked zones.
+func (a *App) dispatchZoneEvents(routes map[string][]zoneRoute) {
+	defer a.shutdownWg.Done()
+	for {
+		select {
+		case event := <-a.platform.GetSensorEvents():
+			if _, ok := routes[event.ID]; !ok {
+				slog.Warn("Sensor belongs to no zone, ignoring it", "sensor", event.ID)
+			}
+			for _, route := range routes[event.ID] {
+				trigger := u.NewTrigger(route.id, event.Value, event.Timestamp)
+				if !route.zone.SendSensorEvent(trigger) {
+					slog.Debug("Zone is busy, dropping sensor event", "sensor", route.id)
+				}
+			}
+		case <-a.stopsignal:
+			return
+		}
+	}
+}
+
+// forwardBrightness hands changes of the master brightness to the
+// zones.
+func (a *App) forwardBrightness() {
+	defer a.shutdownWg.Done()
+	for {
+		select {
+		case <-a.brightness.Channel():
+			for _, zone := range a.zones {
+				zone.brightness.Send(a.brightness.Value())
+			}
+		case <-a.stopsignal:
+			return
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	c "lautenbacher.net/goleds/config"
+	pl "lautenbacher.net/goleds/platform"
+	u "lautenbacher.net/goleds/util"
+)
+
+const zonesTestConfig = `
+Hardware:
+  Display:
+    ForceUpdateDelay: 1s
+    LedsTotal: 20
+  Sensors:
+    SensorCfg:
+      S0: {LedIndex: 0, TriggerValue: 100, SpiMultiplex: P0}
+      S1: {LedIndex: 19, TriggerValue: 100, SpiMultiplex: P0}
+  SpiMultiplexGPIO:
+    P0: {Low: [], High: []}
+Logging:
+  TUI: {Level: WARN, Format: text, File: ""}
+  HW: {Level: WARN, Format: text, File: ""}
+Zones:
+  hall:
+    Segments: [{FirstLed: 0, LastLed: 9}]
+    Sensors: [S0]
+    Links: {stairs: S1}
+    SensorLED: {Enabled: true, RunUpDelay: 10ms, RunDownDelay: 10ms, HoldTime: 500ms, LedRGB: [100, 100, 100], LatchLedRGB: [0, 0, 0]}
+    NightLED: {Enabled: false, LedRGB: [[0, 0, 0]]}
+    ClockLED: {Enabled: false, StartLedHour: 0, EndLedHour: 1, StartLedMinute: 2, EndLedMinute: 3, LedHour: [0, 0, 0], LedMinute: [0, 0, 0]}
+    AudioLED: {Enabled: false, StartLedLeft: 0, EndLedLeft: 1, StartLedRight: 2, EndLedRight: 3, LedGreen: [0, 0, 0], LedYellow: [0, 0, 0], LedRed: [0, 0, 0], SampleRate: 44100, FramesPerBuffer: 1024, UpdateFreq: 10ms, MinDB: -60, MaxDB: -10}
+    CylonLED: {Enabled: false, Duration: 1s, Delay: 20ms, Step: 1, Width: 2, LedRGB: [255, 0, 0]}
+    MultiBlobLED: {Enabled: false, Duration: 1s, Delay: 10ms, BlobCfg: []}
+  stairs:
+    Segments: [{FirstLed: 10, LastLed: 19, Reverse: true}]
+    Sensors: [S1]
+    SensorLED: {Enabled: true, RunUpDelay: 10ms, RunDownDelay: 10ms, HoldTime: 500ms, LedRGB: [0, 100, 0], LatchLedRGB: [0, 0, 0]}
+    NightLED: {Enabled: false, LedRGB: [[0, 0, 0]]}
+    ClockLED: {Enabled: false, StartLedHour: 0, EndLedHour: 1, StartLedMinute: 2, EndLedMinute: 3, LedHour: [0, 0, 0], LedMinute: [0, 0, 0]}
+    AudioLED: {Enabled: false, StartLedLeft: 0, EndLedLeft: 1, StartLedRight: 2, EndLedRight: 3, LedGreen: [0, 0, 0], LedYellow: [0, 0, 0], LedRed: [0, 0, 0], SampleRate: 44100, FramesPerBuffer: 1024, UpdateFreq: 10ms, MinDB: -60, MaxDB: -10}
+    CylonLED: {Enabled: false, Duration: 1s, Delay: 20ms, Step: 1, Width: 2, LedRGB: [255, 0, 0]}
+    MultiBlobLED: {Enabled: false, Duration: 1s, Delay: 10ms, BlobCfg: []}
+`
+
+func TestRender_Zones(t *testing.T) {
+	cfile := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(cfile, []byte(zonesTestConfig), 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	tests := map[string]struct {
+		sensor       string
+		hall, stairs int
+	}{
+		// S0 wakes the linked stairs too, S1 only the stairs.
+		"linked":   {sensor: "S0", hall: 10, stairs: 10},
+		"unlinked": {sensor: "S1", hall: 0, stairs: 10},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			frames, err := render(renderOptions{
+				configFile: cfile,
+				duration:   2 * time.Second,
+				interval:   50 * time.Millisecond,
+				start:      time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC),
+				triggers:   []u.TriggerRecord{{Offset: 100 * time.Millisecond, ID: tc.sensor, Value: 300}},
+			})
+			if err != nil {
+				t.Fatalf("render failed: %v", err)
+			}
+			held := frames[8].Leds
+			if n := litCount(held[:10]); n != tc.hall {
+				t.Errorf("Expected %d lit LEDs in the hall, got %d", tc.hall, n)
+			}
+			if n := litCount(held[10:]); n != tc.stairs {
+				t.Errorf("Expected %d lit LEDs on the stairs, got %d", tc.stairs, n)
+			}
+			if held[19].Green == 0 || held[19].Red != 0 {
+				t.Errorf("Expected the stairs in their own colour, got %v", held[19])
+			}
+			if n := litCount(frames[len(frames)-1].Leds); n != 0 {
+				t.Errorf("Expected a dark strip at the end, got %d lit LEDs", n)
+			}
+		})
+	}
+}
+
+func TestStartZones_ApiZone(t *testing.T) {
+	alert := "    AlertLED: {Enabled: true, Delay: 10ms, Period: 100ms, Repeat: 1, LedRGB: [0, 0, 255], MaxQueue: 4}\n"
+	config := strings.ReplaceAll(zonesTestConfig, "    MultiBlobLED:", alert+"    MultiBlobLED:")
+	tests := map[string]struct {
+		apiZone string
+		owner   int
+	}{
+		// Without ApiZone, the first zone by name drives the API.
+		"first zone": {apiZone: "", owner: 0},
+		"api zone":   {apiZone: "stairs", owner: 1},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfile := filepath.Join(t.TempDir(), "config.yml")
+			if err := os.WriteFile(cfile, []byte(config+"ApiZone: \""+tc.apiZone+"\"\n"), 0o644); err != nil {
+				t.Fatalf("Failed to write config: %v", err)
+			}
+			conf, err := c.ReadConfig(cfile)
+			if err != nil {
+				t.Fatalf("Failed to read config: %v", err)
+			}
+			app := NewApp(make(chan os.Signal, 1))
+			app.clock = u.NewFakeClock(time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC))
+			app.platform = pl.NewHeadlessPlatform(conf)
+			app.reset()
+			if err := app.start(conf); err != nil {
+				t.Fatalf("start failed: %v", err)
+			}
+			defer app.shutdown()
+			if len(app.zones) != 2 {
+				t.Fatalf("Expected 2 zones, got %d", len(app.zones))
+			}
+			if alerts := app.alerts.Load(); alerts == nil || alerts != app.zones[tc.owner].alerts.Load() {
+				t.Errorf("Expected the alerts of zone %d to be driven by the API", tc.owner)
+			}
+		})
+	}
+}